@@ -22,13 +22,33 @@ module.exports = async ({ github, context, workdir }) => {
   body += `|:-----:|:------:|:------:|:-----------:|\n`;
   body += `| ${result.total_tests} | ${result.passed} | ${result.failed} | ${result.regressions} |\n\n`;
 
+  if (result.sections?.length > 0) {
+    body += `| Area | Passed | Failed | Regressions |\n`;
+    body += `|:-----|:------:|:------:|:-----------:|\n`;
+    result.sections.forEach(s => {
+      body += `| ${s.title} | ${s.passed} | ${s.failed} | ${s.regressions || 0} |\n`;
+    });
+    body += `\n`;
+  }
+
   if (result.regressions > 0 && result.comparison?.new_failures) {
     body += `### 🔴 Regressions Detected\n\n`;
     body += `These tests were **passing** in the baseline but are now **failing**:\n\n`;
+    const owners = Object.fromEntries(
+      (result.test_results || []).filter(t => t.owner).map(t => [t.name, t.owner])
+    );
     result.comparison.new_failures.forEach(name => {
-      body += `- \`${name}\`\n`;
+      body += `- \`${name}\`${owners[name] ? ` — owner: ${owners[name]}` : ''}\n`;
     });
     body += `\n`;
+
+    if (result.comparison.suspect_commits?.length > 0) {
+      body += `Recent commits touching tests or config:\n\n`;
+      result.comparison.suspect_commits.forEach(c => {
+        body += `- \`${c.sha.slice(0, 7)}\` ${c.subject} (${c.author})\n`;
+      });
+      body += `\n`;
+    }
   }
 
   if (result.failed > 0) {
@@ -36,7 +56,7 @@ module.exports = async ({ github, context, workdir }) => {
     result.test_results
       .filter(t => t.status === 'failed')
       .forEach(t => {
-        body += `#### \`${t.name}\`\n`;
+        body += `#### \`${t.name}\`${t.owner ? ` (owner: ${t.owner})` : ''}\n`;
         t.checks
           .filter(c => !c.passed)
           .forEach(c => {
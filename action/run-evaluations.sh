@@ -27,8 +27,13 @@ echo "passed=$PASSED" >> $GITHUB_OUTPUT
 echo "failed=$FAILED" >> $GITHUB_OUTPUT
 echo "regressions=$REGRESSIONS" >> $GITHUB_OUTPUT
 
-# Determine result
-if [ "$REGRESSIONS" -gt 0 ]; then
+# Determine result (exit codes 3 and 4 are config and infrastructure errors)
+STATUS=$(jq -r '.status // empty' .regrada/results.json 2>/dev/null)
+if [ "$EXIT_CODE" -ge 3 ]; then
+  echo "result=error" >> $GITHUB_OUTPUT
+elif [ -n "$STATUS" ]; then
+  echo "result=$STATUS" >> $GITHUB_OUTPUT
+elif [ "$REGRESSIONS" -gt 0 ]; then
   echo "result=regression" >> $GITHUB_OUTPUT
 elif [ "$FAILED" -gt 0 ]; then
   echo "result=failure" >> $GITHUB_OUTPUT
@@ -47,7 +52,9 @@ echo "| Failed | $FAILED |" >> $GITHUB_STEP_SUMMARY
 echo "| Regressions | $REGRESSIONS |" >> $GITHUB_STEP_SUMMARY
 
 # Determine exit code based on inputs
-if [ "$3" = "true" ] && [ "$REGRESSIONS" -gt 0 ]; then
+if [ "$EXIT_CODE" -ge 3 ]; then
+  echo "exit_code=1" >> $GITHUB_OUTPUT
+elif [ "$3" = "true" ] && [ "$REGRESSIONS" -gt 0 ]; then
   echo "exit_code=1" >> $GITHUB_OUTPUT
 elif [ "$4" = "true" ] && [ "$FAILED" -gt 0 ]; then
   echo "exit_code=1" >> $GITHUB_OUTPUT
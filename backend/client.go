@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// Upload kinds accepted by the backend.
+const (
+	KindSessions = "sessions"
+	KindResults  = "results"
+)
+
+// Client uploads traces and results to the Regrada backend.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a backend client from the project configuration.
+func NewClient(cfg config.BackendConfig) (*Client, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("backend url is not configured")
+	}
+
+	apiKeyEnv := cfg.APIKeyEnv
+	if apiKeyEnv == "" {
+		apiKeyEnv = "REGRADA_API_KEY"
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(cfg.URL, "/"),
+		apiKey:     os.Getenv(apiKeyEnv),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Upload sends a batch of payloads of the given kind to the backend.
+func (c *Client) Upload(kind string, items []json.RawMessage) error {
+	body, err := json.Marshal(map[string]interface{}{"items": items})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(http.MethodPost, fmt.Sprintf("/v1/%s/batch", kind), body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("backend returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+func (c *Client) do(method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return c.httpClient.Do(req)
+}
+
+// ProjectStore keeps a project's baselines in the backend, so CI runners need no local
+// state. The default baseline, baseline.json, is the project's "default" baseline and
+// baselines/<name>.json its named ones.
+type ProjectStore struct {
+	client  *Client
+	project string
+}
+
+// NewProjectStore creates a store for the project's baselines.
+func NewProjectStore(cfg config.BackendConfig, project string) (*ProjectStore, error) {
+	if project == "" {
+		return nil, fmt.Errorf("project is not configured")
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ProjectStore{client: client, project: project}, nil
+}
+
+// Get downloads the baseline stored under key.
+func (s *ProjectStore) Get(key string) ([]byte, error) {
+	resp, err := s.client.do(http.MethodGet, s.path(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", s.Location(key), ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put uploads a baseline under key, replacing the previous one.
+func (s *ProjectStore) Put(key string, data []byte) error {
+	resp, err := s.client.do(http.MethodPut, s.path(key), data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// Location returns the backend URL of the baseline under key.
+func (s *ProjectStore) Location(key string) string {
+	return s.client.baseURL + s.path(key)
+}
+
+func (s *ProjectStore) path(key string) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(key, "baselines/"), ".json")
+	if key == "baseline.json" {
+		name = "default"
+	}
+	return "/v1/projects/" + url.PathEscape(s.project) + "/baselines/" + url.PathEscape(name)
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/matias/regrada/config"
+)
+
+// OutboxDir is where pending uploads are persisted until `regrada sync` pushes them.
+var OutboxDir = filepath.Join(".regrada", "outbox")
+
+// Entry is a pending upload stored in the outbox.
+type Entry struct {
+	Kind    string
+	ID      string
+	Path    string
+	Payload json.RawMessage
+}
+
+// Enqueue persists a payload in the outbox. Entries are keyed by kind and ID,
+// so enqueuing the same item twice keeps a single copy.
+func Enqueue(kind, id string, payload interface{}) error {
+	if err := os.MkdirAll(OutboxDir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(OutboxDir, fmt.Sprintf("%s-%s.json", kind, id)), data, 0644)
+}
+
+// Pending returns all queued uploads, ordered by kind and ID.
+func Pending() ([]Entry, error) {
+	files, err := filepath.Glob(filepath.Join(OutboxDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	entries := make([]Entry, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".json")
+		idx := strings.Index(name, "-")
+		if idx <= 0 {
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read outbox entry %s: %w", file, err)
+		}
+
+		entries = append(entries, Entry{
+			Kind:    name[:idx],
+			ID:      name[idx+1:],
+			Path:    file,
+			Payload: data,
+		})
+	}
+
+	return entries, nil
+}
+
+// Remove deletes an entry from the outbox once it has been uploaded.
+func Remove(entry Entry) error {
+	return os.Remove(entry.Path)
+}
+
+// Submit uploads a payload immediately when the backend is enabled, and queues it
+// in the outbox when the backend is disabled or the upload fails. It returns
+// true if the payload was uploaded. Projects without a backend URL are ignored.
+func Submit(cfg config.BackendConfig, kind, id string, payload interface{}) (bool, error) {
+	if cfg.URL == "" {
+		return false, nil
+	}
+
+	if cfg.Enabled {
+		client, err := NewClient(cfg)
+		if err == nil {
+			data, err := json.Marshal(payload)
+			if err == nil && client.Upload(kind, []json.RawMessage{data}) == nil {
+				return true, nil
+			}
+		}
+	}
+
+	return false, Enqueue(kind, id, payload)
+}
@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/sigv4"
+)
+
+// ErrNotFound is returned by Store.Get when the object does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Store keeps objects, such as baselines, outside the local .regrada directory. Keys
+// are slash-separated paths such as baselines/prod.json.
+type Store interface {
+	// Get downloads the object stored under key, or returns ErrNotFound.
+	Get(key string) ([]byte, error)
+	// Put uploads data under key, replacing any existing object.
+	Put(key string, data []byte) error
+	// Location describes where key is stored, for messages.
+	Location(key string) string
+}
+
+// BucketStore keeps objects in an S3 or GCS bucket. Both are reached through their
+// S3-style XML API: S3 requests are signed with SigV4 and GCS requests carry an
+// OAuth access token.
+type BucketStore struct {
+	cfg        config.RemoteConfig
+	endpoint   *url.URL
+	region     string
+	httpClient *http.Client
+}
+
+// NewBucketStore creates a store for the bucket in cfg.
+func NewBucketStore(cfg config.RemoteConfig) (*BucketStore, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("remote bucket is not configured")
+	}
+
+	s := &BucketStore{cfg: cfg, httpClient: &http.Client{Timeout: 30 * time.Second}}
+	endpoint := cfg.Endpoint
+	switch cfg.Type {
+	case "s3":
+		s.region = cfg.Region
+		if s.region == "" {
+			s.region = os.Getenv("AWS_REGION")
+		}
+		if s.region == "" {
+			s.region = "us-east-1"
+		}
+		if endpoint == "" {
+			endpoint = "https://s3." + s.region + ".amazonaws.com"
+		}
+	case "gcs":
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported remote type %q (must be s3 or gcs)", cfg.Type)
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote endpoint: %w", err)
+	}
+	s.endpoint = u
+	return s, nil
+}
+
+// Location returns the URL of key, e.g. s3://bucket/prefix/key.
+func (s *BucketStore) Location(key string) string {
+	return s.cfg.Type + "://" + s.cfg.Bucket + "/" + s.objectKey(key)
+}
+
+// Get downloads the object stored under key.
+func (s *BucketStore) Get(key string) ([]byte, error) {
+	resp, err := s.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", s.Location(key), ErrNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Put uploads data under key, replacing any existing object.
+func (s *BucketStore) Put(key string, data []byte) error {
+	resp, err := s.do(http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func (s *BucketStore) objectKey(key string) string {
+	return path.Join(strings.Trim(s.cfg.Prefix, "/"), key)
+}
+
+// do sends an authenticated request for the object under key. Buckets are addressed
+// by path, which S3-compatible stores also accept.
+func (s *BucketStore) do(method, key string, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.cfg.Bucket + "/" + s.objectKey(key)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	switch s.cfg.Type {
+	case "s3":
+		creds, err := sigv4.CredentialsFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("s3: %w", err)
+		}
+		sigv4.Sign(req, body, "s3", s.region, creds, time.Now())
+	case "gcs":
+		tokenEnv := s.cfg.TokenEnv
+		if tokenEnv == "" {
+			tokenEnv = "GOOGLE_OAUTH_ACCESS_TOKEN"
+		}
+		token := os.Getenv(tokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("gcs: %s must be set to an OAuth access token", tokenEnv)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return s.httpClient.Do(req)
+}
+
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s returned %d: %s", resp.Request.URL.Host, resp.StatusCode, strings.TrimSpace(string(msg)))
+}
@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/proxy"
+	"github.com/matias/regrada/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	abConfigA   string
+	abConfigB   string
+	abTestsPath string
+)
+
+var abCmd = &cobra.Command{
+	Use:   "ab -- <command>",
+	Short: "Compare two configurations head-to-head",
+	Long: `Trace your command under two configurations back-to-back, run the test suite
+against both sessions, and report per-test wins, losses, and ties along with
+the significance of the difference (two-sided sign test).`,
+	Args: cobra.ArbitraryArgs,
+	Run:  runAB,
+}
+
+func init() {
+	rootCmd.AddCommand(abCmd)
+
+	abCmd.Flags().StringVar(&abConfigA, "config-a", config.DefaultPath, "Path to config A")
+	abCmd.Flags().StringVar(&abConfigB, "config-b", "", "Path to config B")
+	abCmd.Flags().StringVarP(&abTestsPath, "tests", "t", "", "Path to test suite")
+
+	abCmd.Flags().SetInterspersed(false)
+}
+
+func runAB(cmd *cobra.Command, args []string) {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no command specified after --\n")
+		os.Exit(1)
+	}
+	if abConfigB == "" {
+		fmt.Fprintf(os.Stderr, "Error: --config-b is required\n")
+		os.Exit(1)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	fmt.Println()
+	fmt.Println(titleStyle.Render("Regrada A/B"))
+	fmt.Println(dimStyle.Render(fmt.Sprintf("Comparing %s against %s...", abConfigA, abConfigB)))
+	fmt.Println()
+
+	cfgA, err := config.Load(abConfigA)
+	if err != nil {
+		fmt.Printf("%s Failed to load config A: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	cfgB, err := config.Load(abConfigB)
+	if err != nil {
+		fmt.Printf("%s Failed to load config B: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	if abTestsPath == "" {
+		abTestsPath = filepath.Join(cfgA.Evals.Path, "tests.yaml")
+	}
+
+	suite, err := eval.LoadSuite(abTestsPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	sessionA, err := captureWithConfig(cfgA, args)
+	if err != nil {
+		fmt.Printf("%s Run A failed: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	sessionB, err := captureWithConfig(cfgB, args)
+	if err != nil {
+		fmt.Printf("%s Run B failed: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	traceDir := filepath.Join(".regrada", "traces")
+	configs := []*config.RegradaConfig{cfgA, cfgB}
+	for i, session := range []*trace.TraceSession{sessionA, sessionB} {
+		label := string(rune('a' + i))
+		compress := configs[i].Storage.Compression == "gzip"
+		path := filepath.Join(traceDir, trace.FileName(session.ID+"-"+label, compress))
+		if err := trace.Save(session, path); err != nil {
+			fmt.Printf("%s Failed to save session %s: %v\n", failStyle.Render("✗"), strings.ToUpper(label), err)
+		}
+	}
+
+	if edits := eval.PromptEdits(eval.PromptFiles(sessionA), eval.PromptFiles(sessionB)); len(edits) > 0 {
+		fmt.Println()
+		fmt.Println(dimStyle.Render("System prompt differs between A and B:"))
+		for _, edit := range edits {
+			fmt.Println(dimStyle.Render("  - " + edit))
+		}
+	}
+
+	var wins, losses, ties int
+	fmt.Println()
+	fmt.Println("Results:")
+	for _, test := range suite.Tests {
+		scoreA := abScore(test, sessionA)
+		scoreB := abScore(test, sessionB)
+
+		var outcome string
+		switch {
+		case scoreA > scoreB:
+			wins++
+			outcome = successStyle.Render("A wins")
+		case scoreB > scoreA:
+			losses++
+			outcome = failStyle.Render("B wins")
+		default:
+			ties++
+			outcome = dimStyle.Render("tie")
+		}
+		fmt.Printf("  %-40s A %.2f  B %.2f  %s\n", test.Name, scoreA, scoreB, outcome)
+	}
+
+	fmt.Println()
+	fmt.Printf("  A wins: %d  B wins: %d  Ties: %d\n", wins, losses, ties)
+
+	pValue := signTestPValue(wins, losses)
+	if pValue < 0.05 {
+		fmt.Printf("  Difference is significant (p = %.4f)\n", pValue)
+	} else {
+		fmt.Printf("  %s\n", dimStyle.Render(fmt.Sprintf("Difference is not significant (p = %.4f)", pValue)))
+	}
+	fmt.Println()
+}
+
+// captureWithConfig runs the command behind a proxy built from cfg and returns the captured session.
+func captureWithConfig(cfg *config.RegradaConfig, args []string) (*trace.TraceSession, error) {
+	prox, err := proxy.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start proxy: %w", err)
+	}
+	defer prox.Shutdown()
+
+	session := &trace.TraceSession{
+		ID:        generateTraceID(),
+		StartTime: time.Now(),
+		Command:   strings.Join(args, " "),
+		Metadata:  sessionMetadata(cfg),
+	}
+
+	exitCode := executeCommand(args, buildProxyEnv(prox.Address(), cfg))
+	session.EndTime = time.Now()
+	session.Traces = prox.Traces()
+	session.Summary = summarizeSession(session.Traces, cfg)
+
+	if exitCode != 0 {
+		return nil, fmt.Errorf("command exited with code %d", exitCode)
+	}
+	return session, nil
+}
+
+// abScore returns the fraction of a test's evaluated checks that pass against a
+// session. Tests whose trace cannot be resolved score zero.
+func abScore(test eval.TestCase, session *trace.TraceSession) float64 {
+	result := eval.RunTestInSession(test, session)
+	if result.Status == "error" {
+		return 0
+	}
+
+	passed, evaluated := 0, 0
+	for _, cr := range result.CheckResults {
+		if cr.Skipped {
+			continue
+		}
+		evaluated++
+		if cr.Passed {
+			passed++
+		}
+	}
+	if evaluated == 0 {
+		if result.Status == "passed" {
+			return 1
+		}
+		return 0
+	}
+	return float64(passed) / float64(evaluated)
+}
+
+// signTestPValue returns the two-sided exact sign test p-value for the given win/loss counts.
+// Ties are excluded by the caller.
+func signTestPValue(wins, losses int) float64 {
+	n := wins + losses
+	if n == 0 {
+		return 1
+	}
+
+	k := wins
+	if losses < k {
+		k = losses
+	}
+
+	// P(X <= k) for X ~ Binomial(n, 0.5), computed in log space
+	tail := 0.0
+	for i := 0; i <= k; i++ {
+		tail += math.Exp(logChoose(n, i) - float64(n)*math.Ln2)
+	}
+
+	return math.Min(1, 2*tail)
+}
+
+func logChoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	acceptConfigPath   string
+	acceptSessionPath  string
+	acceptTestsPath    string
+	acceptSample       int
+	acceptStrategy     string
+	acceptParameterize bool
+)
+
+var acceptCmd = &cobra.Command{
+	Use:   "accept",
+	Short: "Convert a sample of recorded traces into tests",
+	Long: `Select a representative sample of traces from the latest trace session and
+add a test stub for each one, so the suite keeps tracking evolving traffic
+without accepting every captured call.`,
+	Args: cobra.NoArgs,
+	Run:  runAccept,
+}
+
+func init() {
+	rootCmd.AddCommand(acceptCmd)
+
+	acceptCmd.Flags().StringVarP(&acceptConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	acceptCmd.Flags().StringVarP(&acceptSessionPath, "session", "s", "", "Trace session file (default: latest in .regrada/traces)")
+	acceptCmd.Flags().StringVarP(&acceptTestsPath, "tests", "t", "", "Path to test suite")
+	acceptCmd.Flags().IntVar(&acceptSample, "sample", 0, "Number of traces to accept (0 = all)")
+	acceptCmd.Flags().StringVar(&acceptStrategy, "strategy", "diverse", "Sampling strategy: diverse, random, first")
+	acceptCmd.Flags().BoolVar(&acceptParameterize, "parameterize", false, "Collapse near-duplicate traces into one test with a dataset")
+}
+
+func runAccept(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+	cfg, err := config.Load(acceptConfigPath)
+	if err != nil {
+		fmt.Printf("%s Config not found, using defaults\n", warnStyle.Render("Warning:"))
+		cfg = config.Defaults(".")
+	}
+
+	var session *trace.TraceSession
+	if acceptSessionPath != "" {
+		session, err = trace.Load(acceptSessionPath)
+	} else {
+		session, err = eval.LoadLatestSession()
+	}
+	if err != nil {
+		fmt.Printf("%s Failed to load trace session: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	indices, err := eval.SampleTraces(session, acceptSample, acceptStrategy)
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	if acceptTestsPath == "" {
+		acceptTestsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+
+	stubs := eval.GenerateTestStubs(session)
+	sampled := &eval.TestSuite{
+		Name:        stubs.Name,
+		Description: stubs.Description,
+		Tests:       make([]eval.TestCase, 0, len(indices)),
+	}
+	for _, i := range indices {
+		test := stubs.Tests[i]
+		test.TraceID = session.Traces[i].ID
+		sampled.Tests = append(sampled.Tests, test)
+	}
+
+	if acceptParameterize {
+		sampled.Tests = eval.ParameterizeStubs(sampled.Tests, session)
+	}
+
+	if err := handleTestGeneration(sampled, acceptTestsPath, "merge"); err != nil {
+		fmt.Printf("%s Failed to write tests: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s Accepted %d of %d traces (%s) as %d tests in %s\n",
+		successStyle.Render("✓"), len(indices), len(session.Traces), acceptStrategy, len(sampled.Tests), acceptTestsPath)
+}
@@ -0,0 +1,474 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/backend"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+	"github.com/matias/regrada/vcs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	baselineConfigPath string
+	baselineTestsPath  string
+	baselinePath       string
+	baselineName       string
+	baselineDryRun     bool
+	baselineTags       []string
+	baselineExclude    []string
+	baselineResults    string
+	baselineTests      []string
+	baselineGit        bool
+)
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage baselines and stored trace sessions",
+}
+
+var baselineListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the default and named baselines",
+	Long: `List the default baseline and the named baselines in .regrada/baselines, saved
+with 'regrada run --save-baseline --baseline-name <name>' and selected with
+--baseline-name. Matrix entries keep their baselines there too.`,
+	Args: cobra.NoArgs,
+	Run:  runBaselineList,
+}
+
+var baselineShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show where a baseline came from",
+	Long: `Show the default baseline, or the named one, with its provenance: the git commit
+and branch, regrada version, and provider/model it was recorded with.`,
+	Args: cobra.MaximumNArgs(1),
+	Run:  runBaselineShow,
+}
+
+var baselinePromoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Accept the latest results as the baseline",
+	Long: `Copy the latest results (.regrada/results.json) into the baseline, or into the
+named baseline with --baseline-name. With --test, only those tests' results are
+promoted and the rest of the baseline is kept. With --git, the baseline file is
+staged for commit.`,
+	Args: cobra.NoArgs,
+	Run:  runBaselinePromote,
+}
+
+var baselineGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Prune stale baseline entries and deduplicate recorded payloads",
+	Long: `Remove baseline results for tests that no longer exist in the suite, and
+store request and response bodies that several trace sessions recorded once, in
+.regrada/traces/objects, keyed by their SHA-256. Every session file is kept and
+loads as before.`,
+	Args: cobra.NoArgs,
+	Run:  runBaselineGC,
+}
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+	baselineCmd.AddCommand(baselineGCCmd)
+	baselineCmd.AddCommand(baselineListCmd)
+	baselineCmd.AddCommand(baselineShowCmd)
+	baselineCmd.AddCommand(baselinePromoteCmd)
+
+	baselineCmd.PersistentFlags().StringVarP(&baselineConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	baselineCmd.PersistentFlags().StringVarP(&baselineTestsPath, "tests", "t", "", "Path to test suite")
+	baselineCmd.PersistentFlags().StringVarP(&baselinePath, "baseline", "b", filepath.Join(".regrada", "baseline.json"), "Path to baseline")
+	baselineGCCmd.Flags().StringVar(&baselineName, "baseline-name", "", "Prune the named baseline in .regrada/baselines instead")
+
+	baselinePromoteCmd.Flags().StringVar(&baselineResults, "results", filepath.Join(".regrada", "results.json"), "Results to promote")
+	baselinePromoteCmd.Flags().StringArrayVar(&baselineTests, "test", nil, "Only promote this test's result (repeatable)")
+	baselinePromoteCmd.Flags().StringVar(&baselineName, "baseline-name", "", "Promote to the named baseline in .regrada/baselines")
+	baselinePromoteCmd.Flags().BoolVar(&baselineGit, "git", false, "Stage the baseline with git add")
+
+	baselineGCCmd.Flags().BoolVar(&baselineDryRun, "dry-run", false, "Show what would be removed without deleting anything")
+	baselineGCCmd.Flags().StringSliceVar(&baselineTags, "tags", nil, "Only prune baseline results with one of these tags (comma-separated)")
+	baselineGCCmd.Flags().StringSliceVar(&baselineExclude, "exclude-tags", nil, "Keep baseline results with any of these tags (comma-separated)")
+}
+
+func runBaselineGC(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	cfg, err := config.Load(baselineConfigPath)
+	if err != nil {
+		cfg = config.Defaults(".")
+	}
+	if baselineTestsPath == "" {
+		baselineTestsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+
+	suite, err := eval.LoadSuite(baselineTestsPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	if baselineName != "" {
+		baselinePath = namedBaselinePath(baselineName)
+	}
+	store, err := commandBaselineStore(cmd, cfg)
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	verb := "Removed"
+	if baselineDryRun {
+		verb = "Would remove"
+	}
+
+	pruned, err := pruneBaseline(store, baselinePath, suite, baselineDryRun)
+	if err != nil {
+		fmt.Printf("%s Failed to prune baseline: %v\n", failStyle.Render("✗"), err)
+	}
+	for _, name := range pruned {
+		fmt.Printf("  %s baseline result for %s\n", dimStyle.Render(verb), name)
+	}
+
+	var stats trace.DedupeStats
+	files, err := filepath.Glob(filepath.Join(".regrada", "traces", "*.json*"))
+	if err == nil {
+		stats, err = trace.DedupeBodies(files, baselineDryRun)
+	}
+	if err != nil {
+		fmt.Printf("%s Failed to deduplicate trace payloads: %v\n", failStyle.Render("✗"), err)
+	}
+
+	shared := "Shared"
+	if baselineDryRun {
+		shared = "Would share"
+	}
+	fmt.Println()
+	fmt.Printf("%s %s %d stale baseline results\n", successStyle.Render("✓"), verb, len(pruned))
+	fmt.Printf("%s %s %d duplicate payloads across %d sessions as %d stored objects (%s saved)\n",
+		successStyle.Render("✓"), shared, stats.Bodies, stats.Sessions, stats.Objects, formatBytes(stats.BytesSaved))
+	if stats.RemovedObjects > 0 {
+		fmt.Printf("  %s %d unreferenced objects\n", dimStyle.Render(verb), stats.RemovedObjects)
+	}
+}
+
+func runBaselinePromote(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	current, err := eval.LoadResults(baselineResults)
+	if err != nil {
+		fmt.Printf("%s Failed to load results (run 'regrada run' first): %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	if baselineName != "" {
+		baselinePath = namedBaselinePath(baselineName)
+	}
+
+	cfg, err := config.Load(baselineConfigPath)
+	if err != nil {
+		cfg = config.Defaults(".")
+	}
+	store, err := commandBaselineStore(cmd, cfg)
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	// A missing baseline is created from the promoted results
+	previous, err := loadBaseline(store, baselinePath)
+	if err != nil {
+		fmt.Printf("%s Failed to load baseline: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	promoted, err := eval.Promote(previous, current, baselineTests)
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	location, err := saveBaseline(store, promoted, baselinePath)
+	if err != nil {
+		fmt.Printf("%s Failed to save baseline: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	count := len(current.TestResults)
+	if len(baselineTests) > 0 {
+		count = len(baselineTests)
+	}
+	fmt.Printf("%s Promoted %d test results to %s\n", successStyle.Render("✓"), count, location)
+	for _, name := range baselineTests {
+		fmt.Printf("  %s\n", dimStyle.Render(name))
+	}
+
+	if baselineGit && store != nil {
+		fmt.Printf("%s\n", dimStyle.Render("Nothing to stage, the baseline is kept in the remote store"))
+	} else if baselineGit {
+		if err := vcs.Stage(baselinePath); err != nil {
+			fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n", dimStyle.Render("Staged "+baselinePath+" for commit"))
+	}
+}
+
+func runBaselineList(cmd *cobra.Command, args []string) {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	named, _ := filepath.Glob(filepath.Join(".regrada", "baselines", "*.json"))
+	entries := append([]string{baselinePath}, named...)
+
+	found := 0
+	for i, path := range entries {
+		result, err := eval.LoadResults(path)
+		if err != nil {
+			continue
+		}
+		found++
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		if i == 0 {
+			name = "(default)"
+		}
+		fmt.Printf("  %-24s %s\n", name, dimStyle.Render(fmt.Sprintf("%d/%d passed, saved %s  %s",
+			result.Passed, result.TotalTests-result.Skipped, result.Timestamp.Format("2006-01-02 15:04"), path)))
+	}
+	if found == 0 {
+		fmt.Println(dimStyle.Render("No baselines saved yet"))
+	}
+}
+
+func runBaselineShow(cmd *cobra.Command, args []string) {
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	path := baselinePath
+	if len(args) > 0 {
+		path = namedBaselinePath(args[0])
+	}
+	cfg, err := config.Load(baselineConfigPath)
+	if err != nil {
+		cfg = config.Defaults(".")
+	}
+	store, err := commandBaselineStore(cmd, cfg)
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	result, err := loadBaseline(store, path)
+	if err == nil && result == nil {
+		err = fmt.Errorf("no baseline at %s", path)
+	}
+	if err != nil {
+		fmt.Printf("%s Failed to load baseline: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	if store != nil {
+		path = store.Location(baselineKey(path))
+	}
+
+	unknown := dimStyle.Render("unknown")
+	field := func(label, value string) {
+		if value == "" {
+			value = unknown
+		}
+		fmt.Printf("  %-16s %s\n", label, value)
+	}
+	p := result.Provenance
+	if p == nil {
+		p = &eval.Provenance{}
+	}
+	field("Path", path)
+	field("Saved", result.Timestamp.Format("2006-01-02 15:04:05 MST"))
+	field("Commit", p.GitSHA)
+	field("Branch", p.GitBranch)
+	field("Regrada version", p.Version)
+	field("Provider", p.Provider)
+	field("Model", p.Model)
+	if result.PromptSHA256 != "" {
+		field("System prompt", result.PromptSHA256)
+	}
+	field("Tests", fmt.Sprintf("%d passed, %d failed, %d skipped", result.Passed, result.Failed, result.Skipped))
+}
+
+// resultProvenance records the commit, regrada version, and provider/model a session
+// was evaluated with. The model is the one the session's calls used, or the configured
+// one when they used several.
+func resultProvenance(cfg *config.RegradaConfig, session *trace.TraceSession) *eval.Provenance {
+	p := &eval.Provenance{
+		Version:  version,
+		Provider: cfg.Provider.Type,
+		Model:    cfg.Provider.Model,
+	}
+	if len(session.Summary.ByModel) == 1 {
+		for model := range session.Summary.ByModel {
+			p.Model = model
+		}
+	}
+	if rev, err := vcs.CurrentRevision(); err == nil {
+		p.GitSHA, p.GitBranch = rev.SHA, rev.Branch
+	}
+	return p
+}
+
+// namedBaselinePath is where a named baseline, such as a matrix entry's, is kept.
+func namedBaselinePath(name string) string {
+	return filepath.Join(".regrada", "baselines", fileLabel(name)+".json")
+}
+
+// pruneBaseline drops baseline results for tests that are no longer in the suite and
+// match the --tags filter, and returns their names. Trace-session baselines have no per-test entries and are left alone.
+func pruneBaseline(store backend.Store, path string, suite *eval.TestSuite, dryRun bool) ([]string, error) {
+	baseline, err := loadBaseline(store, path)
+	if err != nil || baseline == nil || baseline.TestResults == nil {
+		return nil, err
+	}
+
+	current := make(map[string]bool)
+	for _, test := range suite.Tests {
+		current[test.Name] = true
+	}
+
+	var pruned []string
+	kept := make([]eval.TestResult, 0, len(baseline.TestResults))
+	for _, tr := range baseline.TestResults {
+		if current[tr.Name] || !eval.MatchesTags(tr.Tags, baselineTags, baselineExclude) {
+			kept = append(kept, tr)
+		} else {
+			pruned = append(pruned, tr.Name)
+		}
+	}
+
+	if len(pruned) == 0 || dryRun {
+		return pruned, nil
+	}
+
+	baseline.TestResults = kept
+	baseline.Recount()
+	_, err = saveBaseline(store, baseline, path)
+	return pruned, err
+}
+
+// baselineStore returns where baselines are kept with baseline.mode remote or backend,
+// and nil when they are local files. Backend baselines fall back to local files while
+// the backend is disabled, as it is with --offline.
+func baselineStore(cfg *config.RegradaConfig) (backend.Store, error) {
+	switch cfg.Baseline.Mode {
+	case "remote":
+		store, err := backend.NewBucketStore(cfg.Baseline.Remote)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "backend":
+		if !cfg.Backend.Enabled {
+			return nil, nil
+		}
+		store, err := backend.NewProjectStore(cfg.Backend, cfg.Project)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	return nil, nil
+}
+
+// commandBaselineStore is baselineStore for the baseline subcommands, where a
+// --baseline path always names a local file.
+func commandBaselineStore(cmd *cobra.Command, cfg *config.RegradaConfig) (backend.Store, error) {
+	if cmd.Flags().Changed("baseline") {
+		return nil, nil
+	}
+	return baselineStore(cfg)
+}
+
+// baselineKey is the key of the baseline at path in a remote store: its path under
+// .regrada, such as baseline.json or baselines/prod.json.
+func baselineKey(path string) string {
+	if rel, err := filepath.Rel(".regrada", path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.Base(path)
+}
+
+// loadBaseline reads the baseline at path, or its copy in store when there is one.
+// It returns nil when there is no baseline yet.
+func loadBaseline(store backend.Store, path string) (*eval.EvalResult, error) {
+	if store == nil {
+		result, err := eval.LoadResults(path)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return result, err
+	}
+
+	data, err := store.Get(baselineKey(path))
+	if errors.Is(err, backend.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var result eval.EvalResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid baseline %s: %w", store.Location(baselineKey(path)), err)
+	}
+	return &result, nil
+}
+
+// saveBaseline writes a baseline to path, or to store when there is one, and returns
+// where it was saved.
+func saveBaseline(store backend.Store, result *eval.EvalResult, path string) (string, error) {
+	if store == nil {
+		return path, eval.SaveResults(result, path)
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	key := baselineKey(path)
+	return store.Location(key), store.Put(key, data)
+}
+
+// saveRunBaseline saves a run's result as the baseline at path. A filtered run is
+// merged into the existing baseline, so the tests it left out keep their results.
+func saveRunBaseline(store backend.Store, result *eval.EvalResult, path string) (string, error) {
+	if !result.Filtered {
+		return saveBaseline(store, result, path)
+	}
+	previous, err := loadBaseline(store, path)
+	if err != nil {
+		return "", err
+	}
+	merged, err := eval.Promote(previous, result, nil)
+	if err != nil {
+		return "", err
+	}
+	return saveBaseline(store, merged, path)
+}
+
+// formatBytes formats a byte count for humans, e.g. 1.5 MB.
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, suffix := float64(n)/unit, "kB"
+	for _, s := range []string{"MB", "GB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, s
+	}
+	return fmt.Sprintf("%.1f %s", value, suffix)
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchConfigPath string
+	benchTestsPath  string
+	benchTraces     int
+)
+
+var benchChecksCmd = &cobra.Command{
+	Use:   "bench-checks",
+	Short: "Benchmark the check engine against stored traces",
+	Long: `Replay stored traces through every test's checks and report throughput and
+allocations. Useful for profiling the evaluation path before suites grow to
+thousands of tests. Traces are reused round-robin when fewer than --traces are stored.`,
+	Args: cobra.NoArgs,
+	Run:  runBenchChecks,
+}
+
+func init() {
+	rootCmd.AddCommand(benchChecksCmd)
+
+	benchChecksCmd.Flags().StringVarP(&benchConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	benchChecksCmd.Flags().StringVarP(&benchTestsPath, "tests", "t", "", "Path to test suite")
+	benchChecksCmd.Flags().IntVarP(&benchTraces, "traces", "n", 1000, "Number of traces to replay")
+}
+
+// benchTiming accumulates time spent evaluating one test.
+type benchTiming struct {
+	name  string
+	total time.Duration
+	runs  int
+}
+
+func runBenchChecks(cmd *cobra.Command, args []string) {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	fmt.Println()
+	fmt.Println(titleStyle.Render("Regrada Check Benchmark"))
+	fmt.Println()
+
+	cfg, err := config.Load(benchConfigPath)
+	if err != nil {
+		cfg = config.Defaults(".")
+	}
+	if benchTestsPath == "" {
+		benchTestsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+
+	suite, err := eval.LoadSuite(benchTestsPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	sessions, err := eval.LoadAllSessions()
+	if err != nil {
+		fmt.Printf("%s Failed to load trace sessions: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	var stored []trace.LLMTrace
+	for _, session := range sessions {
+		stored = append(stored, session.Traces...)
+	}
+	if len(stored) == 0 || len(suite.Tests) == 0 {
+		fmt.Println(dimStyle.Render("Nothing to benchmark (need at least one stored trace and one test)"))
+		return
+	}
+	if benchTraces <= 0 {
+		benchTraces = len(stored)
+	}
+
+	fmt.Printf("Replaying %d traces through %d tests (%d stored traces)...\n\n", benchTraces, len(suite.Tests), len(stored))
+
+	timings := make([]benchTiming, len(suite.Tests))
+	for i, test := range suite.Tests {
+		timings[i].name = test.Name
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	checks := 0
+	for n := 0; n < benchTraces; n++ {
+		tr := &stored[n%len(stored)]
+		for i, test := range suite.Tests {
+			testStart := time.Now()
+			result := eval.RunTest(test, tr)
+			timings[i].total += time.Since(testStart)
+			timings[i].runs++
+			checks += len(result.CheckResults)
+		}
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	allocs := after.Mallocs - before.Mallocs
+	bytes := after.TotalAlloc - before.TotalAlloc
+
+	fmt.Println("Results:")
+	fmt.Printf("  Checks run:    %d\n", checks)
+	fmt.Printf("  Elapsed:       %s\n", elapsed.Round(time.Millisecond))
+	if elapsed > 0 {
+		fmt.Printf("  Checks/sec:    %.0f\n", float64(checks)/elapsed.Seconds())
+	}
+	if checks > 0 {
+		fmt.Printf("  Allocs/check:  %.1f\n", float64(allocs)/float64(checks))
+		fmt.Printf("  Bytes/check:   %.0f\n", float64(bytes)/float64(checks))
+	}
+
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].total > timings[j].total
+	})
+
+	fmt.Println()
+	fmt.Println("Slowest tests:")
+	for i, t := range timings {
+		if i == 5 {
+			break
+		}
+		avg := t.total / time.Duration(t.runs)
+		fmt.Printf("  %-40s %s/trace\n", t.name, avg)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bisectTestName   string
+	bisectTestsPath  string
+	bisectConfigPath string
+)
+
+var bisectCmd = &cobra.Command{
+	Use:   "bisect",
+	Short: "Find the first trace session where a test started failing",
+	Long: `Replay a test against every stored trace session in .regrada/traces, oldest
+first, and report the session where it last passed and the first one where it
+started failing.`,
+	Args: cobra.NoArgs,
+	Run:  runBisect,
+}
+
+func init() {
+	rootCmd.AddCommand(bisectCmd)
+
+	bisectCmd.Flags().StringVar(&bisectTestName, "test", "", "Name of the test to bisect")
+	bisectCmd.Flags().StringVarP(&bisectTestsPath, "tests", "t", "", "Path to test suite")
+	bisectCmd.Flags().StringVarP(&bisectConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+}
+
+func runBisect(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	if bisectTestName == "" {
+		fmt.Fprintf(os.Stderr, "Error: --test is required\n")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(bisectConfigPath)
+	if err != nil {
+		cfg = config.Defaults(".")
+	}
+	if bisectTestsPath == "" {
+		bisectTestsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+
+	suite, err := eval.LoadSuite(bisectTestsPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	var test *eval.TestCase
+	for i := range suite.Tests {
+		if suite.Tests[i].Name == bisectTestName {
+			test = &suite.Tests[i]
+			break
+		}
+	}
+	if test == nil {
+		fmt.Printf("%s Test %s not found in %s\n", failStyle.Render("✗"), bisectTestName, bisectTestsPath)
+		os.Exit(1)
+	}
+
+	sessions, err := eval.LoadAllSessions()
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	fmt.Printf("History of %s across %d sessions:\n\n", test.Name, len(sessions))
+
+	lastPass, firstFail := -1, -1
+	for i, session := range sessions {
+		result := eval.RunTestInSession(*test, session)
+
+		var status string
+		switch result.Status {
+		case "passed":
+			status = successStyle.Render("✓ passed")
+			lastPass = i
+			firstFail = -1
+		case "error":
+			status = dimStyle.Render("- " + result.Error)
+		default:
+			status = failStyle.Render("✗ failed")
+			if firstFail == -1 {
+				firstFail = i
+			}
+		}
+
+		fmt.Printf("  %s  %-22s %s\n", session.StartTime.Format("2006-01-02 15:04:05"), session.ID, status)
+	}
+
+	fmt.Println()
+	switch {
+	case lastPass == -1 && firstFail == -1:
+		fmt.Printf("%s No usable sessions: %s could not be evaluated in any of the %d stored sessions\n", failStyle.Render("✗"), test.Name, len(sessions))
+		os.Exit(1)
+	case firstFail == -1:
+		fmt.Printf("%s %s passes in the latest session\n", successStyle.Render("✓"), test.Name)
+	case lastPass == -1:
+		fmt.Printf("%s %s has never passed in the stored sessions\n", warnStyle.Render("⚠"), test.Name)
+	default:
+		good, bad := sessions[lastPass], sessions[firstFail]
+		fmt.Printf("%s Last passed in session %s (%s)\n", successStyle.Render("✓"), good.ID, good.StartTime.Format("2006-01-02 15:04:05"))
+		fmt.Printf("%s First failed in session %s (%s)\n", failStyle.Render("✗"), bad.ID, bad.StartTime.Format("2006-01-02 15:04:05"))
+		if bad.Command != "" {
+			fmt.Printf("  %s\n", dimStyle.Render("command: "+bad.Command))
+		}
+	}
+}
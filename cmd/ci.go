@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciTestsPath    string
+	ciBaselinePath string
+	ciBaselineName string
+	ciConfigPath   string
+	ciOutputFormat string
+	ciRuns         int
+	ciSLOCSVPath   string
+	ciBadgePath    string
+	ciJUnitPath    string
+	ciCodeQuality  string
+	ciOffline      bool
+	ciConcurrency  int
+	ciVars         []string
+	ciTags         []string
+	ciExcludeTags  []string
+)
+
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Run the full CI pipeline in one step",
+	Long: `Validate the config, run the test suite with CI defaults, write results,
+upload them to the backend, and exit according to the quality gate.
+
+Exit codes:
+  0  gate passed
+  1  tests failed without regressions (gate.fail_on: any-failure or threshold)
+     or the quality score is below gate.min_score
+  2  regressions against the baseline
+  3  invalid config or test suite
+  4  infrastructure error (e.g. no trace session to evaluate)`,
+	Args: cobra.NoArgs,
+	Run:  runCI,
+}
+
+func init() {
+	rootCmd.AddCommand(ciCmd)
+
+	ciCmd.Flags().StringVarP(&ciTestsPath, "tests", "t", "", "Path to test suite")
+	ciCmd.Flags().StringVarP(&ciBaselinePath, "baseline", "b", "", "Path to baseline")
+	ciCmd.Flags().StringVar(&ciBaselineName, "baseline-name", "", "Compare with the named baseline in .regrada/baselines (e.g. prod, canary)")
+	ciCmd.Flags().StringVarP(&ciConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	ciCmd.Flags().StringVarP(&ciOutputFormat, "output", "o", "", "Output format: text, json, github (default: github on GitHub Actions, otherwise text)")
+	ciCmd.Flags().StringVar(&ciSLOCSVPath, "slo-csv", "", "Write the latency SLO report to a CSV file")
+	ciCmd.Flags().StringVar(&ciJUnitPath, "junit", "", "Write the results as a JUnit XML report")
+	ciCmd.Flags().StringVar(&ciCodeQuality, "codequality", "", "Write failed checks as a GitLab code quality report (JSON)")
+	ciCmd.Flags().StringVar(&ciBadgePath, "badge", "", "Write the quality score as a shields.io endpoint badge (JSON)")
+	ciCmd.Flags().BoolVar(&ciOffline, "offline", false, "Evaluate recorded traces only: fail fast if any test has no recording, and queue uploads instead of sending them")
+	ciCmd.Flags().IntVar(&ciRuns, "runs", 1, "Evaluate against the N latest sessions and compare pass rates statistically")
+	ciCmd.Flags().StringArrayVar(&ciVars, "var", nil, "Override a test variable as name=value (repeatable)")
+	ciCmd.Flags().StringSliceVar(&ciTags, "tags", nil, "Only run tests with one of these tags (comma-separated)")
+	ciCmd.Flags().StringSliceVar(&ciExcludeTags, "exclude-tags", nil, "Skip tests with any of these tags (comma-separated)")
+	ciCmd.Flags().IntVarP(&ciConcurrency, "concurrency", "j", 0, "Tests evaluated at once (default: evals.concurrent)")
+	ciCmd.MarkFlagsMutuallyExclusive("baseline", "baseline-name")
+}
+
+func runCI(cmd *cobra.Command, args []string) {
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+	cfg, err := config.Load(ciConfigPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load config: %v\n", failStyle.Render("✗"), err)
+		os.Exit(ExitPolicyError)
+	}
+	if err := config.Validate(cfg); err != nil {
+		fmt.Printf("%s Invalid config: %v\n", failStyle.Render("✗"), err)
+		os.Exit(ExitPolicyError)
+	}
+
+	if ciOutputFormat == "" {
+		ciOutputFormat = "text"
+		if os.Getenv("GITHUB_ACTIONS") == "true" {
+			ciOutputFormat = "github"
+		}
+	}
+
+	runTestsPath = ciTestsPath
+	runBaselinePath = ciBaselinePath
+	runBaselineName = ciBaselineName
+	runConfigPath = ciConfigPath
+	runOutputFormat = ciOutputFormat
+	runRuns = ciRuns
+	runSLOCSVPath = ciSLOCSVPath
+	runBadgePath = ciBadgePath
+	runJUnitPath = ciJUnitPath
+	runCodeQuality = ciCodeQuality
+	runOffline = ciOffline
+	runConcurrency = ciConcurrency
+	runVars = ciVars
+	runTags = ciTags
+	runExcludeTags = ciExcludeTags
+	runCIMode = true
+
+	result, cfg := executeRun()
+
+	if reason := gateFailure(cfg.Gate, result); reason != "" {
+		if runOutputFormat != "json" {
+			fmt.Printf("%s Quality gate failed: %s\n", failStyle.Render("✗"), reason)
+		}
+		switch {
+		case onlyErrored(result):
+			os.Exit(ExitInfraError)
+		case result.Regressions > 0:
+			os.Exit(ExitRegressions)
+		}
+		os.Exit(ExitFailures)
+	}
+	if reason := staleBaselineFailure(cfg.Policies.BaselineStaleness, result); reason != "" {
+		if runOutputFormat != "json" {
+			fmt.Printf("%s Quality gate failed: %s\n", failStyle.Render("✗"), reason)
+		}
+		os.Exit(ExitFailures)
+	}
+}
+
+// staleBaselineFailure returns why a stale baseline fails the run under a policy with
+// fail set, or "" if it doesn't.
+func staleBaselineFailure(policy config.BaselineStalenessPolicy, result *eval.EvalResult) string {
+	if !policy.Fail || result.Comparison == nil || len(result.Comparison.Stale) == 0 {
+		return ""
+	}
+	return "baseline is stale: " + strings.Join(result.Comparison.Stale, "; ")
+}
+
+// gateFailure applies the quality gate to a result and returns why it failed, or "" if it passed.
+// A disabled gate only fails on regressions, matching `regrada run --ci`.
+func gateFailure(gate config.GateConfig, result *eval.EvalResult) string {
+	failOn := gate.FailOn
+	if !gate.Enabled || failOn == "" {
+		failOn = "regression"
+	}
+
+	switch failOn {
+	case "any-failure":
+		if result.Failed > 0 {
+			return fmt.Sprintf("%d tests failed", result.Failed)
+		}
+	case "threshold":
+		// Drafts and skipped tests are excluded from the pass rate
+		if gated := result.Passed + result.Failed; gated > 0 {
+			passRate := float64(result.Passed) / float64(gated)
+			if passRate < gate.Threshold {
+				return fmt.Sprintf("pass rate %.2f is below threshold %.2f", passRate, gate.Threshold)
+			}
+		}
+	}
+
+	if result.Regressions > 0 {
+		return fmt.Sprintf("%d regressions detected", result.Regressions)
+	}
+
+	if gate.Enabled && gate.MinScore > 0 && result.Quality != nil && result.Quality.Score < gate.MinScore {
+		return fmt.Sprintf("quality score %.1f is below minimum %.1f", result.Quality.Score, gate.MinScore)
+	}
+
+	if gate.Enabled && gate.MaxLossRate > 0 && result.Pairwise != nil && result.Pairwise.LossRate > gate.MaxLossRate {
+		return fmt.Sprintf("pairwise loss rate %.1f%% exceeds maximum %.1f%%", result.Pairwise.LossRate*100, gate.MaxLossRate*100)
+	}
+	return ""
+}
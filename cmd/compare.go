@@ -0,0 +1,272 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	compareConfigPath string
+	compareTestsPath  string
+	compareModelA     string
+	compareModelB     string
+	compareProviderA  string
+	compareProviderB  string
+	compareReportPath string
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare --model-a <model> --model-b <model> -- <command>",
+	Short: "Compare two models on the test suite for a migration decision",
+	Long: `Trace your command once with each model (every request's model is replaced),
+run the test suite against both sessions, and compare pass rate, latency, cost,
+and refusal rate side by side. A markdown decision report listing the tests that
+regress or improve is written to --report.`,
+	Args: cobra.ArbitraryArgs,
+	Run:  runCompare,
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVarP(&compareConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	compareCmd.Flags().StringVarP(&compareTestsPath, "tests", "t", "", "Path to test suite")
+	compareCmd.Flags().StringVar(&compareModelA, "model-a", "", "Current model (required)")
+	compareCmd.Flags().StringVar(&compareModelB, "model-b", "", "Candidate model (required)")
+	compareCmd.Flags().StringVar(&compareProviderA, "provider-a", "", "Provider for model A (default: provider.type)")
+	compareCmd.Flags().StringVar(&compareProviderB, "provider-b", "", "Provider for model B (default: provider.type)")
+	compareCmd.Flags().StringVar(&compareReportPath, "report", filepath.Join(".regrada", "compare.md"), "Path of the markdown decision report")
+
+	compareCmd.Flags().SetInterspersed(false)
+}
+
+// comparedModel is one side of a comparison.
+type comparedModel struct {
+	label   string
+	result  *eval.EvalResult
+	session *trace.TraceSession
+	agg     eval.Aggregates
+}
+
+func runCompare(cmd *cobra.Command, args []string) {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no command specified after --\n")
+		os.Exit(1)
+	}
+	if compareModelA == "" || compareModelB == "" {
+		fmt.Fprintf(os.Stderr, "Error: --model-a and --model-b are required\n")
+		os.Exit(1)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	fmt.Println()
+	fmt.Println(titleStyle.Render("Regrada Compare"))
+	fmt.Println(dimStyle.Render(fmt.Sprintf("Comparing %s against %s...", compareModelB, compareModelA)))
+	fmt.Println()
+
+	cfg, err := config.Load(compareConfigPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load config: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	if compareTestsPath == "" {
+		compareTestsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+	suite, err := eval.LoadSuite(compareTestsPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	if embedder, err := eval.NewEmbedder(cfg.Embeddings); err == nil {
+		eval.UseEmbedder(embedder)
+	}
+	if judge, err := eval.NewJudge(cfg.Judge); err == nil {
+		eval.UseJudge(judge)
+	}
+	eval.UseConcurrency(cfg.Evals.Concurrent)
+
+	entries := []config.MatrixEntry{
+		{Name: compareModelA, Model: compareModelA, Provider: compareProviderA},
+		{Name: compareModelB, Model: compareModelB, Provider: compareProviderB},
+	}
+	models := make([]comparedModel, len(entries))
+	for i, entry := range entries {
+		side := string(rune('a' + i))
+		entryCfg := matrixConfig(cfg, entry)
+		fmt.Printf("Tracing %s...\n", entry.Model)
+
+		session, err := captureWithConfig(entryCfg, args)
+		if err != nil {
+			fmt.Printf("%s Run %s failed: %v\n", failStyle.Render("✗"), strings.ToUpper(side), err)
+			os.Exit(1)
+		}
+		compress := entryCfg.Storage.Compression == "gzip"
+		path := filepath.Join(".regrada", "traces", trace.FileName(session.ID+"-"+side, compress))
+		if err := trace.Save(session, path); err != nil {
+			fmt.Printf("%s Failed to save session %s: %v\n", failStyle.Render("✗"), strings.ToUpper(side), err)
+		}
+
+		result := eval.EvaluateSuite(suite, session, nil)
+		models[i] = comparedModel{label: entry.Model, result: result, session: session, agg: eval.Aggregate(result, session)}
+	}
+
+	a, b := models[0], models[1]
+	regressed, fixed := eval.DiffTests(a.result, b.result)
+
+	fmt.Println()
+	fmt.Printf("  %-14s %14s %14s  %s\n", "", truncateLabel(a.label, 14), truncateLabel(b.label, 14), "Change")
+	for _, row := range comparisonRows(a.agg, b.agg) {
+		fmt.Printf("  %-14s %14s %14s  %s\n", row.metric, row.a, row.b, row.change)
+	}
+	fmt.Println()
+	if len(regressed) > 0 {
+		fmt.Println(failStyle.Render(fmt.Sprintf("  %d tests regress on %s", len(regressed), b.label)))
+		for _, c := range regressed {
+			fmt.Printf("    - %s\n", c.Name)
+		}
+		fmt.Println()
+	}
+	if len(fixed) > 0 {
+		fmt.Println(successStyle.Render(fmt.Sprintf("  %d tests improve on %s", len(fixed), b.label)))
+		for _, c := range fixed {
+			fmt.Printf("    - %s\n", c.Name)
+		}
+		fmt.Println()
+	}
+
+	recommendation := recommendMigration(a, b, regressed)
+	fmt.Printf("  %s\n", recommendation)
+
+	report := comparisonReport(suite, a, b, regressed, fixed, recommendation)
+	err = os.MkdirAll(filepath.Dir(compareReportPath), 0755)
+	if err == nil {
+		err = os.WriteFile(compareReportPath, report, 0644)
+	}
+	if err != nil {
+		fmt.Printf("%s Failed to write report: %v\n", failStyle.Render("✗"), err)
+	} else {
+		fmt.Printf("\n%s\n", dimStyle.Render("Decision report written to "+compareReportPath))
+	}
+	fmt.Println()
+}
+
+// comparisonRow is one aggregate of the two models, formatted for display.
+type comparisonRow struct {
+	metric, a, b, change string
+}
+
+func comparisonRows(a, b eval.Aggregates) []comparisonRow {
+	ms := func(d time.Duration) string { return fmt.Sprintf("%dms", int64(d)) }
+	pct := func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) }
+	points := func(d float64) string { return fmt.Sprintf("%+.1f pts", d*100) }
+	tokens := func(agg eval.Aggregates) string {
+		return fmt.Sprintf("%.0f ± %.0f", agg.TokensOutMean, agg.TokensOutStdDev)
+	}
+
+	return []comparisonRow{
+		{"Pass rate", pct(a.PassRate), pct(b.PassRate), points(b.PassRate - a.PassRate)},
+		{"Pass rate CV", fmt.Sprintf("%.2f", a.PassRateCV), fmt.Sprintf("%.2f", b.PassRateCV), fmt.Sprintf("%+.2f", b.PassRateCV-a.PassRateCV)},
+		{"Latency (mean)", ms(a.LatencyMean), ms(b.LatencyMean), relativeChange(float64(a.LatencyMean), float64(b.LatencyMean))},
+		{"Latency (p95)", ms(a.LatencyP95), ms(b.LatencyP95), relativeChange(float64(a.LatencyP95), float64(b.LatencyP95))},
+		{"Latency (stddev)", ms(a.LatencyStdDev), ms(b.LatencyStdDev), relativeChange(float64(a.LatencyStdDev), float64(b.LatencyStdDev))},
+		{"Cost", fmt.Sprintf("$%.4f", a.CostUSD), fmt.Sprintf("$%.4f", b.CostUSD), relativeChange(a.CostUSD, b.CostUSD)},
+		{"Output tokens", fmt.Sprintf("%d", a.TokensOut), fmt.Sprintf("%d", b.TokensOut), relativeChange(float64(a.TokensOut), float64(b.TokensOut))},
+		{"Tokens/call", tokens(a), tokens(b), relativeChange(a.TokensOutMean, b.TokensOutMean)},
+		{"Refusal rate", pct(a.RefusalRate), pct(b.RefusalRate), points(b.RefusalRate - a.RefusalRate)},
+	}
+}
+
+// relativeChange formats the change from a to b as a percentage of a.
+func relativeChange(a, b float64) string {
+	if a == 0 {
+		if b == 0 {
+			return "-"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", (b-a)/a*100)
+}
+
+// recommendMigration summarizes whether model B can replace model A: never when a test
+// that passes on A fails on B, and otherwise with the trade-offs in cost and latency.
+func recommendMigration(a, b comparedModel, regressed []eval.TestChange) string {
+	switch {
+	case len(regressed) > 0:
+		return fmt.Sprintf("Not recommended: %d tests that pass on %s fail on %s.", len(regressed), a.label, b.label)
+	case b.agg.PassRate < a.agg.PassRate:
+		return fmt.Sprintf("Not recommended: %s passes fewer tests than %s.", b.label, a.label)
+	case b.agg.RefusalRate > a.agg.RefusalRate:
+		return fmt.Sprintf("Review: %s passes every test %s does but refuses more often (%.1f%% vs %.1f%%).",
+			b.label, a.label, b.agg.RefusalRate*100, a.agg.RefusalRate*100)
+	default:
+		return fmt.Sprintf("Recommended: %s passes every test %s does (cost %s, mean latency %s).",
+			b.label, a.label, relativeChange(a.agg.CostUSD, b.agg.CostUSD),
+			relativeChange(float64(a.agg.LatencyMean), float64(b.agg.LatencyMean)))
+	}
+}
+
+// comparisonReport renders the markdown decision report.
+func comparisonReport(suite *eval.TestSuite, a, b comparedModel, regressed, fixed []eval.TestChange, recommendation string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Model comparison: %s vs %s\n\n", a.label, b.label)
+	fmt.Fprintf(&buf, "Suite **%s** (%d tests), %s.\n\n", suite.Name, len(suite.Tests), time.Now().Format("2006-01-02 15:04"))
+
+	fmt.Fprintf(&buf, "## Decision\n\n%s\n\n", recommendation)
+
+	fmt.Fprintf(&buf, "## Aggregates\n\n")
+	fmt.Fprintf(&buf, "| Metric | %s | %s | Change |\n", a.label, b.label)
+	fmt.Fprintf(&buf, "|---|---:|---:|---:|\n")
+	for _, row := range comparisonRows(a.agg, b.agg) {
+		fmt.Fprintf(&buf, "| %s | %s | %s | %s |\n", row.metric, row.a, row.b, row.change)
+	}
+	fmt.Fprintf(&buf, "\nPass rate counts %d tests on %s and %d on %s; latency, cost, tokens, and refusals cover %d and %d calls.\n\n",
+		a.agg.Tests, a.label, b.agg.Tests, b.label, a.agg.Calls, b.agg.Calls)
+
+	writeChanges := func(title string, changes []eval.TestChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&buf, "## %s (%d)\n\n", title, len(changes))
+		fmt.Fprintf(&buf, "| Test | %s | %s |\n|---|---|---|\n", a.label, b.label)
+		for _, c := range changes {
+			fmt.Fprintf(&buf, "| %s | %s | %s |\n", c.Name, c.StatusA, c.StatusB)
+		}
+		buf.WriteString("\n")
+	}
+	writeChanges("Regressed", regressed)
+	writeChanges("Improved", fixed)
+	if len(regressed) == 0 && len(fixed) == 0 {
+		buf.WriteString("No test changed status.\n")
+	}
+
+	return buf.Bytes()
+}
+
+// truncateLabel shortens s to n characters for fixed-width columns.
+func truncateLabel(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
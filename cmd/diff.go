@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/eval"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffOutputFormat string
+	diffContext      int
+	diffNoOutput     bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <results-a.json> <results-b.json>",
+	Short: "Compare two saved results test by test",
+	Long: `Compare two results files, such as .regrada/results.json from two branches or a
+baseline and a later run: the tests that regressed or were fixed, tests only one run
+has, and for every test that changed its status, latency, tokens, cost, score, and a
+line diff of its output.`,
+	Args: cobra.ExactArgs(2),
+	Run:  runDiff,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringVarP(&diffOutputFormat, "output", "o", "text", "Output format: text, json")
+	diffCmd.Flags().IntVarP(&diffContext, "context", "U", 2, "Unchanged output lines shown around each change")
+	diffCmd.Flags().BoolVar(&diffNoOutput, "no-output", false, "Leave out output diffs")
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	var results [2]*eval.EvalResult
+	for i, path := range args {
+		result, err := eval.LoadResults(path)
+		if err != nil {
+			fmt.Printf("%s Failed to load %s: %v\n", failStyle.Render("✗"), path, err)
+			os.Exit(1)
+		}
+		results[i] = result
+	}
+	a, b := results[0], results[1]
+	diff := eval.DiffResults(a, b)
+	if diffNoOutput {
+		for i := range diff.Tests {
+			diff.Tests[i].OutputDiff = nil
+		}
+	}
+
+	if diffOutputFormat == "json" {
+		data, _ := json.MarshalIndent(diff, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("  %s %s\n", dimStyle.Render("A:"), args[0])
+	fmt.Printf("  %s %s\n", dimStyle.Render("B:"), args[1])
+	fmt.Println()
+	fmt.Printf("  Passed: %d/%d → %d/%d\n", a.Passed, a.TotalTests-a.Skipped, b.Passed, b.TotalTests-b.Skipped)
+	if a.CostUSD > 0 || b.CostUSD > 0 {
+		fmt.Printf("  Cost: $%.4f → $%.4f (%s)\n", a.CostUSD, b.CostUSD, relativeChange(a.CostUSD, b.CostUSD))
+	}
+
+	if len(diff.Regressed) > 0 {
+		fmt.Printf("\n%s\n", failStyle.Render(fmt.Sprintf("  New failures: %d", len(diff.Regressed))))
+		for _, c := range diff.Regressed {
+			fmt.Printf("    - %s\n", c.Name)
+		}
+	}
+	if len(diff.Fixed) > 0 {
+		fmt.Printf("\n%s\n", successStyle.Render(fmt.Sprintf("  New passes: %d", len(diff.Fixed))))
+		for _, c := range diff.Fixed {
+			fmt.Printf("    - %s\n", c.Name)
+		}
+	}
+	if len(diff.Added) > 0 {
+		fmt.Printf("\n  Only in B: %s\n", strings.Join(diff.Added, ", "))
+	}
+	if len(diff.Removed) > 0 {
+		fmt.Printf("\n  Only in A: %s\n", strings.Join(diff.Removed, ", "))
+	}
+
+	unchanged := 0
+	for _, d := range diff.Tests {
+		if !d.Changed() {
+			unchanged++
+			continue
+		}
+		fmt.Printf("\n  %s\n", lipgloss.NewStyle().Bold(true).Render(d.Name))
+		if d.StatusA != d.StatusB {
+			fmt.Printf("    Status: %s → %s\n", d.StatusA, d.StatusB)
+		}
+		if d.LatencyA != d.LatencyB {
+			fmt.Printf("    Latency: %dms → %dms (%s)\n", int64(d.LatencyA), int64(d.LatencyB), relativeChange(float64(d.LatencyA), float64(d.LatencyB)))
+		}
+		if d.TokensOutA != d.TokensOutB {
+			fmt.Printf("    Output tokens: %d → %d (%s)\n", d.TokensOutA, d.TokensOutB, relativeChange(float64(d.TokensOutA), float64(d.TokensOutB)))
+		}
+		if d.CostA != d.CostB {
+			fmt.Printf("    Cost: $%.4f → $%.4f\n", d.CostA, d.CostB)
+		}
+		if d.ScoreA != nil && d.ScoreB != nil && *d.ScoreA != *d.ScoreB {
+			fmt.Printf("    Score: %.2f → %.2f (%+.2f)\n", *d.ScoreA, *d.ScoreB, *d.ScoreB-*d.ScoreA)
+		}
+		for _, line := range diffHunks(d.OutputDiff, diffContext) {
+			switch {
+			case strings.HasPrefix(line, "- "):
+				line = failStyle.Render(line)
+			case strings.HasPrefix(line, "+ "):
+				line = successStyle.Render(line)
+			default:
+				line = dimStyle.Render(line)
+			}
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	if unchanged > 0 {
+		fmt.Printf("\n  %s\n", dimStyle.Render(fmt.Sprintf("%d tests unchanged", unchanged)))
+	}
+	fmt.Println()
+}
+
+// diffHunks keeps the changed lines of a line diff and up to context unchanged lines
+// around them, replacing longer unchanged runs with "...".
+func diffHunks(diff []string, context int) []string {
+	keep := make([]bool, len(diff))
+	for i, line := range diff {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		for j := max(i-context, 0); j <= min(i+context, len(diff)-1); j++ {
+			keep[j] = true
+		}
+	}
+
+	var hunks []string
+	skipped := false
+	for i, line := range diff {
+		if keep[i] {
+			hunks = append(hunks, line)
+			skipped = false
+		} else if !skipped {
+			hunks = append(hunks, "  ...")
+			skipped = true
+		}
+	}
+	return hunks
+}
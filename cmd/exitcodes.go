@@ -0,0 +1,27 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import "github.com/matias/regrada/eval"
+
+// Exit codes for `regrada run --ci` and `regrada ci`. These are stable so CI
+// pipelines can treat infrastructure flakes differently from quality regressions.
+const (
+	// ExitOK means the run passed its gate.
+	ExitOK = 0
+	// ExitFailures means tests failed but nothing regressed against the baseline.
+	ExitFailures = 1
+	// ExitRegressions means tests that passed in the baseline are now failing.
+	ExitRegressions = 2
+	// ExitPolicyError means the config or test suite is invalid.
+	ExitPolicyError = 3
+	// ExitInfraError means the run could not be performed, e.g. no trace session was found.
+	ExitInfraError = 4
+)
+
+// onlyErrored reports whether every failed test of a run errored rather than failed a
+// check, e.g. because the judge or embeddings provider was unreachable. Such a run is
+// an infrastructure error, not a quality failure.
+func onlyErrored(result *eval.EvalResult) bool {
+	return result.Errored > 0 && result.Failed == result.Errored
+}
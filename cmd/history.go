@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/eval"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit        int
+	historyOutputFormat string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List past runs",
+	Long: `List the runs recorded in .regrada/history, newest first: when each ran, on
+which commit, and how it did. Every run's summary is kept; the full results of the
+latest runs (history.keep, default 20) can be inspected with 'regrada history show'.`,
+	Args: cobra.NoArgs,
+	Run:  runHistory,
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a past run",
+	Long: `Show a run from the history, given its ID or a unique prefix of it, with the same
+report 'regrada run' printed.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runHistoryShow,
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+
+	historyCmd.PersistentFlags().StringVarP(&historyOutputFormat, "output", "o", "text", "Output format: text, json")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of runs to list (0 for all)")
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	runs, err := eval.ReadHistory(filepath.Join(".regrada", "history"))
+	if err != nil {
+		fmt.Printf("%s Failed to read history: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	// Newest first
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	if historyLimit > 0 && len(runs) > historyLimit {
+		runs = runs[:historyLimit]
+	}
+
+	if historyOutputFormat == "json" {
+		if runs == nil {
+			runs = []eval.RunSummary{}
+		}
+		data, _ := json.MarshalIndent(runs, "", "  ")
+		fmt.Println(string(data))
+		return
+	}
+	if len(runs) == 0 {
+		fmt.Println(dimStyle.Render("No runs recorded yet"))
+		return
+	}
+
+	fmt.Printf("  %-25s  %-16s  %-22s  %-9s  %-11s  %-7s  %s\n", "ID", "Date", "Commit", "Passed", "Regressions", "Quality", "Cost")
+	for _, run := range runs {
+		commit := "-"
+		if run.GitSHA != "" {
+			commit = run.GitSHA[:min(len(run.GitSHA), 7)]
+			if run.GitBranch != "" {
+				commit = truncateLabel(run.GitBranch, 14) + "@" + commit
+			}
+		}
+		quality := "-"
+		if run.Quality != nil {
+			quality = fmt.Sprintf("%.1f", *run.Quality)
+		}
+
+		// Pad before styling, as lipgloss breaks %-*s padding
+		status := fmt.Sprintf("%-9s", fmt.Sprintf("%d/%d", run.Passed, run.Passed+run.Failed))
+		switch run.Status {
+		case eval.RunRegression:
+			status = failStyle.Render(status)
+		case eval.RunFailure:
+			status = warnStyle.Render(status)
+		default:
+			status = successStyle.Render(status)
+		}
+		var note string
+		if run.Filtered {
+			note = "  " + dimStyle.Render("filtered")
+		}
+		fmt.Printf("  %-25s  %-16s  %-22s  %s  %-11d  %-7s  $%.4f%s\n",
+			run.ID, run.Timestamp.Local().Format("2006-01-02 15:04"), commit, status, run.Regressions, quality, run.CostUSD, note)
+	}
+}
+
+func runHistoryShow(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	run, result, err := eval.FindRun(filepath.Join(".regrada", "history"), args[0])
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	if historyOutputFormat == "json" {
+		var data []byte
+		if result != nil {
+			data, _ = json.MarshalIndent(result, "", "  ")
+		} else {
+			data, _ = json.MarshalIndent(run, "", "  ")
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("  Run %s, %s\n", run.ID, run.Timestamp.Local().Format("2006-01-02 15:04:05"))
+	if result != nil && result.Provenance != nil {
+		fmt.Printf("  %s\n", dimStyle.Render(result.Provenance.String()))
+	}
+	if result == nil {
+		fmt.Printf("  %s\n", dimStyle.Render("Full results were pruned (history.keep), showing the summary"))
+		fmt.Println()
+		fmt.Printf("  Passed: %d, failed: %d, regressions: %d, skipped: %d\n", run.Passed, run.Failed, run.Regressions, run.Skipped)
+		if run.Quality != nil {
+			fmt.Printf("  Quality score: %.1f\n", *run.Quality)
+		}
+		if run.LatencyMean > 0 {
+			fmt.Printf("  Mean latency: %dms\n", int64(run.LatencyMean))
+		}
+		if run.CostUSD > 0 {
+			fmt.Printf("  Estimated cost: $%.4f\n", run.CostUSD)
+		}
+		fmt.Println()
+		return
+	}
+	outputText(result, successStyle, failStyle, warnStyle)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/trace"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 )
@@ -44,7 +45,24 @@ func runInit(cmd *cobra.Command, args []string) {
 	fmt.Println(dimStyle.Render("Setting up your AI testing environment..."))
 	fmt.Println()
 
-	if _, err := os.Stat(".regrada.yaml"); err == nil && !initForce {
+	// A project set up by an earlier release is upgraded in place instead
+	if !initForce {
+		changes, err := migrateProject()
+		if err != nil {
+			fmt.Printf("%s Failed to migrate project: %v\n", warnStyle.Render("Error:"), err)
+			os.Exit(1)
+		}
+		if len(changes) > 0 {
+			fmt.Printf("%s Migrated project from an earlier release:\n", successStyle.Render("✓"))
+			for _, change := range changes {
+				fmt.Printf("  - %s\n", change)
+			}
+			fmt.Println(dimStyle.Render("Use --force to reinitialize instead."))
+			return
+		}
+	}
+
+	if _, err := os.Stat(config.DefaultPath); err == nil && !initForce {
 		fmt.Printf("%s Project already initialized. Use --force to reinitialize.\n", warnStyle.Render("Warning:"))
 		os.Exit(1)
 	}
@@ -62,7 +80,7 @@ func runInit(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	if err := os.WriteFile(".regrada.yaml", data, 0644); err != nil {
+	if err := os.WriteFile(config.DefaultPath, data, 0644); err != nil {
 		fmt.Printf("%s Failed to write config: %v\n", warnStyle.Render("Error:"), err)
 		os.Exit(1)
 	}
@@ -130,8 +148,13 @@ func runInteractiveSetup() *config.RegradaConfig {
 				Options(
 					huh.NewOption("OpenAI", "openai"),
 					huh.NewOption("Anthropic (Claude)", "anthropic"),
+					huh.NewOption("Google Gemini", "gemini"),
 					huh.NewOption("Azure OpenAI", "azure-openai"),
-					huh.NewOption("Custom/Ollama", "custom"),
+					huh.NewOption("AWS Bedrock", "bedrock"),
+					huh.NewOption("OpenRouter", "openrouter"),
+					huh.NewOption("OpenAI-compatible (vLLM, LM Studio, Groq, ...)", "openai-compatible"),
+					huh.NewOption("Ollama", "ollama"),
+					huh.NewOption("Custom", "custom"),
 				).
 				Value(&providerType),
 		),
@@ -139,7 +162,7 @@ func runInteractiveSetup() *config.RegradaConfig {
 		huh.NewGroup(
 			huh.NewInput().
 				Title("Model").
-				Description("e.g., gpt-4o, claude-3-5-sonnet-20241022, llama2").
+				Description("e.g., gpt-4o, claude-3-5-sonnet-20241022, gemini-2.0-flash, llama2").
 				Value(&model).
 				Placeholder("gpt-4o"),
 		),
@@ -223,7 +246,7 @@ func runInteractiveSetup() *config.RegradaConfig {
 		os.Exit(1)
 	}
 
-	if providerType == "azure-openai" || providerType == "custom" {
+	if providerType == "azure-openai" || providerType == "custom" || providerType == "openai-compatible" {
 		baseURLForm := huh.NewForm(
 			huh.NewGroup(
 				huh.NewInput().
@@ -287,6 +310,49 @@ func runInteractiveSetup() *config.RegradaConfig {
 	return cfg
 }
 
+// migrateProject upgrades a project set up by an earlier release: its config is moved
+// to config.DefaultPath in the current schema and a legacy trace file is converted.
+// It returns the changes made, none when the project is current or has no config.
+func migrateProject() ([]string, error) {
+	source := config.DefaultPath
+	if _, err := os.Stat(source); err != nil {
+		if source = config.LegacyPath(); source == "" {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, changes, err := config.Upgrade(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	if source != config.DefaultPath {
+		changes = append([]string{fmt.Sprintf("renamed %s to %s", source, config.DefaultPath)}, changes...)
+	}
+	if len(changes) > 0 {
+		if err := os.WriteFile(config.DefaultPath, upgraded, 0644); err != nil {
+			return nil, err
+		}
+		if source != config.DefaultPath {
+			if err := os.Remove(source); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	converted, err := trace.ConvertLegacy(filepath.Join(".regrada", "traces"))
+	if err != nil {
+		return changes, err
+	}
+	if converted != "" {
+		changes = append(changes, fmt.Sprintf("converted %s to %s", trace.LegacyPath, converted))
+	}
+	return changes, nil
+}
+
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
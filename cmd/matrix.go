@@ -0,0 +1,336 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	matrixConfigPath   string
+	matrixTestsPath    string
+	matrixOutputFormat string
+	matrixSaveBaseline bool
+	matrixCIMode       bool
+)
+
+var matrixCmd = &cobra.Command{
+	Use:   "matrix -- <command>",
+	Short: "Run the suite against every provider/model in the matrix",
+	Long: `Trace your command once per matrix entry (config "matrix"), with every request's
+model replaced by the entry's, evaluate the test suite against each session, and
+report the results side by side. Each entry is compared with its own baseline in
+.regrada/baselines/<name>.json.`,
+	Args: cobra.ArbitraryArgs,
+	Run:  runMatrix,
+}
+
+func init() {
+	rootCmd.AddCommand(matrixCmd)
+
+	matrixCmd.Flags().StringVarP(&matrixConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	matrixCmd.Flags().StringVarP(&matrixTestsPath, "tests", "t", "", "Path to test suite")
+	matrixCmd.Flags().StringVarP(&matrixOutputFormat, "output", "o", "text", "Output format: text, json, github")
+	matrixCmd.Flags().BoolVar(&matrixSaveBaseline, "save-baseline", false, "Save each entry's results as its baseline")
+	matrixCmd.Flags().BoolVar(&matrixCIMode, "ci", false, "CI mode (exit 2 when any entry regresses against its baseline)")
+
+	matrixCmd.Flags().SetInterspersed(false)
+}
+
+// matrixRun is the outcome of one matrix entry.
+type matrixRun struct {
+	Name     string           `json:"name"`
+	Provider string           `json:"provider"`
+	Model    string           `json:"model"`
+	Session  string           `json:"session,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	Result   *eval.EvalResult `json:"result,omitempty"`
+}
+
+func runMatrix(cmd *cobra.Command, args []string) {
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: no command specified after --\n")
+		os.Exit(1)
+	}
+
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	cfg, err := config.Load(matrixConfigPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load config: %v\n", failStyle.Render("✗"), err)
+		os.Exit(ExitPolicyError)
+	}
+	if len(cfg.Matrix) == 0 {
+		fmt.Printf("%s No matrix entries in %s\n", failStyle.Render("✗"), matrixConfigPath)
+		os.Exit(ExitPolicyError)
+	}
+	if err := config.Validate(cfg); err != nil {
+		fmt.Printf("%s Invalid config: %v\n", failStyle.Render("✗"), err)
+		os.Exit(ExitPolicyError)
+	}
+
+	if matrixTestsPath == "" {
+		matrixTestsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+	suite, err := eval.LoadSuite(matrixTestsPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(ExitPolicyError)
+	}
+
+	if matrixOutputFormat != "json" {
+		fmt.Println()
+		fmt.Println(titleStyle.Render("Regrada Matrix"))
+		fmt.Println(dimStyle.Render(fmt.Sprintf("Running %d tests against %d models...", len(suite.Tests), len(cfg.Matrix))))
+		fmt.Println()
+	}
+
+	if embedder, err := eval.NewEmbedder(cfg.Embeddings); err == nil {
+		eval.UseEmbedder(embedder)
+	}
+	if judge, err := eval.NewJudge(cfg.Judge); err == nil {
+		eval.UseJudge(judge)
+	}
+	eval.UseConcurrency(cfg.Evals.Concurrent)
+
+	traceDir := filepath.Join(".regrada", "traces")
+	runs := make([]matrixRun, 0, len(cfg.Matrix))
+	regressed := false
+	for _, entry := range cfg.Matrix {
+		entryCfg := matrixConfig(cfg, entry)
+		run := matrixRun{Name: entry.Label(), Provider: entryCfg.Provider.Type, Model: entry.Model}
+		if matrixOutputFormat != "json" {
+			fmt.Printf("Tracing %s...\n", run.Name)
+		}
+
+		// One failing combination shouldn't hide the others
+		session, err := captureWithConfig(entryCfg, args)
+		if err != nil {
+			run.Error = err.Error()
+			runs = append(runs, run)
+			if matrixOutputFormat != "json" {
+				fmt.Printf("%s %s failed: %v\n", failStyle.Render("✗"), run.Name, err)
+			}
+			continue
+		}
+		session.Metadata = withMetadata(session.Metadata, "matrix", run.Name)
+		run.Session = session.ID
+
+		compress := entryCfg.Storage.Compression == "gzip"
+		path := filepath.Join(traceDir, trace.FileName(session.ID+"-"+fileLabel(run.Name), compress))
+		if err := trace.Save(session, path); err != nil && matrixOutputFormat != "json" {
+			fmt.Printf("%s Failed to save session for %s: %v\n", warnStyle.Render("Warning:"), run.Name, err)
+		}
+
+		result := eval.EvaluateSuite(suite, session, nil)
+		result.CostUSD = session.Summary.TotalCostUSD
+		result.Provenance = resultProvenance(entryCfg, session)
+
+		baselinePath := namedBaselinePath(run.Name)
+		if _, err := eval.ApplyBaseline(result, baselinePath); err == nil && result.Regressions > 0 {
+			regressed = true
+		}
+		if matrixSaveBaseline {
+			if err := eval.SaveResults(result, baselinePath); err != nil && matrixOutputFormat != "json" {
+				fmt.Printf("%s Failed to save baseline for %s: %v\n", warnStyle.Render("Warning:"), run.Name, err)
+			}
+		}
+
+		run.Result = result
+		runs = append(runs, run)
+	}
+
+	data, _ := json.MarshalIndent(runs, "", "  ")
+	os.MkdirAll(".regrada", 0755)
+	if err := os.WriteFile(filepath.Join(".regrada", "matrix.json"), data, 0644); err != nil && matrixOutputFormat != "json" {
+		fmt.Printf("%s Failed to save matrix results: %v\n", warnStyle.Render("Warning:"), err)
+	}
+
+	report := matrixReport(suite, runs)
+	if err := os.WriteFile(filepath.Join(".regrada", "matrix.md"), []byte(report), 0644); err != nil && matrixOutputFormat != "json" {
+		fmt.Printf("%s Failed to save matrix report: %v\n", warnStyle.Render("Warning:"), err)
+	}
+
+	switch matrixOutputFormat {
+	case "json":
+		fmt.Println(string(data))
+	case "github":
+		fmt.Println(report)
+	default:
+		outputMatrix(suite, runs)
+		if matrixSaveBaseline {
+			fmt.Printf("\n%s\n", dimStyle.Render("Baselines saved to "+filepath.Join(".regrada", "baselines")))
+		}
+		fmt.Println()
+	}
+
+	if matrixCIMode && regressed {
+		os.Exit(ExitRegressions)
+	}
+}
+
+// matrixConfig returns a copy of cfg that routes every request to the entry's provider and model.
+// Switching provider drops the project's base URL, which belongs to the other provider.
+func matrixConfig(cfg *config.RegradaConfig, entry config.MatrixEntry) *config.RegradaConfig {
+	entryCfg := *cfg
+	entryCfg.Provider.Model = entry.Model
+	entryCfg.Provider.ForceModel = entry.Model
+	if entry.Provider != "" && entry.Provider != cfg.Provider.Type {
+		entryCfg.Provider.Type = entry.Provider
+		entryCfg.Provider.BaseURL = ""
+	}
+	if entry.BaseURL != "" {
+		entryCfg.Provider.BaseURL = entry.BaseURL
+	}
+	return &entryCfg
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileLabel makes a matrix entry name safe to use in a file name.
+func fileLabel(name string) string {
+	return unsafeFileChars.ReplaceAllString(name, "-")
+}
+
+// withMetadata returns metadata with key set, allocating the map if needed.
+func withMetadata(metadata map[string]string, key, value string) map[string]string {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	metadata[key] = value
+	return metadata
+}
+
+// matrixTotals are the per-entry rows shown below the test table.
+var matrixTotals = []struct {
+	label string
+	value func(*eval.EvalResult) string
+}{
+	{"Passed", func(r *eval.EvalResult) string { return fmt.Sprintf("%d/%d", r.Passed, r.TotalTests-r.Skipped) }},
+	{"Regressions", func(r *eval.EvalResult) string { return fmt.Sprintf("%d", r.Regressions) }},
+	{"Avg latency", func(r *eval.EvalResult) string { return formatMatrixLatency(r) }},
+	{"Tokens", func(r *eval.EvalResult) string { return fmt.Sprintf("%d", matrixTokens(r)) }},
+	{"Cost", func(r *eval.EvalResult) string { return fmt.Sprintf("$%.4f", r.CostUSD) }},
+}
+
+// outputMatrix prints a test-by-entry table followed by per-entry totals.
+func outputMatrix(suite *eval.TestSuite, runs []matrixRun) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	nameWidth := len("Regressions") // Longest totals label
+	for _, test := range suite.Tests {
+		nameWidth = max(nameWidth, len(test.Name))
+	}
+	widths := make([]int, len(runs))
+	for i, run := range runs {
+		widths[i] = max(len(run.Name), 10)
+	}
+
+	// lipgloss styles would break %-*s padding, so cells are padded before styling
+	cell := func(text string, width int, style *lipgloss.Style) string {
+		padded := fmt.Sprintf("%-*s", width, text)
+		if style == nil {
+			return padded
+		}
+		return style.Render(padded)
+	}
+
+	fmt.Println()
+	fmt.Printf("  %-*s", nameWidth, "Test")
+	for i, run := range runs {
+		fmt.Printf("  %s", cell(run.Name, widths[i], nil))
+	}
+	fmt.Println()
+
+	for _, test := range suite.Tests {
+		fmt.Printf("  %-*s", nameWidth, test.Name)
+		for i, run := range runs {
+			text, style := "-", &dimStyle
+			if tr := matrixTestResult(run, test.Name); tr != nil {
+				switch {
+				case tr.Regression:
+					text, style = "✗ regressed", &failStyle
+				case tr.Status == "passed":
+					text, style = "✓", &successStyle
+				case tr.Status == "failed":
+					text, style = "✗", &failStyle
+				default:
+					text = tr.Status
+				}
+			}
+			fmt.Printf("  %s", cell(text, widths[i], style))
+		}
+		fmt.Println()
+	}
+
+	fmt.Println()
+	for _, row := range matrixTotals {
+		fmt.Printf("  %-*s", nameWidth, row.label)
+		for i, run := range runs {
+			text := "error"
+			if run.Result != nil {
+				text = row.value(run.Result)
+			}
+			fmt.Printf("  %s", cell(text, widths[i], nil))
+		}
+		fmt.Println()
+	}
+}
+
+// matrixTestResult returns an entry's result for the named test, if it was evaluated.
+func matrixTestResult(run matrixRun, name string) *eval.TestResult {
+	if run.Result == nil {
+		return nil
+	}
+	for i := range run.Result.TestResults {
+		if run.Result.TestResults[i].Name == name {
+			return &run.Result.TestResults[i]
+		}
+	}
+	return nil
+}
+
+// formatMatrixLatency returns the mean latency of the tests that recorded one.
+func formatMatrixLatency(r *eval.EvalResult) string {
+	var total time.Duration
+	n := 0
+	for _, tr := range r.TestResults {
+		if tr.Latency > 0 {
+			total += tr.Latency
+			n++
+		}
+	}
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dms", int64(total)/int64(n))
+}
+
+// matrixTokens sums the input and output tokens of the evaluated traces.
+func matrixTokens(r *eval.EvalResult) int {
+	tokens := 0
+	for _, tr := range r.TestResults {
+		tokens += tr.TokensIn + tr.TokensOut
+	}
+	return tokens
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	redteamConfigPath   string
+	redteamSessionPath  string
+	redteamTestsPath    string
+	redteamOutputPath   string
+	redteamRequestsPath string
+)
+
+var redteamCmd = &cobra.Command{
+	Use:   "redteam",
+	Short: "Build adversarial test suites from existing cases",
+}
+
+var redteamGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate adversarial variants of existing tests",
+	Long: `Rewrite the prompt of every test with a resolvable trace into adversarial
+variants (prompt injection, jailbreak prefix, encoded instructions, fake system
+delimiters, false authority) and write them to a separate suite tagged "redteam".
+
+Each generated test checks that the model did not comply with the injected
+instruction, and every test must refuse and must not leak the system prompt.
+The matching request bodies are written as JSON lines; send them in
+order under "regrada trace" and evaluate the resulting session with "regrada run --tests <output>".`,
+	Args: cobra.NoArgs,
+	Run:  runRedteamGenerate,
+}
+
+func init() {
+	rootCmd.AddCommand(redteamCmd)
+	redteamCmd.AddCommand(redteamGenerateCmd)
+
+	redteamGenerateCmd.Flags().StringVarP(&redteamConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	redteamGenerateCmd.Flags().StringVarP(&redteamSessionPath, "session", "s", "", "Trace session file (default: latest in .regrada/traces)")
+	redteamGenerateCmd.Flags().StringVarP(&redteamTestsPath, "tests", "t", "", "Path to the source test suite")
+	redteamGenerateCmd.Flags().StringVarP(&redteamOutputPath, "output", "o", "", "Path to write the red team suite (default: <evals.path>/redteam.yaml)")
+	redteamGenerateCmd.Flags().StringVar(&redteamRequestsPath, "requests", "", "Path to write the adversarial requests (default: <evals.path>/redteam_requests.jsonl)")
+}
+
+func runRedteamGenerate(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	cfg, err := config.Load(redteamConfigPath)
+	if err != nil {
+		fmt.Printf("%s Config not found, using defaults\n", warnStyle.Render("Warning:"))
+		cfg = config.Defaults(".")
+	}
+	if redteamTestsPath == "" {
+		redteamTestsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+	if redteamOutputPath == "" {
+		redteamOutputPath = filepath.Join(cfg.Evals.Path, "redteam.yaml")
+	}
+	if redteamRequestsPath == "" {
+		redteamRequestsPath = filepath.Join(cfg.Evals.Path, "redteam_requests.jsonl")
+	}
+
+	suite, err := eval.LoadSuite(redteamTestsPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	var session *trace.TraceSession
+	if redteamSessionPath != "" {
+		session, err = trace.Load(redteamSessionPath)
+	} else {
+		session, err = eval.LoadLatestSession()
+	}
+	if err != nil {
+		fmt.Printf("%s Failed to load trace session: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	redteam, requests := eval.GenerateRedTeam(suite, session)
+	if len(requests) == 0 {
+		fmt.Printf("%s No tests have a trace with a user prompt to rewrite\n", failStyle.Render("✗"))
+		os.Exit(1)
+	}
+
+	if err := eval.SaveSuite(redteam, redteamOutputPath); err != nil {
+		fmt.Printf("%s Failed to write red team suite: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+	if err := writeRedTeamRequests(requests, redteamRequestsPath); err != nil {
+		fmt.Printf("%s Failed to write requests: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s Generated %d adversarial tests from %d tests in %s\n",
+		successStyle.Render("✓"), len(redteam.Tests), len(suite.Tests), redteamOutputPath)
+	fmt.Printf("%s Requests written to %s\n", successStyle.Render("✓"), redteamRequestsPath)
+	fmt.Println(dimStyle.Render(fmt.Sprintf("  Send them in order under `regrada trace`, then `regrada run --tests %s`", redteamOutputPath)))
+}
+
+// writeRedTeamRequests writes one request per line, in trace_index order.
+func writeRedTeamRequests(requests []eval.RedTeamRequest, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, req := range requests {
+		if err := enc.Encode(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -20,6 +20,20 @@ Key commands:
   regrada init                   Initialize new project with interactive setup
   regrada trace -- <command>     Trace LLM API calls from command
   regrada run [options]          Run evaluations and detect regressions
+  regrada ci                     Run the full CI pipeline with gate-aware exit codes
+  regrada accept [options]       Convert a sample of recorded traces into tests
+  regrada baseline gc            Prune stale baseline entries and duplicate sessions
+  regrada baseline list          List the default and named baselines
+  regrada baseline show [name]   Show where a baseline came from
+  regrada baseline promote       Accept the latest results as the baseline
+  regrada bisect --test <name>   Find the session where a test started failing
+  regrada ab -- <command>        Compare two configurations head-to-head
+  regrada diff <a.json> <b.json> Compare two saved results test by test
+  regrada history [show <id>]    List past runs or inspect one
+  regrada bench-checks           Benchmark the check engine against stored traces
+  regrada redteam generate       Generate adversarial variants of existing tests
+  regrada scan [paths...]        Scan tests, prompts, configs, and baselines for secrets
+  regrada sync                   Upload queued traces and results to the backend
   regrada version                Show version information`,
 	Version:      version,
 	SilenceUsage: true,
@@ -8,28 +8,50 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"sort"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/backend"
 	"github.com/matias/regrada/config"
 	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/notify"
+	"github.com/matias/regrada/trace"
+	"github.com/matias/regrada/vcs"
 	"github.com/spf13/cobra"
 )
 
 var (
 	runTestsPath     string
 	runBaselinePath  string
+	runBaselineName  string
+	runSaveBaseline  bool
 	runCIMode        bool
 	runOutputFormat  string
 	runConfigPath    string
 	runVerboseOutput bool
+	runRuns          int
+	runSLOCSVPath    string
+	runNoCheckCache  bool
+	runBadgePath     string
+	runJUnitPath     string
+	runCodeQuality   string
+	runOffline       bool
+	runConcurrency   int
+	runResume        bool
+	runVars          []string
+	runTags          []string
+	runExcludeTags   []string
+	runFilter        string
+	runOnlyFailed    bool
 )
 
 var runCmd = &cobra.Command{
-	Use:   "run",
-	Short: "Run evaluations and detect regressions",
-	Args:  cobra.NoArgs,
-	Run:   runEval,
+	Use:     "run",
+	Aliases: []string{"test"},
+	Short:   "Run evaluations and detect regressions",
+	Args:    cobra.NoArgs,
+	Run:     runEval,
 }
 
 func init() {
@@ -37,13 +59,53 @@ func init() {
 
 	runCmd.Flags().StringVarP(&runTestsPath, "tests", "t", "", "Path to test suite")
 	runCmd.Flags().StringVarP(&runBaselinePath, "baseline", "b", "", "Path to baseline")
-	runCmd.Flags().BoolVar(&runCIMode, "ci", false, "CI mode (exit 1 on regressions)")
+	runCmd.Flags().StringVar(&runBaselineName, "baseline-name", "", "Compare with the named baseline in .regrada/baselines (e.g. prod, canary)")
+	runCmd.Flags().BoolVar(&runSaveBaseline, "save-baseline", false, "Save the results as the baseline (the named one with --baseline-name)")
+	runCmd.Flags().BoolVar(&runCIMode, "ci", false, "CI mode (exit 2 on regressions)")
 	runCmd.Flags().StringVarP(&runOutputFormat, "output", "o", "text", "Output format: text, json, github")
-	runCmd.Flags().StringVarP(&runConfigPath, "config", "c", ".regrada.yaml", "Path to config file")
+	runCmd.Flags().StringVarP(&runConfigPath, "config", "c", config.DefaultPath, "Path to config file")
 	runCmd.Flags().BoolVarP(&runVerboseOutput, "verbose", "v", false, "Verbose output")
+	runCmd.Flags().StringVar(&runSLOCSVPath, "slo-csv", "", "Write the latency SLO report to a CSV file")
+	runCmd.Flags().IntVar(&runRuns, "runs", 1, "Evaluate against the N latest sessions and compare pass rates statistically")
+	runCmd.Flags().StringVar(&runJUnitPath, "junit", "", "Write the results as a JUnit XML report")
+	runCmd.Flags().StringVar(&runCodeQuality, "codequality", "", "Write failed checks as a GitLab code quality report (JSON)")
+	runCmd.Flags().StringVar(&runBadgePath, "badge", "", "Write the quality score as a shields.io endpoint badge (JSON)")
+	runCmd.Flags().BoolVar(&runOffline, "offline", false, "Evaluate recorded traces only: fail fast if any test has no recording, and queue uploads instead of sending them")
+	runCmd.Flags().BoolVar(&runNoCheckCache, "no-check-cache", false, "Re-evaluate every check instead of reusing results for identical outputs")
+	runCmd.Flags().StringArrayVar(&runVars, "var", nil, "Override a test variable as name=value (repeatable)")
+	runCmd.Flags().StringSliceVar(&runTags, "tags", nil, "Only run tests with one of these tags (comma-separated)")
+	runCmd.Flags().StringSliceVar(&runExcludeTags, "exclude-tags", nil, "Skip tests with any of these tags (comma-separated)")
+	runCmd.Flags().StringVar(&runFilter, "filter", "", "Only run tests whose name or case matches this regex or glob")
+	runCmd.Flags().BoolVar(&runOnlyFailed, "only-failed", false, "Only run the tests that failed or errored in the last saved results")
+	runCmd.Flags().BoolVar(&runResume, "resume", false, "Reuse results of tests finished by an interrupted run of the same suite and traces")
+	runCmd.Flags().IntVarP(&runConcurrency, "concurrency", "j", 0, "Tests evaluated at once (default: evals.concurrent)")
+	runCmd.MarkFlagsMutuallyExclusive("baseline", "baseline-name")
 }
 
 func runEval(cmd *cobra.Command, args []string) {
+	result, cfg := executeRun()
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+	if runCIMode && onlyErrored(result) {
+		if runOutputFormat != "json" {
+			fmt.Printf("%s %d test(s) could not be evaluated\n", failStyle.Render("✗"), result.Errored)
+		}
+		os.Exit(ExitInfraError)
+	}
+	if runCIMode && result.Regressions > 0 {
+		os.Exit(ExitRegressions)
+	}
+	if reason := staleBaselineFailure(cfg.Policies.BaselineStaleness, result); runCIMode && reason != "" {
+		if runOutputFormat != "json" {
+			fmt.Printf("%s %s\n", failStyle.Render("✗"), reason)
+		}
+		os.Exit(ExitFailures)
+	}
+}
+
+// executeRun loads the config and suite, evaluates every test against the latest
+// trace session, prints the results, and saves them to .regrada/results.json.
+func executeRun() (*eval.EvalResult, *config.RegradaConfig) {
 	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
 	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
 	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
@@ -72,28 +134,124 @@ func runEval(cmd *cobra.Command, args []string) {
 	suite, err := eval.LoadSuite(runTestsPath)
 	if err != nil {
 		if runOutputFormat == "json" {
-			jsonErr, _ := json.Marshal(map[string]string{"error": err.Error()})
+			jsonErr, _ := json.Marshal(map[string]string{"status": eval.RunError, "error": err.Error()})
 			fmt.Println(string(jsonErr))
 		} else {
 			fmt.Printf("%s Failed to load test suite: %v\n", failStyle.Render("✗"), err)
 		}
-		os.Exit(1)
+		os.Exit(ExitPolicyError)
+	}
+
+	vars, err := eval.ParseVars(runVars)
+	if err != nil {
+		if runOutputFormat == "json" {
+			jsonErr, _ := json.Marshal(map[string]string{"status": eval.RunError, "error": err.Error()})
+			fmt.Println(string(jsonErr))
+		} else {
+			fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		}
+		os.Exit(ExitPolicyError)
+	}
+	suite.SetVars(vars)
+
+	filtered := suite.FilterTags(runTags, runExcludeTags)
+	byName, err := suite.FilterNames(runFilter)
+	filtered = append(filtered, byName...)
+	if err == nil && runOnlyFailed {
+		var previous *eval.EvalResult
+		if previous, err = eval.LoadResults(filepath.Join(".regrada", "results.json")); err != nil {
+			err = fmt.Errorf("--only-failed needs the results of a previous run: %w", err)
+		} else if failed := previous.FailedTests(); len(failed) == 0 {
+			if runOutputFormat == "json" {
+				out, _ := json.Marshal(map[string]string{"status": eval.RunSuccess, "message": "no failed tests in the last run"})
+				fmt.Println(string(out))
+			} else {
+				fmt.Printf("%s No failed tests in the last run\n", successStyle.Render("✓"))
+			}
+			os.Exit(0)
+		} else {
+			filtered = append(filtered, suite.KeepTests(failed)...)
+		}
+	}
+	if err == nil && len(suite.Tests) == 0 && len(filtered) > 0 {
+		err = fmt.Errorf("no tests match the filter")
+	}
+	if err != nil {
+		if runOutputFormat == "json" {
+			jsonErr, _ := json.Marshal(map[string]string{"status": eval.RunError, "error": err.Error()})
+			fmt.Println(string(jsonErr))
+		} else {
+			fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		}
+		os.Exit(ExitPolicyError)
 	}
 
 	if runOutputFormat != "json" {
 		fmt.Printf("Test suite: %s\n", suite.Name)
-		fmt.Printf("Tests: %d\n\n", len(suite.Tests))
+		if len(filtered) > 0 {
+			fmt.Printf("Tests: %d (%d filtered out)\n\n", len(suite.Tests), len(filtered))
+		} else {
+			fmt.Printf("Tests: %d\n\n", len(suite.Tests))
+		}
 	}
 
-	session, err := eval.LoadLatestSession()
+	var sessions []*trace.TraceSession
+	if runRuns > 1 {
+		sessions, err = eval.LoadLatestSessions(runRuns)
+	} else {
+		var session *trace.TraceSession
+		if session, err = eval.LoadLatestSession(); err == nil {
+			sessions = []*trace.TraceSession{session}
+		}
+	}
 	if err != nil {
 		if runOutputFormat == "json" {
-			jsonErr, _ := json.Marshal(map[string]string{"error": err.Error()})
+			jsonErr, _ := json.Marshal(map[string]string{"status": eval.RunError, "error": err.Error()})
 			fmt.Println(string(jsonErr))
 		} else {
 			fmt.Printf("%s Failed to load trace session: %v\n", failStyle.Render("✗"), err)
 		}
-		os.Exit(1)
+		os.Exit(ExitInfraError)
+	}
+
+	if runOffline {
+		if missing := eval.MissingTraces(suite, sessions); len(missing) > 0 {
+			if runOutputFormat == "json" {
+				jsonErr, _ := json.Marshal(map[string]interface{}{
+					"status":  eval.RunError,
+					"error":   fmt.Sprintf("offline: %d tests have no recorded trace", len(missing)),
+					"missing": missing,
+				})
+				fmt.Println(string(jsonErr))
+			} else {
+				fmt.Printf("%s Offline: %d tests have no recorded trace\n", failStyle.Render("✗"), len(missing))
+				for _, m := range missing {
+					fmt.Printf("  - %s\n", m)
+				}
+				fmt.Printf("\n%s Record them with 'regrada trace -- <command>' before running offline\n",
+					dimStyle.Render("Tip:"))
+			}
+			os.Exit(ExitInfraError)
+		}
+		// Results are still queued, so 'regrada sync' can upload them once back online
+		cfg.Backend.Enabled = false
+	}
+
+	// Sessions recorded before pricing was configured (or changed) are priced now
+	if len(cfg.Pricing) > 0 {
+		table := priceTable(cfg)
+		for _, s := range sessions {
+			s.Summary.TotalCostUSD = trace.ApplyPricing(s.Traces, table)
+		}
+	}
+
+	session := sessions[len(sessions)-1]
+	if runRuns > 1 && runOutputFormat != "json" {
+		fmt.Printf("Runs: %d sessions\n\n", len(sessions))
+		if len(sessions) < eval.MinStatRuns {
+			fmt.Printf("%s Fewer than %d runs, baseline comparison uses pass/fail status\n\n",
+				warnStyle.Render("Warning:"), eval.MinStatRuns)
+		}
 	}
 
 	if len(session.Traces) > len(suite.Tests) && runOutputFormat != "json" {
@@ -104,86 +262,256 @@ func runEval(cmd *cobra.Command, args []string) {
 			dimStyle.Render("Tip:"))
 	}
 
-	result := &eval.EvalResult{
-		Timestamp:   time.Now(),
-		TestSuite:   suite.Name,
-		TotalTests:  len(suite.Tests),
-		TestResults: make([]eval.TestResult, 0, len(suite.Tests)),
+	// A baseline picked on the command line wins over a rolling one
+	explicitBaseline := runBaselinePath != "" || runBaselineName != ""
+	localBaseline := runBaselinePath != ""
+	switch {
+	case runBaselineName != "":
+		runBaselinePath = namedBaselinePath(runBaselineName)
+	case runBaselinePath == "":
+		runBaselinePath = filepath.Join(".regrada", "baseline.json")
+	}
+	// With baseline.mode remote or backend, baselines other than a --baseline file are stored there
+	var store backend.Store
+	if !localBaseline {
+		if store, err = baselineStore(cfg); err != nil && runOutputFormat != "json" {
+			fmt.Printf("%s Remote baselines unavailable: %v\n", warnStyle.Render("Warning:"), err)
+		}
+	}
+	baseline, err := loadBaseline(store, runBaselinePath)
+	if err != nil && store != nil && runOutputFormat != "json" {
+		fmt.Printf("%s Failed to fetch baseline: %v\n", warnStyle.Render("Warning:"), err)
+	}
+	if runBaselineName != "" && baseline == nil && !runSaveBaseline && runOutputFormat != "json" {
+		fmt.Printf("%s No baseline named %s (save one with --save-baseline --baseline-name %s)\n", warnStyle.Render("Warning:"), runBaselineName, runBaselineName)
+	}
+	historyDir := filepath.Join(".regrada", "history")
+	rolling := cfg.Baseline.Mode == "rolling"
+	if rolling && !explicitBaseline {
+		// Until there is history to aggregate, the baseline file is used
+		if history, err := eval.LoadHistory(historyDir, suite.Name, cfg.Baseline.RollingRuns()); err == nil && len(history) > 0 {
+			baseline = eval.RollingBaseline(history)
+			if runVerboseOutput && runOutputFormat != "json" {
+				fmt.Printf("%s\n\n", dimStyle.Render(fmt.Sprintf("Rolling baseline over the last %d results", len(history))))
+			}
+		}
 	}
 
-	for _, test := range suite.Tests {
-		if runVerboseOutput {
-			fmt.Printf("  Running: %s... ", test.Name)
+	// similar_to and rubric checks call the embeddings provider and the judge, which --offline forbids
+	env := eval.Env{Concurrency: cfg.Evals.Concurrent}
+	if !runOffline {
+		if env, err = eval.NewEnv(cfg); err != nil && runOutputFormat != "json" {
+			fmt.Printf("%s %v\n", warnStyle.Render("Warning:"), err)
 		}
+	}
 
-		tr, err := eval.GetTraceForTest(test, session)
-		if err != nil {
-			testResult := eval.TestResult{
-				Name:   test.Name,
-				Status: "error",
-				Error:  err.Error(),
+	var checkCache *eval.CheckCache
+	if !runNoCheckCache {
+		checkCache = eval.LoadCheckCache(filepath.Join(".regrada", "cache", "checks.json"))
+		env.CheckCache = checkCache
+	}
+
+	if runConcurrency > 0 {
+		env.Concurrency = runConcurrency
+	}
+	if !runVerboseOutput && runOutputFormat == "text" && isTerminal(os.Stderr) {
+		env.Progress = func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\r%s", dimStyle.Render(fmt.Sprintf("Evaluating %d/%d", done, total)))
+			if done == total {
+				fmt.Fprint(os.Stderr, "\r\033[K")
 			}
-			result.TestResults = append(result.TestResults, testResult)
-			result.Failed++
-			if runVerboseOutput {
-				fmt.Println(failStyle.Render("✗ error: " + err.Error()))
+		}
+	}
+
+	// Results are checkpointed as they complete, so an interrupted run can be resumed
+	runID := eval.RunID(suite, sessions)
+	checkpoint, err := eval.OpenCheckpoint(filepath.Join(".regrada", "checkpoint.jsonl"), runID, runResume)
+	if err != nil && runOutputFormat != "json" {
+		fmt.Printf("%s Failed to open run checkpoint: %v\n", warnStyle.Render("Warning:"), err)
+	}
+	if checkpoint != nil {
+		env.Checkpoint = checkpoint
+		if runResume && runOutputFormat != "json" {
+			if checkpoint.Resumed > 0 {
+				fmt.Printf("Resuming run %s: %d results already evaluated\n\n", runID, checkpoint.Resumed)
+			} else {
+				fmt.Printf("%s No interrupted run %s to resume, evaluating every test\n\n", warnStyle.Render("Warning:"), runID)
 			}
-			continue
 		}
+	}
 
-		testResult := eval.RunTest(test, tr)
-		result.TestResults = append(result.TestResults, testResult)
+	env.OnResult = func(test eval.TestCase, testResult eval.TestResult) {
+		if !runVerboseOutput {
+			return
+		}
 
-		if testResult.Status == "passed" {
-			result.Passed++
-			if runVerboseOutput {
-				fmt.Println(successStyle.Render("✓ passed"))
+		fmt.Printf("  Running: %s... ", test.Name)
+		switch {
+		case testResult.Status == "skipped":
+			fmt.Println(dimStyle.Render("- skipped (" + testResult.Error + ")"))
+		case test.State == eval.StateDraft:
+			fmt.Println(dimStyle.Render(fmt.Sprintf("%s (draft, not gated)", testResult.Status)))
+		case test.XFail != "" && testResult.Status == "passed":
+			fmt.Println(warnStyle.Render("! passed unexpectedly (xfail: " + test.XFail + ")"))
+		case test.XFail != "":
+			fmt.Println(dimStyle.Render(fmt.Sprintf("%s as expected (xfail: %s)", testResult.Status, test.XFail)))
+		case testResult.Status == "error":
+			fmt.Println(failStyle.Render("✗ error: " + testResult.Error))
+		case testResult.Stats != nil:
+			s := testResult.Stats
+			style := successStyle
+			if testResult.Status != "passed" {
+				style = failStyle
 			}
-		} else {
-			result.Failed++
-			if runVerboseOutput {
-				fmt.Println(failStyle.Render("✗ failed"))
-				for _, cr := range testResult.CheckResults {
-					if !cr.Passed {
-						fmt.Printf("      %s: %s\n", cr.Check, cr.Message)
-					}
+			fmt.Println(style.Render(fmt.Sprintf("%d/%d passed (95%% CI %.0f%%-%.0f%%)",
+				s.Passes, s.Runs, s.PassRateLow*100, s.PassRateHigh*100)))
+		case testResult.Status == "passed":
+			fmt.Println(successStyle.Render("✓ passed"))
+		default:
+			fmt.Println(failStyle.Render("✗ failed"))
+			for _, cr := range testResult.CheckResults {
+				if cr.Failed() {
+					fmt.Printf("      %s: %s\n", cr.Check, cr.Message)
 				}
 			}
 		}
 	}
 
-	if runBaselinePath == "" {
-		runBaselinePath = filepath.Join(".regrada", "baseline.json")
+	result, report := eval.Evaluate(cfg, suite, sessions, baseline, env)
+	result.Filtered = len(filtered) > 0
+
+	if checkpoint != nil {
+		checkpoint.Remove()
+	}
+
+	if checkCache != nil {
+		if err := checkCache.Save(); err != nil && runOutputFormat != "json" {
+			fmt.Printf("%s Failed to save check cache: %v\n", warnStyle.Render("Warning:"), err)
+		}
+		if runVerboseOutput && runOutputFormat != "json" {
+			fmt.Printf("\n%s\n", dimStyle.Render(fmt.Sprintf("Check cache: %d hits, %d misses", checkCache.Hits, checkCache.Misses)))
+		}
 	}
 
-	if comp, err := eval.CompareWithBaseline(result, runBaselinePath); err == nil {
-		result.Comparison = comp
-		result.Regressions = len(comp.NewFailures)
+	if len(report.TooFewRuns) > 0 && runOutputFormat != "json" {
+		fmt.Printf("%s Sampling policies skipped for %d tests with too few runs (use --runs)\n", warnStyle.Render("Warning:"), len(report.TooFewRuns))
+	}
+	if len(report.UnapprovedPrompts) > 0 && runOutputFormat != "json" {
+		fmt.Printf("%s Session used %d unapproved system prompt version(s): %s\n", warnStyle.Render("Warning:"), len(report.UnapprovedPrompts), shortFingerprints(report.UnapprovedPrompts))
+	}
+	for _, err := range report.Warnings {
+		if runOutputFormat != "json" {
+			fmt.Printf("%s %v\n", warnStyle.Render("Warning:"), err)
+		}
+	}
 
-		for i := range result.TestResults {
-			for _, name := range comp.NewFailures {
-				if result.TestResults[i].Name == name {
-					result.TestResults[i].Regression = true
-				}
+	if comp := result.Comparison; comp != nil {
+		// Tests left out by a filter weren't removed from the suite
+		comp.RemovedTests = withoutNames(comp.RemovedTests, filtered)
+		if result.Regressions > 0 {
+			paths := append([]string{runTestsPath, runConfigPath}, eval.ReferencedFiles(cfg, suite)...)
+			if commits, err := vcs.CommitsSince(comp.BaselineDate, paths, 5); err == nil {
+				comp.SuspectCommits = commits
+			}
+		}
+	}
+	result.Provenance = resultProvenance(cfg, session)
+	if runBadgePath != "" {
+		if err := eval.WriteScoreBadge(result.Quality, runBadgePath); err != nil && runOutputFormat != "json" {
+			fmt.Printf("%s Failed to write badge: %v\n", warnStyle.Render("Warning:"), err)
+		}
+	}
+
+	if len(cfg.SLO.Latency) > 0 {
+		result.SLOReport = eval.LatencySLOReport(result, baseline, cfg.SLO.Latency)
+		if runSLOCSVPath != "" {
+			if err := eval.WriteSLOCSV(result.SLOReport, runSLOCSVPath); err != nil && runOutputFormat != "json" {
+				fmt.Printf("%s Failed to write SLO report: %v\n", warnStyle.Render("Warning:"), err)
 			}
 		}
 	}
 
+	if runJUnitPath != "" {
+		if err := eval.WriteJUnit(result, runJUnitPath); err != nil && runOutputFormat != "json" {
+			fmt.Printf("%s Failed to write JUnit report: %v\n", warnStyle.Render("Warning:"), err)
+		}
+	}
+	if runCodeQuality != "" {
+		if err := eval.WriteCodeQuality(result, runTestsPath, runCodeQuality); err != nil && runOutputFormat != "json" {
+			fmt.Printf("%s Failed to write code quality report: %v\n", warnStyle.Render("Warning:"), err)
+		}
+	}
+
 	switch runOutputFormat {
 	case "json":
 		outputJSON(result)
 	case "github":
-		outputGitHub(result)
+		trend, _ := eval.Trend(historyDir, result, cfg.History.TrendRuns())
+		outputGitHub(result, trend)
 	default:
 		outputText(result, successStyle, failStyle, warnStyle)
 	}
 
 	resultsPath := filepath.Join(".regrada", "results.json")
 	eval.SaveResults(result, resultsPath)
+	if runSaveBaseline {
+		if location, err := saveRunBaseline(store, result, runBaselinePath); err != nil {
+			if runOutputFormat != "json" {
+				fmt.Printf("%s Failed to save baseline: %v\n", warnStyle.Render("Warning:"), err)
+			}
+		} else if result.Filtered && runOutputFormat != "json" {
+			fmt.Printf("%s\n", dimStyle.Render(fmt.Sprintf("Merged %d filtered test results into the baseline at %s", len(result.TestResults), location)))
+		} else if runOutputFormat != "json" {
+			fmt.Printf("%s\n", dimStyle.Render("Baseline saved to "+location))
+		}
+	}
+	keep := cfg.History.KeepRuns()
+	if rolling {
+		keep = max(keep, cfg.Baseline.RollingRuns())
+	}
+	if err := eval.SaveHistory(result, historyDir, keep); err != nil && runOutputFormat != "json" {
+		fmt.Printf("%s Failed to save run history: %v\n", warnStyle.Render("Warning:"), err)
+	}
 
-	if runCIMode && result.Regressions > 0 {
-		os.Exit(1)
+	var commentURL string
+	if cfg.CI.CommentOnPR {
+		report := func() string {
+			trend, _ := eval.Trend(historyDir, result, cfg.History.TrendRuns())
+			return githubReport(result, trend)
+		}
+		url, err := commentOnPR(report)
+		if err != nil {
+			if runOutputFormat != "json" {
+				fmt.Printf("%s Failed to comment on the pull request: %v\n", warnStyle.Render("Warning:"), err)
+			}
+		} else if url != "" && runOutputFormat != "json" {
+			fmt.Printf("%s\n", dimStyle.Render("Commented on "+url))
+		}
+		commentURL = url
+	}
+
+	if runCIMode && cfg.Notifications.Enabled() {
+		if event, ok := notify.NewEvent(result, cfg.Project, cfg.Notifications.MinSeverity()); ok {
+			if url := vcs.CIRunURL(); url != "" {
+				event.Links = append(event.Links, notify.Link{Title: "CI run", URL: url})
+			}
+			if strings.HasPrefix(commentURL, "http") {
+				event.Links = append(event.Links, notify.Link{Title: "Report", URL: commentURL})
+			}
+			if err := notify.Send(cfg.Notifications, event); err != nil && runOutputFormat != "json" {
+				fmt.Printf("%s Failed to send notifications: %v\n", warnStyle.Render("Warning:"), err)
+			}
+		}
 	}
+
+	resultID := fmt.Sprintf("%d", result.Timestamp.UnixNano())
+	if queued, err := submitToBackend(cfg, backend.KindResults, resultID, result); err != nil && runOutputFormat != "json" {
+		fmt.Printf("%s Failed to queue upload: %v\n", warnStyle.Render("Warning:"), err)
+	} else if queued && runOutputFormat != "json" {
+		fmt.Printf("%s Upload queued, run 'regrada sync' to push it\n", dimStyle.Render("→"))
+	}
+
+	return result, cfg
 }
 
 func outputText(result *eval.EvalResult, successStyle, failStyle, warnStyle lipgloss.Style) {
@@ -192,46 +520,446 @@ func outputText(result *eval.EvalResult, successStyle, failStyle, warnStyle lipg
 	fmt.Printf("  Total: %d\n", result.TotalTests)
 	fmt.Printf("  %s: %d\n", successStyle.Render("Passed"), result.Passed)
 	fmt.Printf("  %s: %d\n", failStyle.Render("Failed"), result.Failed)
+	if result.Drafts > 0 {
+		fmt.Printf("  Drafts (not gated): %d\n", result.Drafts)
+	}
+	if result.XFailed > 0 {
+		fmt.Printf("  Expected failures: %d\n", result.XFailed)
+	}
+	if result.XPassed > 0 {
+		fmt.Printf("  %s: %d (remove their xfail marker)\n", warnStyle.Render("Unexpected passes"), result.XPassed)
+		for _, tr := range result.TestResults {
+			if tr.XFail != "" && tr.Status == "passed" {
+				fmt.Printf("    - %s: %s\n", tr.Name, tr.XFail)
+			}
+		}
+	}
+	if q := result.Quality; q != nil {
+		fmt.Printf("  Quality score: %s\n", formatScore(q))
+	}
+	if d := result.Scores; d != nil {
+		fmt.Printf("  Rubric scores: mean %.2f, p50 %.2f, min %.2f (%d tests)\n", d.Mean, d.P50, d.Min, d.Count)
+	}
+	if len(result.PassAtK) > 0 {
+		fmt.Printf("  Sampled: %s\n", formatPassAtK(result.PassAtK))
+	}
+	if p := result.Pairwise; p != nil {
+		fmt.Printf("  Pairwise vs baseline: %s\n", formatPairwise(p))
+		for _, tr := range result.TestResults {
+			if tr.Pairwise != nil && tr.Pairwise.Outcome == eval.PairwiseLoss {
+				fmt.Printf("    - %s: %s\n", tr.Name, tr.Pairwise.Reason)
+			}
+		}
+	}
+	if result.CostUSD > 0 {
+		fmt.Printf("  Estimated cost: $%.4f\n", result.CostUSD)
+	}
+	if result.Retries > 0 {
+		fmt.Printf("  Provider retries: %d (transient failures retried while tracing)\n", result.Retries)
+	}
+	if result.Footprint != nil {
+		fmt.Printf("  Estimated footprint: %.2f Wh, %.2f g CO2e\n", result.Footprint.EnergyWh, result.Footprint.CarbonGrams)
+	}
+	if result.Skipped > 0 {
+		fmt.Printf("  Skipped: %d\n", result.Skipped)
+		for _, tr := range result.TestResults {
+			if tr.Status == "skipped" {
+				fmt.Printf("    - %s: %s\n", tr.Name, tr.Error)
+			}
+		}
+	}
+	if result.Comparison != nil {
+		fmt.Printf("  Baseline: %s\n", formatBaseline(result.Comparison))
+		if len(result.Comparison.Stale) > 0 {
+			fmt.Printf("  %s: %s\n", warnStyle.Render("Stale baseline"), strings.Join(result.Comparison.Stale, "; "))
+		}
+	}
+
+	if len(result.Sections) > 0 {
+		fmt.Println()
+		for _, s := range result.Sections {
+			style := successStyle
+			if s.Failed > 0 {
+				style = failStyle
+			}
+			fmt.Printf("  %-30s %s\n", s.Title, style.Render(fmt.Sprintf("%d/%d passed", s.Passed, s.Total)))
+			for _, name := range s.FailedTests {
+				fmt.Printf("    - %s\n", name)
+			}
+		}
+	}
 
 	if result.Comparison != nil && len(result.Comparison.NewFailures) > 0 {
 		fmt.Printf("  %s: %d\n", warnStyle.Render("Regressions"), result.Regressions)
 		fmt.Println()
 		fmt.Println(warnStyle.Render("New failures (regressions):"))
 		for _, name := range result.Comparison.NewFailures {
-			fmt.Printf("  - %s\n", name)
+			fmt.Printf("  - %s%s\n", name, ownerSuffix(result.OwnerOf(name)))
+		}
+
+		if len(result.Comparison.SuspectCommits) > 0 {
+			fmt.Println()
+			fmt.Println(warnStyle.Render("Recent commits touching tests or config:"))
+			for _, c := range result.Comparison.SuspectCommits {
+				fmt.Printf("  - %s %s (%s)\n", shortSHA(c.SHA), c.Subject, c.Author)
+			}
+		}
+
+		if len(result.Comparison.PromptEdits) > 0 {
+			fmt.Println()
+			fmt.Println(warnStyle.Render("Prompt files changed since the baseline:"))
+			for _, edit := range result.Comparison.PromptEdits {
+				fmt.Printf("  - %s\n", edit)
+			}
+		}
+	}
+
+	if len(result.SLOReport) > 0 {
+		fmt.Println()
+		fmt.Println("Latency SLOs:")
+		for _, row := range result.SLOReport {
+			style := successStyle
+			if !row.Compliant {
+				style = failStyle
+			}
+			line := fmt.Sprintf("  %-20s %-16s %-24s %s", row.SLO, row.Tag, row.Model,
+				style.Render(fmt.Sprintf("%.1f%% (%d/%d)", row.Compliance*100, row.Met, row.Total)))
+			if row.Baseline != nil {
+				line += fmt.Sprintf("  baseline %.1f%%", *row.Baseline*100)
+			}
+			fmt.Println(line)
+		}
+	}
+
+	if result.Comparison != nil && len(result.Comparison.BehaviorChanges) > 0 {
+		fmt.Println()
+		fmt.Println(warnStyle.Render("Behavior changes:"))
+		for _, change := range result.Comparison.BehaviorChanges {
+			fmt.Printf("  - %s\n", change)
 		}
 	}
 
 	fmt.Println()
 }
 
+// withoutNames returns names without any of the excluded names.
+func withoutNames(names, excluded []string) []string {
+	if len(excluded) == 0 {
+		return names
+	}
+	kept := names[:0]
+	for _, name := range names {
+		if !contains(excluded, name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// shortFingerprints abbreviates prompt fingerprints for display.
+func shortFingerprints(fingerprints []string) string {
+	short := make([]string, len(fingerprints))
+	for i, f := range fingerprints {
+		short[i] = f[:min(len(f), 12)]
+	}
+	return strings.Join(short, ", ")
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatScore formats a quality score with its change from the baseline, if known.
+func formatScore(q *eval.QualityScore) string {
+	if q.BaselineScore == nil {
+		return fmt.Sprintf("%.1f", q.Score)
+	}
+	return fmt.Sprintf("%.1f (%+.1f vs baseline)", q.Score, q.Delta())
+}
+
+// formatBaseline describes the baseline a run was compared with: when it was saved and,
+// if recorded, its commit, provider/model, and regrada version.
+func formatBaseline(comp *eval.BaselineComparison) string {
+	saved := "saved " + comp.BaselineDate.Format("2006-01-02 15:04")
+	if p := comp.Baseline.String(); p != "" {
+		return p + " (" + saved + ")"
+	}
+	return saved
+}
+
+// formatPassAtK formats run-level pass@k averages in order of k, then the majority vote.
+func formatPassAtK(aggregates map[string]float64) string {
+	var ks []int
+	for key := range aggregates {
+		var k int
+		if _, err := fmt.Sscanf(key, "pass@%d", &k); err == nil {
+			ks = append(ks, k)
+		}
+	}
+	sort.Ints(ks)
+
+	var parts []string
+	for _, k := range ks {
+		parts = append(parts, fmt.Sprintf("pass@%d %.2f", k, aggregates[fmt.Sprintf("pass@%d", k)]))
+	}
+	parts = append(parts, fmt.Sprintf("majority %.2f", aggregates["majority"]))
+	return strings.Join(parts, ", ")
+}
+
+// formatPairwise formats pairwise comparison counts with the loss rate.
+func formatPairwise(p *eval.PairwiseSummary) string {
+	return fmt.Sprintf("%d wins, %d ties, %d losses (loss rate %.1f%%)", p.Wins, p.Ties, p.Losses, p.LossRate*100)
+}
+
+// ownerSuffix formats a test owner for appending to a test name.
+func ownerSuffix(owner string) string {
+	if owner == "" {
+		return ""
+	}
+	return " (owner: " + owner + ")"
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
 func outputJSON(result *eval.EvalResult) {
 	data, _ := json.MarshalIndent(result, "", "  ")
 	fmt.Println(string(data))
 }
 
-func outputGitHub(result *eval.EvalResult) {
+func outputGitHub(result *eval.EvalResult, trend []eval.RunSummary) {
+	fmt.Println(githubReport(result, trend))
+}
+
+// commentOnPR upserts the sticky report comment on the GitHub pull request or GitLab
+// merge request the CI job runs for, and returns its URL. Outside of one it posts
+// nothing and returns "".
+func commentOnPR(report func() string) (string, error) {
+	if pr, ok := vcs.CurrentPullRequest(); ok {
+		client, err := vcs.NewGitHubClient()
+		if err != nil {
+			return "", err
+		}
+		return client.UpsertComment(pr, report())
+	}
+	if mr, ok := vcs.CurrentMergeRequest(); ok {
+		client, err := vcs.NewGitLabClient()
+		if err != nil {
+			return "", err
+		}
+		return client.UpsertNote(mr, report())
+	}
+	return "", nil
+}
+
+// githubReport renders the result as the markdown report used for GitHub job summaries
+// and pull request comments.
+func githubReport(result *eval.EvalResult, trend []eval.RunSummary) string {
 	var buf bytes.Buffer
 
 	fmt.Fprintf(&buf, "## Regrada Evaluation Results\n\n")
 	fmt.Fprintf(&buf, "**Total Tests:** %d  \n", result.TotalTests)
 	fmt.Fprintf(&buf, "**Passed:** %d ✓  \n", result.Passed)
 	fmt.Fprintf(&buf, "**Failed:** %d ✗  \n", result.Failed)
+	if result.Drafts > 0 {
+		fmt.Fprintf(&buf, "**Drafts (not gated):** %d  \n", result.Drafts)
+	}
+	if result.XFailed > 0 {
+		fmt.Fprintf(&buf, "**Expected failures:** %d  \n", result.XFailed)
+	}
+	if result.XPassed > 0 {
+		var names []string
+		for _, tr := range result.TestResults {
+			if tr.XFail != "" && tr.Status == "passed" {
+				names = append(names, tr.Name)
+			}
+		}
+		fmt.Fprintf(&buf, "**Unexpected passes (remove xfail):** %s  \n", strings.Join(names, ", "))
+	}
+	if q := result.Quality; q != nil {
+		fmt.Fprintf(&buf, "**Quality score:** %s  \n", formatScore(q))
+	}
+	if d := result.Scores; d != nil {
+		fmt.Fprintf(&buf, "**Rubric scores:** mean %.2f, p50 %.2f, min %.2f (%d tests)  \n", d.Mean, d.P50, d.Min, d.Count)
+	}
+	if len(result.PassAtK) > 0 {
+		fmt.Fprintf(&buf, "**Sampled:** %s  \n", formatPassAtK(result.PassAtK))
+	}
+	if p := result.Pairwise; p != nil {
+		fmt.Fprintf(&buf, "**Pairwise vs baseline:** %s  \n", formatPairwise(p))
+	}
+	if result.CostUSD > 0 {
+		fmt.Fprintf(&buf, "**Estimated cost:** $%.4f  \n", result.CostUSD)
+	}
+	if result.Retries > 0 {
+		fmt.Fprintf(&buf, "**Provider retries:** %d  \n", result.Retries)
+	}
+	if result.Footprint != nil {
+		fmt.Fprintf(&buf, "**Estimated footprint:** %.2f Wh, %.2f g CO2e  \n", result.Footprint.EnergyWh, result.Footprint.CarbonGrams)
+	}
+	if result.Skipped > 0 {
+		fmt.Fprintf(&buf, "**Skipped:** %d  \n", result.Skipped)
+	}
+	if result.Comparison != nil {
+		fmt.Fprintf(&buf, "**Baseline:** %s  \n", formatBaseline(result.Comparison))
+		if len(result.Comparison.Stale) > 0 {
+			fmt.Fprintf(&buf, "**⚠️ Stale baseline:** %s  \n", strings.Join(result.Comparison.Stale, "; "))
+		}
+	}
+
+	if len(result.Sections) > 0 {
+		fmt.Fprintf(&buf, "\n| Area | Passed | Failed | Regressions |\n|---|---|---|---|\n")
+		for _, s := range result.Sections {
+			fmt.Fprintf(&buf, "| %s | %d | %d | %d |\n", s.Title, s.Passed, s.Failed, s.Regressions)
+		}
+		for _, s := range result.Sections {
+			if len(s.FailedTests) == 0 {
+				continue
+			}
+			fmt.Fprintf(&buf, "\n#### %s\n\n", s.Title)
+			for _, name := range s.FailedTests {
+				fmt.Fprintf(&buf, "- %s%s\n", name, ownerSuffix(result.OwnerOf(name)))
+			}
+		}
+	}
 
 	if result.Regressions > 0 {
 		fmt.Fprintf(&buf, "\n### ⚠️ Regressions Detected: %d\n\n", result.Regressions)
 		fmt.Fprintf(&buf, "The following tests passed in the baseline but are now failing:\n\n")
 		for _, name := range result.Comparison.NewFailures {
-			fmt.Fprintf(&buf, "- %s\n", name)
+			fmt.Fprintf(&buf, "- %s%s\n", name, ownerSuffix(result.OwnerOf(name)))
+		}
+
+		if len(result.Comparison.SuspectCommits) > 0 {
+			fmt.Fprintf(&buf, "\nRecent commits touching tests or config:\n\n")
+			for _, c := range result.Comparison.SuspectCommits {
+				fmt.Fprintf(&buf, "- `%s` %s (%s)\n", shortSHA(c.SHA), c.Subject, c.Author)
+			}
+		}
+
+		if len(result.Comparison.PromptEdits) > 0 {
+			fmt.Fprintf(&buf, "\nPrompt files changed since the baseline:\n\n")
+			for _, edit := range result.Comparison.PromptEdits {
+				fmt.Fprintf(&buf, "- `%s`\n", edit)
+			}
 		}
 	}
 
-	if len(result.Comparison.NewPasses) > 0 {
+	if len(result.SLOReport) > 0 {
+		fmt.Fprintf(&buf, "\n### Latency SLOs\n\n")
+		fmt.Fprintf(&buf, "| SLO | Tag | Model | Compliance | Baseline |\n|---|---|---|---|---|\n")
+		for _, row := range result.SLOReport {
+			mark := "✓"
+			if !row.Compliant {
+				mark = "✗"
+			}
+			baseline := "-"
+			if row.Baseline != nil {
+				baseline = fmt.Sprintf("%.1f%%", *row.Baseline*100)
+			}
+			fmt.Fprintf(&buf, "| %s | %s | %s | %s %.1f%% (%d/%d) | %s |\n",
+				row.SLO, row.Tag, row.Model, mark, row.Compliance*100, row.Met, row.Total, baseline)
+		}
+	}
+
+	if result.Comparison != nil && len(result.Comparison.NewPasses) > 0 {
 		fmt.Fprintf(&buf, "\n### ✓ Fixed Tests: %d\n\n", len(result.Comparison.NewPasses))
 		for _, name := range result.Comparison.NewPasses {
 			fmt.Fprintf(&buf, "- %s\n", name)
 		}
 	}
 
-	fmt.Println(buf.String())
+	if len(trend) > 1 {
+		writeTrend(&buf, trend)
+	}
+
+	return buf.String()
+}
+
+// matrixReport renders a matrix run as a markdown report, for GitHub job summaries
+// and .regrada/matrix.md.
+func matrixReport(suite *eval.TestSuite, runs []matrixRun) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "## Regrada Matrix Results\n")
+	writeMatrix(&buf, suite, runs)
+	return buf.String()
+}
+
+// writeMatrix adds a comparison table of every test's status per matrix entry to a
+// report, followed by each entry's totals.
+func writeMatrix(buf *bytes.Buffer, suite *eval.TestSuite, runs []matrixRun) {
+	header := "| Test |"
+	divider := "|---|"
+	for _, run := range runs {
+		header += " " + run.Name + " |"
+		divider += "---|"
+	}
+
+	fmt.Fprintf(buf, "\n### Model Matrix\n\n%s\n%s\n", header, divider)
+	for _, test := range suite.Tests {
+		fmt.Fprintf(buf, "| %s |", test.Name)
+		for _, run := range runs {
+			text := "-"
+			if tr := matrixTestResult(run, test.Name); tr != nil {
+				switch {
+				case tr.Regression:
+					text = "✗ regressed"
+				case tr.Status == "passed":
+					text = "✓"
+				case tr.Status == "failed":
+					text = "✗"
+				default:
+					text = tr.Status
+				}
+			}
+			fmt.Fprintf(buf, " %s |", text)
+		}
+		fmt.Fprintln(buf)
+	}
+
+	fmt.Fprintf(buf, "\n| |%s\n%s\n", strings.TrimPrefix(header, "| Test |"), divider)
+	for _, row := range matrixTotals {
+		fmt.Fprintf(buf, "| %s |", row.label)
+		for _, run := range runs {
+			text := "error"
+			if run.Result != nil {
+				text = row.value(run.Result)
+			}
+			fmt.Fprintf(buf, " %s |", text)
+		}
+		fmt.Fprintln(buf)
+	}
+	for _, run := range runs {
+		if run.Error != "" {
+			fmt.Fprintf(buf, "\n**%s failed:** %s\n", run.Name, run.Error)
+		}
+	}
+}
+
+// writeTrend adds the pass rate and p95 latency of the recent runs to a report, so a
+// metric that has been degrading shows up even when each run is close to the last.
+func writeTrend(buf *bytes.Buffer, trend []eval.RunSummary) {
+	passRates := make([]float64, len(trend))
+	latencies := make([]float64, 0, len(trend))
+	for i, run := range trend {
+		passRates[i] = run.PassRate * 100
+		if run.LatencyP95 > 0 {
+			latencies = append(latencies, float64(run.LatencyP95))
+		}
+	}
+
+	fmt.Fprintf(buf, "\n### Trends (last %d runs)\n\n", len(trend))
+	fmt.Fprintf(buf, "| Metric | Trend | First | Latest |\n|---|---|---|---|\n")
+	fmt.Fprintf(buf, "| Pass rate | %s | %.1f%% | %.1f%% |\n",
+		eval.Sparkline(passRates), passRates[0], passRates[len(passRates)-1])
+	if len(latencies) > 1 {
+		fmt.Fprintf(buf, "| p95 latency | %s | %dms | %dms |\n",
+			eval.Sparkline(latencies), int64(latencies[0]), int64(latencies[len(latencies)-1]))
+	}
 }
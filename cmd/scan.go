@@ -0,0 +1,151 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/redact"
+	"github.com/matias/regrada/trace"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scanConfigPath  string
+	scanSecretsOnly bool
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [paths...]",
+	Short: "Scan tests, prompts, configs, and baselines for secrets and PII",
+	Long: `Check the files regrada commits to your repository for credentials and personal
+data before they are pushed. By default the config, the evals directory, the system
+prompt file, the default and named baselines, the cassettes directory, and the shared
+trace bodies in .regrada/traces/objects are scanned; extra files or directories can be
+passed as arguments. Exits 1 when anything is found.`,
+	Run: runScan,
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+
+	scanCmd.Flags().StringVarP(&scanConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	scanCmd.Flags().BoolVar(&scanSecretsOnly, "secrets-only", false, "Report only credentials, not personal data")
+}
+
+func runScan(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	cfg, err := config.Load(scanConfigPath)
+	if err != nil {
+		cfg = config.Defaults(".")
+	}
+
+	// Baselines, cassettes, and shared trace bodies hold recorded prompts and responses
+	targets := []string{scanConfigPath, cfg.Evals.Path, filepath.Join(".regrada", "baseline.json")}
+	named, _ := filepath.Glob(filepath.Join(".regrada", "baselines", "*.json"))
+	targets = append(targets, named...)
+	cassetteDir := cfg.Cassettes.Dir
+	if cassetteDir == "" {
+		cassetteDir = filepath.Join(".regrada", "cassettes")
+	}
+	targets = append(targets, cassetteDir, filepath.Join(".regrada", "traces", trace.ObjectsDir))
+	if cfg.Provider.SystemPromptFile != "" {
+		targets = append(targets, cfg.Provider.SystemPromptFile)
+		if _, files, err := config.LoadPrompt(cfg.Provider.SystemPromptFile); err == nil {
+			targets = append(targets, files...)
+		}
+	}
+	targets = append(targets, args...)
+
+	files := scanFiles(targets)
+	findings := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil || isBinary(data) {
+			continue
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			for _, m := range redact.Find(scanner.Text()) {
+				if scanSecretsOnly && !m.Secret {
+					continue
+				}
+				label, style := "PII", warnStyle
+				if m.Secret {
+					label, style = "Secret", failStyle
+				}
+				fmt.Printf("%s:%d:%d: %s %s (%s)\n", file, line, m.Start+1, style.Render(label), m.Kind, redact.Mask(m.Value))
+				findings++
+			}
+		}
+	}
+
+	fmt.Println()
+	if findings > 0 {
+		fmt.Printf("%s %d finding(s) in %d scanned file(s)\n", failStyle.Render("✗"), findings, len(files))
+		fmt.Println(dimStyle.Render("Remove or redact these values before pushing"))
+		os.Exit(1)
+	}
+	fmt.Printf("%s No secrets or PII found in %d file(s)\n", successStyle.Render("✓"), len(files))
+}
+
+// scanFiles expands the targets into a de-duplicated list of regular files.
+// Missing targets are ignored; directories are walked, skipping hidden subdirectories.
+func scanFiles(targets []string) []string {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		clean := filepath.Clean(path)
+		if !seen[clean] {
+			seen[clean] = true
+			files = append(files, clean)
+		}
+	}
+
+	for _, target := range targets {
+		info, err := os.Stat(target)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			add(target)
+			continue
+		}
+		filepath.WalkDir(target, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != target && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			add(path)
+			return nil
+		})
+	}
+	return files
+}
+
+// isBinary reports whether data looks like a binary or compressed file.
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/backend"
+	"github.com/matias/regrada/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncConfigPath string
+	syncBatchSize  int
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Upload queued traces and results to the backend",
+	Long:  "Push every pending upload in .regrada/outbox to the Regrada backend in batches.",
+	Args:  cobra.NoArgs,
+	Run:   runSync,
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().StringVarP(&syncConfigPath, "config", "c", config.DefaultPath, "Path to config file")
+	syncCmd.Flags().IntVar(&syncBatchSize, "batch-size", 20, "Number of items per upload request")
+}
+
+func runSync(cmd *cobra.Command, args []string) {
+	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+	cfg, err := config.Load(syncConfigPath)
+	if err != nil {
+		fmt.Printf("%s Failed to load config: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	client, err := backend.NewClient(cfg.Backend)
+	if err != nil {
+		fmt.Printf("%s %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	entries, err := backend.Pending()
+	if err != nil {
+		fmt.Printf("%s Failed to read outbox: %v\n", failStyle.Render("✗"), err)
+		os.Exit(1)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(dimStyle.Render("Nothing to sync"))
+		return
+	}
+
+	if syncBatchSize <= 0 {
+		syncBatchSize = 1
+	}
+
+	byKind := make(map[string][]backend.Entry)
+	var kinds []string
+	for _, entry := range entries {
+		if _, ok := byKind[entry.Kind]; !ok {
+			kinds = append(kinds, entry.Kind)
+		}
+		byKind[entry.Kind] = append(byKind[entry.Kind], entry)
+	}
+
+	uploaded, failed := 0, 0
+	for _, kind := range kinds {
+		pending := byKind[kind]
+		for start := 0; start < len(pending); start += syncBatchSize {
+			end := start + syncBatchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			batch := pending[start:end]
+
+			items := make([]json.RawMessage, len(batch))
+			for i, entry := range batch {
+				items[i] = entry.Payload
+			}
+
+			if err := client.Upload(kind, items); err != nil {
+				failed += len(batch)
+				fmt.Printf("%s Failed to upload %d %s: %v\n", failStyle.Render("✗"), len(batch), kind, err)
+				continue
+			}
+
+			for _, entry := range batch {
+				backend.Remove(entry)
+			}
+			uploaded += len(batch)
+			fmt.Printf("  %s %d/%d uploaded\n", dimStyle.Render("→"), uploaded, len(entries))
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%s Synced %d items\n", successStyle.Render("✓"), uploaded)
+	if failed > 0 {
+		fmt.Printf("%s %d items remain queued\n", failStyle.Render("✗"), failed)
+		os.Exit(1)
+	}
+}
@@ -3,6 +3,8 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,9 +15,11 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/matias/regrada/backend"
 	"github.com/matias/regrada/config"
 	"github.com/matias/regrada/eval"
 	"github.com/matias/regrada/proxy"
+	"github.com/matias/regrada/redact"
 	"github.com/matias/regrada/trace"
 	"github.com/spf13/cobra"
 )
@@ -28,14 +32,19 @@ var (
 	traceVerbose      bool
 	traceUpdateTests  bool
 	traceOnConflict   string
+	traceSystemPrompt string
+	tracePreview      bool
+	traceCassettes    string
+	traceNoCache      bool
 )
 
 var traceCmd = &cobra.Command{
-	Use:   "trace -- <command>",
-	Short: "Trace LLM API calls from a command",
-	Long:  "Start a proxy, run your command, and capture LLM API calls for regression testing.",
-	Args:  cobra.ArbitraryArgs,
-	Run:   runTrace,
+	Use:     "trace -- <command>",
+	Aliases: []string{"record"},
+	Short:   "Trace LLM API calls from a command",
+	Long:    "Start a proxy, run your command, and capture LLM API calls for regression testing.",
+	Args:    cobra.ArbitraryArgs,
+	Run:     runTrace,
 }
 
 func init() {
@@ -43,11 +52,18 @@ func init() {
 
 	traceCmd.Flags().BoolVarP(&traceSaveBaseline, "save-baseline", "b", false, "Save traces as baseline")
 	traceCmd.Flags().StringVarP(&traceOutputFile, "output", "o", "", "Output file for traces")
-	traceCmd.Flags().StringVarP(&traceConfigPath, "config", "c", ".regrada.yaml", "Path to config file")
+	traceCmd.Flags().StringVarP(&traceConfigPath, "config", "c", config.DefaultPath, "Path to config file")
 	traceCmd.Flags().BoolVar(&traceNoProxy, "no-proxy", false, "Run without proxy")
 	traceCmd.Flags().BoolVarP(&traceVerbose, "verbose", "v", false, "Verbose output")
 	traceCmd.Flags().BoolVar(&traceUpdateTests, "update-tests", false, "Auto-generate test stubs for new traces")
 	traceCmd.Flags().StringVar(&traceOnConflict, "on-conflict", "merge", "Handle existing tests: merge, replace, append")
+	traceCmd.Flags().BoolVar(&tracePreview, "preview", false, "Warn immediately when captured traffic contains PII or secrets")
+	traceCmd.Flags().StringVar(&traceCassettes, "cassettes", "", "Cassette mode: off, record, replay, auto (overrides cassettes.mode)")
+	traceCmd.Flags().BoolVar(&traceNoCache, "no-cache", false, "Call the provider even when cache.enabled is set")
+	traceCmd.Flags().StringVar(&traceSystemPrompt, "system-prompt-file", "", "Replace the system prompt of every traced request with this file")
+	// Accepted for scripts written for earlier releases, which could also save YAML
+	traceCmd.Flags().StringP("format", "f", "json", "Output format (sessions are always saved as JSON)")
+	traceCmd.Flags().MarkDeprecated("format", "sessions are always saved as JSON")
 
 	traceCmd.Flags().SetInterspersed(false)
 }
@@ -78,6 +94,16 @@ func runTrace(cmd *cobra.Command, args []string) {
 		cfg = config.Defaults(".")
 	}
 
+	if traceSystemPrompt != "" {
+		cfg.Provider.SystemPromptFile = traceSystemPrompt
+	}
+	if traceCassettes != "" {
+		cfg.Cassettes.Mode = traceCassettes
+	}
+	if traceNoCache {
+		cfg.Cache.Enabled = false
+	}
+
 	traceDir := filepath.Join(".regrada", "traces")
 	if err := os.MkdirAll(traceDir, 0755); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: failed to create trace directory %s: %v\n", traceDir, err)
@@ -92,6 +118,7 @@ func runTrace(cmd *cobra.Command, args []string) {
 			StartTime: time.Now(),
 			Command:   strings.Join(args, " "),
 			Traces:    []trace.LLMTrace{},
+			Metadata:  sessionMetadata(cfg),
 		}
 
 		exitCode := executeCommand(args, nil)
@@ -107,6 +134,15 @@ func runTrace(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 
+		if tracePreview {
+			prox.OnTrace = func(tr trace.LLMTrace) {
+				previewTrace(tr, warnStyle)
+			}
+		}
+		prox.OnWarning = func(msg string) {
+			fmt.Fprintf(os.Stderr, "%s %s\n", warnStyle.Render("⚠ regrada:"), msg)
+		}
+
 		proxyAddr := prox.Address()
 		if traceVerbose {
 			fmt.Printf("%s Proxy running on %s\n", dimStyle.Render("→"), proxyAddr)
@@ -118,13 +154,14 @@ func runTrace(cmd *cobra.Command, args []string) {
 			ID:        generateTraceID(),
 			StartTime: time.Now(),
 			Command:   strings.Join(args, " "),
+			Metadata:  sessionMetadata(cfg),
 		}
 
 		exitCode := executeCommand(args, env)
 		session.EndTime = time.Now()
 
 		session.Traces = prox.Traces()
-		session.Summary = trace.CalculateSummary(session.Traces)
+		session.Summary = summarizeSession(session.Traces, cfg)
 
 		prox.Shutdown()
 
@@ -135,7 +172,7 @@ func runTrace(cmd *cobra.Command, args []string) {
 
 	outputPath := traceOutputFile
 	if outputPath == "" {
-		outputPath = filepath.Join(traceDir, fmt.Sprintf("%s.json", session.ID))
+		outputPath = filepath.Join(traceDir, trace.FileName(session.ID, cfg.Storage.Compression == "gzip"))
 	}
 
 	if err := trace.Save(session, outputPath); err != nil {
@@ -143,6 +180,12 @@ func runTrace(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	if queued, err := submitToBackend(cfg, backend.KindSessions, session.ID, session); err != nil {
+		fmt.Printf("%s Failed to queue upload: %v\n", warnStyle.Render("Warning:"), err)
+	} else if queued {
+		fmt.Printf("%s Upload queued, run 'regrada sync' to push it\n", dimStyle.Render("→"))
+	}
+
 	if traceSaveBaseline {
 		baselinePath := filepath.Join(".regrada", "baseline.json")
 		if err := trace.Save(session, baselinePath); err != nil {
@@ -166,7 +209,7 @@ func runTrace(cmd *cobra.Command, args []string) {
 	if traceUpdateTests && !traceSaveBaseline && len(session.Traces) > 0 {
 		testsPath := filepath.Join(cfg.Evals.Path, "tests.yaml")
 
-		existingSuite, err := eval.LoadSuite(testsPath)
+		existingSuite, err := eval.ReadSuite(testsPath)
 		if err != nil {
 			fmt.Printf("%s No existing tests found, skipping test update\n", warnStyle.Render("Warning:"))
 		} else if len(session.Traces) > len(existingSuite.Tests) {
@@ -194,6 +237,98 @@ func runTrace(cmd *cobra.Command, args []string) {
 	fmt.Printf("%s Traces saved to %s\n", successStyle.Render("✓"), outputPath)
 }
 
+// previewTrace prints a warning for each piece of sensitive data found in a captured trace.
+func previewTrace(tr trace.LLMTrace, warnStyle lipgloss.Style) {
+	for _, part := range []struct {
+		name string
+		body []byte
+	}{
+		{"request", tr.Request.Body},
+		{"response", tr.Response.Body},
+	} {
+		for _, m := range redact.Find(string(part.body)) {
+			label := "PII"
+			if m.Secret {
+				label = "Secret"
+			}
+			fmt.Fprintf(os.Stderr, "%s %s in %s %s (%s): %s\n",
+				warnStyle.Render("⚠ regrada:"), label, tr.Endpoint, part.name, m.Kind, redact.Mask(m.Value))
+		}
+	}
+}
+
+// summarizeSession aggregates session statistics, adding a footprint estimate when
+// sustainability reporting is enabled and pricing each trace when a pricing table is set.
+func summarizeSession(traces []trace.LLMTrace, cfg *config.RegradaConfig) trace.TraceSummary {
+	summary := trace.CalculateSummary(traces)
+	summary.TotalCostUSD = trace.ApplyPricing(traces, priceTable(cfg))
+
+	if s := cfg.Sustainability; s.Enabled {
+		factors := trace.FootprintFactors{
+			WhPer1KTokens:  s.WhPer1KTokens,
+			ModelWhPer1K:   s.Models,
+			GramsCO2PerKWh: s.GramsCO2PerKWh,
+		}
+		if factors.WhPer1KTokens == 0 {
+			factors.WhPer1KTokens = 0.3
+		}
+		if factors.GramsCO2PerKWh == 0 {
+			factors.GramsCO2PerKWh = 400
+		}
+		fp := trace.EstimateFootprint(traces, factors)
+		summary.Footprint = &fp
+	}
+
+	return summary
+}
+
+// priceTable converts the configured pricing to a trace.PriceTable.
+func priceTable(cfg *config.RegradaConfig) trace.PriceTable {
+	table := make(trace.PriceTable, len(cfg.Pricing))
+	for model, price := range cfg.Pricing {
+		table[model] = trace.ModelPrice{InputPer1K: price.Input, OutputPer1K: price.Output}
+	}
+	return table
+}
+
+// sessionMetadata records the run-level settings that influence captured traffic.
+func sessionMetadata(cfg *config.RegradaConfig) map[string]string {
+	metadata := make(map[string]string)
+
+	if cfg.Provider.SystemPromptFile != "" {
+		metadata["system_prompt_file"] = cfg.Provider.SystemPromptFile
+		// Hash the resolved prompt so edits to any included partial change it, and each
+		// file so a change can be attributed to the file that was edited
+		if prompt, files, err := config.LoadPrompt(cfg.Provider.SystemPromptFile); err == nil {
+			metadata["system_prompt_sha256"] = eval.PromptFingerprint(prompt)
+			for _, file := range files {
+				if data, err := os.ReadFile(file); err == nil {
+					sum := sha256.Sum256(data)
+					metadata[eval.PromptFileMetadataPrefix+file] = hex.EncodeToString(sum[:])
+				}
+			}
+		}
+	}
+
+	if cfg.Provider.ForceModel != "" {
+		metadata["force_model"] = cfg.Provider.ForceModel
+	}
+
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// submitToBackend uploads or queues a payload and reports whether it was left in the outbox.
+func submitToBackend(cfg *config.RegradaConfig, kind, id string, payload interface{}) (bool, error) {
+	if cfg.Backend.URL == "" {
+		return false, nil
+	}
+	uploaded, err := backend.Submit(cfg.Backend, kind, id, payload)
+	return !uploaded && err == nil, err
+}
+
 func generateTraceID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
@@ -237,7 +372,7 @@ func executeCommand(args []string, env []string) int {
 }
 
 func handleTestGeneration(newSuite *eval.TestSuite, path string, onConflict string) error {
-	existing, err := eval.LoadSuite(path)
+	existing, err := eval.ReadSuite(path)
 	if err != nil {
 		return eval.SaveSuite(newSuite, path)
 	}
@@ -292,11 +427,32 @@ func buildProxyEnv(proxyAddr string, cfg *config.RegradaConfig) []string {
 		env = append(env, "OPENAI_BASE_URL=http://"+proxyAddr)
 	case "anthropic":
 		env = append(env, "ANTHROPIC_BASE_URL=http://"+proxyAddr)
-	case "azure-openai":
-		if cfg.Provider.BaseURL != "" {
-			env = append(env, "AZURE_OPENAI_ENDPOINT=http://"+proxyAddr)
-		}
-	case "custom":
+	case "gemini":
+		env = append(env, "GOOGLE_GEMINI_BASE_URL=http://"+proxyAddr)
+	case "azure", "azure-openai":
+		// Both Azure SDKs and plain OpenAI clients work; the proxy maps paths onto the deployment
+		env = append(env, "AZURE_OPENAI_ENDPOINT=http://"+proxyAddr)
+		env = append(env, "OPENAI_BASE_URL=http://"+proxyAddr)
+	case "openai-compatible":
+		env = append(env, "OPENAI_BASE_URL=http://"+proxyAddr+"/v1")
+		env = append(env, "OPENAI_API_BASE=http://"+proxyAddr+"/v1")
+	case "openrouter":
+		env = append(env, "OPENAI_BASE_URL=http://"+proxyAddr+"/api/v1")
+		env = append(env, "OPENROUTER_BASE_URL=http://"+proxyAddr+"/api/v1")
+	case "ollama":
+		env = append(env, "OLLAMA_HOST=http://"+proxyAddr)
+	case "bedrock":
+		env = append(env, "AWS_ENDPOINT_URL_BEDROCK_RUNTIME=http://"+proxyAddr)
+	case "replay":
+		// Recordings may come from any provider, so every SDK is pointed at the proxy
+		env = append(env, "OPENAI_BASE_URL=http://"+proxyAddr)
+		env = append(env, "ANTHROPIC_BASE_URL=http://"+proxyAddr)
+		env = append(env, "GOOGLE_GEMINI_BASE_URL=http://"+proxyAddr)
+		env = append(env, "AZURE_OPENAI_ENDPOINT=http://"+proxyAddr)
+		env = append(env, "OLLAMA_HOST=http://"+proxyAddr)
+		env = append(env, "BASE_URL=http://"+proxyAddr)
+		env = append(env, "API_BASE_URL=http://"+proxyAddr)
+	case "custom", "external", "exec":
 		env = append(env, "BASE_URL=http://"+proxyAddr)
 		env = append(env, "API_BASE_URL=http://"+proxyAddr)
 		env = append(env, "OLLAMA_HOST=http://"+proxyAddr)
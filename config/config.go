@@ -3,8 +3,13 @@
 package config
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,10 +17,44 @@ import (
 // RegradaConfig represents the complete configuration for a Regrada project.
 // It is persisted as .regrada.yaml in the project root.
 type RegradaConfig struct {
-	Version  string         `yaml:"version"`
-	Project  string         `yaml:"project"`
-	Env      string         `yaml:"env,omitempty"`
-	Provider ProviderConfig `yaml:"provider"`
+	Version   string         `yaml:"version"`
+	Project   string         `yaml:"project"`
+	Env       string         `yaml:"env,omitempty"`
+	Provider  ProviderConfig `yaml:"provider"`
+	Backend   BackendConfig  `yaml:"backend,omitempty"`
+	Storage   StorageConfig  `yaml:"storage,omitempty"`
+	Cassettes CassetteConfig `yaml:"cassettes,omitempty"`
+	Cache     CacheConfig    `yaml:"cache,omitempty"`
+	SLO       SLOConfig      `yaml:"slo,omitempty"`
+	Quality   QualityConfig  `yaml:"quality,omitempty"`
+	Policies  PoliciesConfig `yaml:"policies,omitempty"`
+
+	// Embeddings selects the embeddings endpoint used by similar_to checks.
+	Embeddings EmbeddingsConfig `yaml:"embeddings,omitempty"`
+
+	// Judge selects the model that grades rubric checks.
+	Judge JudgeConfig `yaml:"judge,omitempty"`
+
+	Sustainability SustainabilityConfig `yaml:"sustainability,omitempty"`
+
+	// Baseline selects what runs are compared against: the baseline file, or a rolling
+	// aggregate of the latest runs.
+	Baseline BaselineConfig `yaml:"baseline,omitempty"`
+
+	// History controls the run history kept in .regrada/history.
+	History HistoryConfig `yaml:"history,omitempty"`
+
+	// Matrix lists the provider/model combinations `regrada matrix` traces and evaluates.
+	Matrix []MatrixEntry `yaml:"matrix,omitempty"`
+
+	// Pricing maps model name prefixes to prices for cost estimates; the longest prefix wins.
+	Pricing map[string]ModelPricing `yaml:"pricing,omitempty"`
+
+	// CI controls pull request comments on GitHub Actions.
+	CI CIConfig `yaml:"ci,omitempty"`
+
+	// Notifications sends a summary to Slack and webhooks when a CI run regresses.
+	Notifications NotificationsConfig `yaml:"notifications,omitempty"`
 
 	// Deprecated fields (kept for backward compatibility)
 	Capture CaptureConfig `yaml:"capture,omitempty"`
@@ -25,11 +64,387 @@ type RegradaConfig struct {
 }
 
 // ProviderConfig defines the LLM provider settings for evaluations.
-// Supported providers: openai, anthropic, azure-openai, custom.
+// Supported providers: openai, anthropic, gemini, azure-openai, bedrock, openrouter, openai-compatible,
+// ollama, custom, external, exec, replay.
 type ProviderConfig struct {
 	Type    string `yaml:"type"`
 	BaseURL string `yaml:"base_url,omitempty"`
 	Model   string `yaml:"model,omitempty"`
+	Region  string `yaml:"region,omitempty"` // AWS region for bedrock (default: $AWS_REGION)
+
+	// Headers are added to every request forwarded to the provider, e.g. for
+	// cost attribution. Values may reference environment variables ($VAR).
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// External configures a subprocess provider (type: external).
+	External *ExternalProviderConfig `yaml:"external,omitempty"`
+
+	// Exec configures a command run once per request (type: exec). It takes the
+	// same fields as External.
+	Exec *ExternalProviderConfig `yaml:"exec,omitempty"`
+
+	// Azure addresses an Azure OpenAI deployment (type: azure-openai).
+	Azure *AzureProviderConfig `yaml:"azure,omitempty"`
+
+	// OpenRouter sets attribution headers and model routing (type: openrouter).
+	OpenRouter *OpenRouterConfig `yaml:"openrouter,omitempty"`
+
+	// OpenAICompatible configures auth for self-hosted and third-party gateways that
+	// speak the OpenAI API (type: openai-compatible).
+	OpenAICompatible *OpenAICompatibleConfig `yaml:"openai_compatible,omitempty"`
+
+	// AllowHosts lists extra hosts accepted when the application sends traffic through
+	// HTTP_PROXY/HTTPS_PROXY. The provider's own host is always allowed; "*.example.com"
+	// matches subdomains. Other hosts are refused.
+	AllowHosts []string `yaml:"allow_hosts,omitempty"`
+
+	// PromptBudget estimates prompt size before requests are forwarded.
+	PromptBudget *PromptBudgetConfig `yaml:"prompt_budget,omitempty"`
+
+	// Signing configures HMAC request signing for gateways that require it.
+	Signing *SigningConfig `yaml:"signing,omitempty"`
+
+	// Retry retries transient provider failures (rate limits, overloads) before the
+	// application sees them.
+	Retry *RetryConfig `yaml:"retry,omitempty"`
+
+	// SystemPromptFile, when set, replaces the system prompt of every proxied
+	// request with this template, after expanding its {{> file}} includes.
+	SystemPromptFile string `yaml:"system_prompt_file,omitempty"`
+
+	// ForceModel, when set, replaces the model of every proxied request whose JSON body
+	// names one in a "model" field (used by matrix runs).
+	ForceModel string `yaml:"force_model,omitempty"`
+}
+
+// ExternalProviderConfig runs a subprocess that serves requests over the
+// line-delimited JSON protocol documented in proxy/external.go.
+type ExternalProviderConfig struct {
+	Command []string          `yaml:"command"`
+	Env     map[string]string `yaml:"env,omitempty"`     // Extra environment variables; values may reference $VAR
+	Timeout string            `yaml:"timeout,omitempty"` // Per-request timeout, e.g. "60s" (default: 120s)
+}
+
+// AzureProviderConfig maps OpenAI-style requests onto an Azure OpenAI deployment:
+// /v1/chat/completions becomes /openai/deployments/<deployment>/chat/completions?api-version=<version>.
+type AzureProviderConfig struct {
+	Deployment string `yaml:"deployment,omitempty"`  // Default: provider.model
+	APIVersion string `yaml:"api_version,omitempty"` // Default: 2024-10-21
+	APIKeyEnv  string `yaml:"api_key_env,omitempty"` // Sent as the api-key header (default: AZURE_OPENAI_API_KEY)
+}
+
+// OpenRouterConfig controls requests sent to OpenRouter's OpenAI-compatible API.
+type OpenRouterConfig struct {
+	SiteURL       string   `yaml:"site_url,omitempty"`       // Sent as HTTP-Referer
+	AppName       string   `yaml:"app_name,omitempty"`       // Sent as X-Title
+	APIKeyEnv     string   `yaml:"api_key_env,omitempty"`    // Default: OPENROUTER_API_KEY
+	Fallbacks     []string `yaml:"fallbacks,omitempty"`      // Added as "models" when the request sets none
+	ProviderOrder []string `yaml:"provider_order,omitempty"` // Added as "provider.order" when the request sets none
+}
+
+// OpenAICompatibleConfig sets the auth header sent to an OpenAI-compatible endpoint
+// (vLLM, LM Studio, Together, Groq, ...). base_url must include the API prefix, e.g. /v1.
+type OpenAICompatibleConfig struct {
+	AuthHeader   string `yaml:"auth_header,omitempty"`   // Default: Authorization
+	AuthTemplate string `yaml:"auth_template,omitempty"` // e.g. "Bearer $GROQ_API_KEY"; empty forwards client auth
+}
+
+// PromptBudgetConfig flags requests whose estimated prompt exceeds a token budget
+// or the target model's context window.
+type PromptBudgetConfig struct {
+	MaxTokens      int            `yaml:"max_tokens,omitempty"`      // Configured budget; 0 checks only the context window
+	Action         string         `yaml:"action,omitempty"`          // Options: warn (default), block
+	ContextWindows map[string]int `yaml:"context_windows,omitempty"` // Per-model overrides of known context sizes
+}
+
+// SigningConfig describes how forwarded requests are HMAC-signed.
+// Template placeholders: {method}, {path}, {query}, {timestamp}, {body}.
+type SigningConfig struct {
+	Header          string `yaml:"header"`                     // Header carrying the signature, e.g. X-Signature
+	SecretEnv       string `yaml:"secret_env"`                 // Environment variable holding the HMAC secret
+	Template        string `yaml:"template,omitempty"`         // Default: "{method}\n{path}\n{timestamp}\n{body}"
+	TimestampHeader string `yaml:"timestamp_header,omitempty"` // Header carrying the Unix timestamp, if any
+	Algorithm       string `yaml:"algorithm,omitempty"`        // Options: sha256 (default), sha512
+	Encoding        string `yaml:"encoding,omitempty"`         // Options: hex (default), base64
+	Prefix          string `yaml:"prefix,omitempty"`           // Prepended to the signature, e.g. "sha256="
+}
+
+// SLOConfig declares service level objectives reported on every run.
+type SLOConfig struct {
+	Latency []LatencySLO `yaml:"latency,omitempty"`
+}
+
+// LatencySLO requires that at least Target (0-1) of calls complete within Threshold.
+type LatencySLO struct {
+	Name      string  `yaml:"name,omitempty"`
+	Metric    string  `yaml:"metric,omitempty"` // latency (default) or ttft (streamed calls only)
+	Threshold string  `yaml:"threshold"`        // e.g. "3s" or "800ms"
+	Target    float64 `yaml:"target"`
+}
+
+// EmbeddingsConfig selects the embeddings endpoint used by similar_to checks.
+type EmbeddingsConfig struct {
+	Provider  string `yaml:"provider,omitempty"`    // openai (default; any OpenAI-compatible /embeddings endpoint) or ollama
+	Model     string `yaml:"model,omitempty"`       // Default: text-embedding-3-small (openai), nomic-embed-text (ollama)
+	BaseURL   string `yaml:"base_url,omitempty"`    // Default: https://api.openai.com/v1 (openai), http://localhost:11434 (ollama)
+	APIKeyEnv string `yaml:"api_key_env,omitempty"` // Default: OPENAI_API_KEY
+
+	// RateLimit paces embeddings requests made while evaluating.
+	RateLimit *RateLimitConfig `yaml:"rate_limit,omitempty"`
+}
+
+// JudgeConfig selects the model that grades rubric checks.
+type JudgeConfig struct {
+	Provider  string `yaml:"provider,omitempty"`    // openai (default; any OpenAI-compatible endpoint) or anthropic
+	Model     string `yaml:"model,omitempty"`       // Default: gpt-4o-mini (openai), claude-3-5-haiku-latest (anthropic)
+	BaseURL   string `yaml:"base_url,omitempty"`    // Default: https://api.openai.com/v1 (openai), https://api.anthropic.com (anthropic)
+	APIKeyEnv string `yaml:"api_key_env,omitempty"` // Default: OPENAI_API_KEY or ANTHROPIC_API_KEY
+
+	// RateLimit paces judge requests made while evaluating.
+	RateLimit *RateLimitConfig `yaml:"rate_limit,omitempty"`
+}
+
+// BaselineConfig controls the baseline runs are compared against. In snapshot mode
+// (default) it is the baseline file; in rolling mode it aggregates the last Runs saved
+// results, so one lucky or unlucky run doesn't decide what counts as a regression; in
+// remote mode it is kept in an S3 or GCS bucket shared by every checkout and CI runner,
+// and in backend mode it is the project's baseline in the Regrada backend.
+type BaselineConfig struct {
+	Mode   string       `yaml:"mode,omitempty"`   // snapshot, rolling, remote, or backend
+	Runs   int          `yaml:"runs,omitempty"`   // Results aggregated in rolling mode (default 5)
+	Remote RemoteConfig `yaml:"remote,omitempty"` // Bucket used in remote mode
+}
+
+// HistoryConfig controls the run history. Every run's summary is kept; full results
+// are kept for the last Keep runs.
+type HistoryConfig struct {
+	Keep  int `yaml:"keep,omitempty"`  // Default 20
+	Trend int `yaml:"trend,omitempty"` // Runs shown in the report's trend lines (default 10)
+}
+
+// DefaultHistoryKeep is the number of runs whose full results are kept by default.
+const DefaultHistoryKeep = 20
+
+// DefaultHistoryTrend is the number of runs the report's trend lines cover by default.
+const DefaultHistoryTrend = 10
+
+// KeepRuns returns the number of runs whose full results are kept.
+func (h HistoryConfig) KeepRuns() int {
+	if h.Keep > 0 {
+		return h.Keep
+	}
+	return DefaultHistoryKeep
+}
+
+// TrendRuns returns the number of runs the report's trend lines cover.
+func (h HistoryConfig) TrendRuns() int {
+	if h.Trend > 0 {
+		return h.Trend
+	}
+	return DefaultHistoryTrend
+}
+
+// RemoteConfig locates a bucket baselines are stored in. S3 credentials come from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN; GCS takes an OAuth
+// access token (e.g. from gcloud auth print-access-token) from TokenEnv.
+type RemoteConfig struct {
+	Type     string `yaml:"type"` // s3 or gcs
+	Bucket   string `yaml:"bucket"`
+	Prefix   string `yaml:"prefix,omitempty"`    // Key prefix, e.g. the project's path in a monorepo
+	Region   string `yaml:"region,omitempty"`    // S3 region (default: AWS_REGION, then us-east-1)
+	Endpoint string `yaml:"endpoint,omitempty"`  // S3-compatible endpoint, e.g. MinIO or Cloudflare R2
+	TokenEnv string `yaml:"token_env,omitempty"` // GCS token variable (default: GOOGLE_OAUTH_ACCESS_TOKEN)
+}
+
+// DefaultRollingRuns is the number of results a rolling baseline aggregates by default.
+const DefaultRollingRuns = 5
+
+// RollingRuns returns the number of results a rolling baseline aggregates.
+func (b BaselineConfig) RollingRuns() int {
+	if b.Runs > 0 {
+		return b.Runs
+	}
+	return DefaultRollingRuns
+}
+
+// MatrixEntry is one combination in a matrix run. Provider and BaseURL default to the
+// project's provider settings; Model replaces the model of every request.
+type MatrixEntry struct {
+	Name     string `yaml:"name,omitempty"` // Label in reports and baseline file names (default: Model)
+	Model    string `yaml:"model"`
+	Provider string `yaml:"provider,omitempty"`
+	BaseURL  string `yaml:"base_url,omitempty"`
+}
+
+// Label returns the entry's name, or its model when unnamed.
+func (m MatrixEntry) Label() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return m.Model
+}
+
+// RateLimitConfig caps calls to a provider over any one-minute window. Tokens are
+// estimated from the request text; zero leaves a limit off.
+type RateLimitConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute,omitempty"`
+	TokensPerMinute   int `yaml:"tokens_per_minute,omitempty"`
+}
+
+// RetryConfig controls retries of failed provider calls with exponential backoff.
+type RetryConfig struct {
+	MaxAttempts int    `yaml:"max_attempts"`          // Total attempts including the first; 1 or less disables retries
+	Backoff     string `yaml:"backoff,omitempty"`     // Delay before the first retry, doubled each time (default: 500ms)
+	MaxBackoff  string `yaml:"max_backoff,omitempty"` // Cap on any single delay, including Retry-After (default: 30s)
+	RetryOn     []int  `yaml:"retry_on,omitempty"`    // Status codes to retry (default: 429, 500, 502, 503, 504)
+}
+
+// PoliciesConfig holds run-wide policies applied to every test on top of its checks.
+type PoliciesConfig struct {
+	Tokens        TokensPolicy        `yaml:"tokens,omitempty"`
+	Latency       LatencyPolicy       `yaml:"latency,omitempty"`
+	ContentFilter RatePolicy          `yaml:"content_filter,omitempty"`
+	Truncation    RatePolicy          `yaml:"truncation,omitempty"`
+	SemanticDrift SemanticDriftPolicy `yaml:"semantic_drift,omitempty"`
+	Score         ScorePolicy         `yaml:"score,omitempty"`
+	Pairwise      PairwisePolicy      `yaml:"pairwise,omitempty"`
+	Sampling      []SamplingPolicy    `yaml:"sampling,omitempty"`
+	PromptDrift   PromptDriftPolicy   `yaml:"prompt_drift,omitempty"`
+
+	BaselineStaleness BaselineStalenessPolicy `yaml:"baseline_staleness,omitempty"`
+}
+
+// TokensPolicy fails tests whose responses grow too long, to catch verbosity regressions.
+type TokensPolicy struct {
+	Max      int     `yaml:"max,omitempty"`       // Maximum output tokens per call
+	MaxDelta float64 `yaml:"max_delta,omitempty"` // Maximum growth in output tokens over the baseline, e.g. 0.25 for +25%
+}
+
+// LatencyPolicy fails tests whose latency grew too much over the baseline. With enough
+// runs on both sides (run --runs), growth only counts when it is statistically significant.
+type LatencyPolicy struct {
+	MaxDelta float64 `yaml:"max_delta,omitempty"` // Maximum growth over the baseline, e.g. 0.2 for +20%
+}
+
+// RatePolicy limits the share of gated tests whose response hit a condition, such as
+// being blocked by the provider's content filter. Rates are 0-1.
+type RatePolicy struct {
+	Max      float64 `yaml:"max,omitempty"`       // Maximum rate
+	MaxDelta float64 `yaml:"max_delta,omitempty"` // Maximum increase over the baseline's rate, e.g. 0.05 for 5 points
+}
+
+// SemanticDriftPolicy fails tests whose output means something different from the
+// baseline output, measured by the cosine similarity of their embeddings.
+type SemanticDriftPolicy struct {
+	MinSimilarity float64 `yaml:"min_similarity,omitempty"` // e.g. 0.85; 0 disables the policy
+}
+
+// ScorePolicy gates rubric-graded tests on their normalized score (0-1).
+type ScorePolicy struct {
+	Min      float64 `yaml:"min,omitempty"`       // Fail tests scoring below this
+	MaxDelta float64 `yaml:"max_delta,omitempty"` // Fail tests whose score dropped more than this from the baseline
+}
+
+// PairwisePolicy has the judge compare each test's output with the baseline output and
+// pick the better one. Gate on the resulting loss rate with gate.max_loss_rate.
+type PairwisePolicy struct {
+	Enabled  bool   `yaml:"enabled"`
+	Criteria string `yaml:"criteria,omitempty"` // What makes an answer better; default: helpfulness, correctness, and clarity
+}
+
+// PromptDriftPolicy fails tests whose captured system prompt is not an approved version.
+// A prompt's fingerprint is the SHA-256 of its text; approved fingerprints may be
+// abbreviated, and each approved file contributes the fingerprint of its resolved prompt.
+type PromptDriftPolicy struct {
+	Approved []string `yaml:"approved,omitempty"` // Approved prompt fingerprints
+	Files    []string `yaml:"files,omitempty"`    // Prompt templates whose current version is approved
+}
+
+// BaselineStalenessPolicy flags a baseline that is older than MaxAge or was recorded
+// with a retired model. A stale baseline is a warning, or fails the run with Fail.
+type BaselineStalenessPolicy struct {
+	MaxAge        string   `yaml:"max_age,omitempty"`        // e.g. 720h (30 days)
+	RetiredModels []string `yaml:"retired_models,omitempty"` // Model names or name prefixes
+	Fail          bool     `yaml:"fail,omitempty"`
+}
+
+// SamplingPolicy asserts on an aggregate of tests evaluated across several runs (run --runs).
+// Variance metrics (pass_rate_cv, latency_cv, latency_stddev, tokens_out_cv,
+// tokens_out_stddev) are gated with Max instead.
+type SamplingPolicy struct {
+	Metric string  `yaml:"metric"`        // pass@K (e.g. pass@3), majority, or a variance metric
+	Min    float64 `yaml:"min"`           // Fail tests whose metric is below this (0-1); majority counts as 1 or 0
+	Max    float64 `yaml:"max,omitempty"` // Fail tests whose variance metric is above this; stddevs in ms or tokens
+}
+
+// VarianceMetrics are the sampling policy metrics that measure spread across runs.
+var VarianceMetrics = []string{"pass_rate_cv", "latency_cv", "latency_stddev", "tokens_out_cv", "tokens_out_stddev"}
+
+// IsVarianceMetric reports whether metric is one of VarianceMetrics.
+func IsVarianceMetric(metric string) bool {
+	for _, m := range VarianceMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// QualityConfig weights the per-run quality score (0-100). Failures cost their severity
+// weight in the weighted pass rate; penalties are subtracted in score points.
+type QualityConfig struct {
+	SeverityWeights   map[string]float64 `yaml:"severity_weights,omitempty"`   // Default: low 1, medium 2, high 4, critical 8
+	RegressionPenalty *float64           `yaml:"regression_penalty,omitempty"` // Per regression (default: 5)
+	LatencyPenalty    *float64           `yaml:"latency_penalty,omitempty"`    // At 2x baseline mean latency (default: 10)
+	CostPenalty       *float64           `yaml:"cost_penalty,omitempty"`       // At 2x baseline total cost (default: 10)
+}
+
+// SustainabilityConfig enables energy and carbon estimates from token counts.
+// The defaults are rough industry averages; set coefficients for your models and region.
+type SustainabilityConfig struct {
+	Enabled        bool               `yaml:"enabled"`
+	WhPer1KTokens  float64            `yaml:"wh_per_1k_tokens,omitempty"`  // Default: 0.3
+	Models         map[string]float64 `yaml:"models,omitempty"`            // Wh per 1K tokens by model name prefix
+	GramsCO2PerKWh float64            `yaml:"grams_co2_per_kwh,omitempty"` // Default: 400
+}
+
+// ModelPricing is a model's price in USD per 1,000 tokens.
+type ModelPricing struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// BackendConfig controls uploads of traces and results to the Regrada backend.
+// When URL is set, uploads are attempted at record time if Enabled is true;
+// otherwise (or on failure) they are queued in .regrada/outbox for `regrada sync`.
+type BackendConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	URL       string `yaml:"url,omitempty"`
+	APIKeyEnv string `yaml:"api_key_env,omitempty"`
+}
+
+// StorageConfig controls how captured traces are written to disk.
+type StorageConfig struct {
+	Compression string `yaml:"compression,omitempty"` // Options: none, gzip
+
+	// StripThinking removes extended thinking blocks from stored response bodies.
+	// Thinking length and usage are still recorded on the trace.
+	StripThinking bool `yaml:"strip_thinking,omitempty"`
+}
+
+// CassetteConfig makes the proxy record provider responses to disk and serve them back,
+// so application integration tests are deterministic and need no provider access.
+type CassetteConfig struct {
+	Mode string `yaml:"mode,omitempty"` // Options: off (default), record, replay, auto
+	Dir  string `yaml:"dir,omitempty"`  // Default: .regrada/cassettes
+}
+
+// CacheConfig makes the proxy reuse successful responses to identical requests, so
+// repeated traces during development don't call the provider again.
+type CacheConfig struct {
+	Enabled bool   `yaml:"enabled,omitempty"`
+	TTL     string `yaml:"ttl,omitempty"` // e.g. 24h; default: entries never expire
+	Dir     string `yaml:"dir,omitempty"` // Default: .regrada/cache
 }
 
 // CaptureConfig controls what data is captured during LLM tracing (DEPRECATED).
@@ -45,33 +460,119 @@ type EvalsConfig struct {
 	Path       string   `yaml:"path"`
 	Types      []string `yaml:"types,omitempty"`
 	Timeout    string   `yaml:"timeout,omitempty"`
-	Concurrent int      `yaml:"concurrent,omitempty"`
+	Concurrent int      `yaml:"concurrent,omitempty"` // Tests evaluated at once (default 1)
 }
 
 // GateConfig defines quality gate thresholds for CI/CD integration.
 type GateConfig struct {
 	Enabled   bool    `yaml:"enabled"`
 	Threshold float64 `yaml:"threshold,omitempty"`
-	FailOn    string  `yaml:"fail_on,omitempty"` // Options: any-failure, regression, threshold
+	FailOn    string  `yaml:"fail_on,omitempty"`   // Options: any-failure, regression, threshold
+	MinScore  float64 `yaml:"min_score,omitempty"` // Fail when the quality score is below this (0-100)
+
+	// MaxLossRate fails the gate when more than this share (0-1) of pairwise comparisons
+	// against the baseline are losses. Requires policies.pairwise.
+	MaxLossRate float64 `yaml:"max_loss_rate,omitempty"`
+}
+
+// CIConfig controls what runs do on CI besides exiting with the gate's verdict.
+type CIConfig struct {
+	// CommentOnPR keeps a single comment with the markdown report on the pull request
+	// a GitHub Actions run belongs to, using GITHUB_TOKEN.
+	CommentOnPR bool `yaml:"comment_on_pr"`
+}
+
+// NotificationsConfig lists where CI runs report regressions and failures of severe
+// tests. URLs may reference environment variables as $VAR, to keep them out of the file.
+type NotificationsConfig struct {
+	Slack    string   `yaml:"slack,omitempty"`    // Slack incoming webhook URL
+	Webhooks []string `yaml:"webhooks,omitempty"` // URLs that receive the summary as JSON
+	Severity string   `yaml:"severity,omitempty"` // Also notify on failures of tests at least this severe (default high)
+}
+
+// DefaultNotifySeverity is the lowest test severity whose failures are notified by default.
+const DefaultNotifySeverity = "high"
+
+// Enabled reports whether any destination is configured.
+func (n NotificationsConfig) Enabled() bool {
+	return n.Slack != "" || len(n.Webhooks) > 0
+}
+
+// MinSeverity returns the lowest test severity whose failures are notified.
+func (n NotificationsConfig) MinSeverity() string {
+	if n.Severity != "" {
+		return n.Severity
+	}
+	return DefaultNotifySeverity
 }
 
 // OutputConfig controls the format and verbosity of command output.
 type OutputConfig struct {
 	Format  string `yaml:"format,omitempty"` // Options: text, json, github
 	Verbose bool   `yaml:"verbose,omitempty"`
+
+	// Sections group the report by product area using test tags.
+	Sections []ReportSection `yaml:"sections,omitempty"`
+}
+
+// ReportSection is a titled report section holding the tests tagged with any of Tags.
+type ReportSection struct {
+	Title string   `yaml:"title"`
+	Tags  []string `yaml:"tags"`
 }
 
-// Load reads and parses a Regrada configuration file.
+// DefaultPath is the project config file read when no --config is given.
+const DefaultPath = ".regrada.yaml"
+
+// legacyPaths are config file names used by earlier releases. They are still read
+// in place of DefaultPath, with a deprecation notice.
+var legacyPaths = []string{"regrada.yml", "regrada.yaml"}
+
+// LegacyPath returns the legacy config file in the working directory, or "" if there
+// is none.
+func LegacyPath() string {
+	for _, legacy := range legacyPaths {
+		if info, err := os.Stat(legacy); err == nil && !info.IsDir() {
+			return legacy
+		}
+	}
+	return ""
+}
+
+// Load reads and parses a Regrada configuration file. When path is DefaultPath and
+// the file does not exist, a legacy config file is used instead if one is present.
+// Configs written for an earlier schema are upgraded in memory, with a notice.
 func Load(path string) (*RegradaConfig, error) {
 	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && path == DefaultPath {
+		if legacy := LegacyPath(); legacy != "" {
+			if legacyData, legacyErr := os.ReadFile(legacy); legacyErr == nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s is deprecated, rename it to %s (regrada init does this)\n", legacy, DefaultPath)
+				data, err, path = legacyData, nil, legacy
+			}
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	var config RegradaConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, err
 	}
+	if notes := upgradeLegacy(&doc); len(notes) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %s uses a deprecated config schema, run regrada init to upgrade it:\n", path)
+		for _, note := range notes {
+			fmt.Fprintf(os.Stderr, "  - %s\n", note)
+		}
+	}
+
+	var config RegradaConfig
+	if doc.Kind != 0 {
+		if err := doc.Decode(&config); err != nil {
+			return nil, err
+		}
+	}
 
 	return &config, nil
 }
@@ -107,6 +608,9 @@ func Defaults(projectName string) *RegradaConfig {
 			Format:  "github",
 			Verbose: false,
 		},
+		CI: CIConfig{
+			CommentOnPR: true,
+		},
 	}
 }
 
@@ -124,13 +628,57 @@ func Validate(cfg *RegradaConfig) error {
 
 	// Validate provider type
 	validProviders := map[string]bool{
-		"openai":       true,
-		"anthropic":    true,
-		"azure-openai": true,
-		"custom":       true,
+		"openai":            true,
+		"anthropic":         true,
+		"gemini":            true,
+		"azure-openai":      true,
+		"bedrock":           true,
+		"openrouter":        true,
+		"openai-compatible": true,
+		"ollama":            true,
+		"custom":            true,
+		"external":          true,
 	}
 	if !validProviders[cfg.Provider.Type] {
-		return fmt.Errorf("invalid provider type: %s (must be one of: openai, anthropic, azure-openai, custom)", cfg.Provider.Type)
+		return fmt.Errorf("invalid provider type: %s (must be one of: openai, anthropic, gemini, azure-openai, bedrock, openrouter, openai-compatible, ollama, custom, external, exec, replay)", cfg.Provider.Type)
+	}
+	if cfg.Provider.Type == "external" && (cfg.Provider.External == nil || len(cfg.Provider.External.Command) == 0) {
+		return fmt.Errorf("external provider requires provider.external.command")
+	}
+	if cfg.Provider.Type == "exec" && (cfg.Provider.Exec == nil || len(cfg.Provider.Exec.Command) == 0) {
+		return fmt.Errorf("exec provider requires provider.exec.command")
+	}
+
+	for _, slo := range cfg.SLO.Latency {
+		if _, err := time.ParseDuration(slo.Threshold); err != nil {
+			return fmt.Errorf("invalid slo.latency threshold %q: %w", slo.Threshold, err)
+		}
+		if slo.Target <= 0 || slo.Target > 1 {
+			return fmt.Errorf("slo.latency target must be between 0 and 1, got %.2f", slo.Target)
+		}
+		if slo.Metric != "" && slo.Metric != "latency" && slo.Metric != "ttft" {
+			return fmt.Errorf("invalid slo.latency metric: %s (must be latency or ttft)", slo.Metric)
+		}
+	}
+
+	if cfg.Provider.Type == "openai-compatible" && cfg.Provider.BaseURL == "" {
+		return fmt.Errorf("openai-compatible provider requires base_url (e.g. http://localhost:8000/v1)")
+	}
+
+	if cfg.Provider.Type == "azure-openai" && cfg.Provider.BaseURL == "" {
+		return fmt.Errorf("azure-openai provider requires base_url (https://<resource>.openai.azure.com)")
+	}
+
+	if b := cfg.Provider.PromptBudget; b != nil && b.Action != "" && b.Action != "warn" && b.Action != "block" {
+		return fmt.Errorf("invalid provider.prompt_budget.action: %s (must be warn or block)", b.Action)
+	}
+
+	if s := cfg.Provider.Signing; s != nil && (s.Header == "" || s.SecretEnv == "") {
+		return fmt.Errorf("provider.signing requires header and secret_env")
+	}
+
+	if cfg.Gate.MinScore < 0 || cfg.Gate.MinScore > 100 {
+		return fmt.Errorf("gate.min_score must be between 0 and 100, got %.1f", cfg.Gate.MinScore)
 	}
 
 	// Validate gate fail_on option
@@ -145,6 +693,159 @@ func Validate(cfg *RegradaConfig) error {
 		}
 	}
 
+	if r := cfg.Provider.Retry; r != nil {
+		for name, value := range map[string]string{"backoff": r.Backoff, "max_backoff": r.MaxBackoff} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+				return fmt.Errorf("invalid provider.retry.%s: %q (expected a positive duration like 500ms)", name, value)
+			}
+		}
+	}
+
+	if cfg.Evals.Concurrent < 0 {
+		return fmt.Errorf("evals.concurrent must not be negative, got %d", cfg.Evals.Concurrent)
+	}
+
+	if cfg.Gate.MaxLossRate < 0 || cfg.Gate.MaxLossRate > 1 {
+		return fmt.Errorf("gate.max_loss_rate must be between 0 and 1, got %.2f", cfg.Gate.MaxLossRate)
+	}
+
+	if ms := cfg.Policies.SemanticDrift.MinSimilarity; ms < 0 || ms > 1 {
+		return fmt.Errorf("policies.semantic_drift.min_similarity must be between 0 and 1, got %.2f", ms)
+	}
+
+	if sp := cfg.Policies.Score; sp.Min < 0 || sp.Min > 1 || sp.MaxDelta < 0 || sp.MaxDelta > 1 {
+		return fmt.Errorf("policies.score min and max_delta must be between 0 and 1")
+	}
+
+	for _, sp := range cfg.Policies.Sampling {
+		if IsVarianceMetric(sp.Metric) {
+			if sp.Max <= 0 {
+				return fmt.Errorf("policies.sampling %s needs a positive max", sp.Metric)
+			}
+			continue
+		}
+		var k int
+		if _, err := fmt.Sscanf(sp.Metric, "pass@%d", &k); (err != nil || k < 1 || sp.Metric != fmt.Sprintf("pass@%d", k)) && sp.Metric != "majority" {
+			return fmt.Errorf("invalid policies.sampling metric: %q (must be pass@K, majority, or one of %s)", sp.Metric, strings.Join(VarianceMetrics, ", "))
+		}
+		if sp.Min < 0 || sp.Min > 1 {
+			return fmt.Errorf("policies.sampling min for %s must be between 0 and 1, got %.2f", sp.Metric, sp.Min)
+		}
+	}
+
+	switch cfg.Baseline.Mode {
+	case "", "snapshot", "rolling":
+	case "remote":
+		if remote := cfg.Baseline.Remote; remote.Type != "s3" && remote.Type != "gcs" {
+			return fmt.Errorf("invalid baseline.remote.type: %q (must be s3 or gcs)", remote.Type)
+		} else if remote.Bucket == "" {
+			return fmt.Errorf("baseline.remote.bucket is required in remote mode")
+		}
+	case "backend":
+		if cfg.Backend.URL == "" || cfg.Project == "" {
+			return fmt.Errorf("baseline.mode backend requires backend.url and project")
+		}
+	default:
+		return fmt.Errorf("invalid baseline.mode: %s (must be snapshot, rolling, remote, or backend)", cfg.Baseline.Mode)
+	}
+	if cfg.Baseline.Runs < 0 {
+		return fmt.Errorf("baseline.runs must not be negative")
+	}
+	if cfg.History.Keep < 0 {
+		return fmt.Errorf("history.keep must not be negative")
+	}
+	if cfg.History.Trend < 0 {
+		return fmt.Errorf("history.trend must not be negative")
+	}
+	switch cfg.Notifications.Severity {
+	case "", "low", "medium", "high", "critical":
+	default:
+		return fmt.Errorf("invalid notifications.severity: %s (must be low, medium, high, or critical)", cfg.Notifications.Severity)
+	}
+
+	for _, fp := range cfg.Policies.PromptDrift.Approved {
+		if _, err := hex.DecodeString(fp); err != nil || len(fp) < 8 || len(fp) > 64 {
+			return fmt.Errorf("invalid policies.prompt_drift fingerprint: %q (must be 8-64 hex characters of a SHA-256)", fp)
+		}
+	}
+
+	switch cfg.Judge.Provider {
+	case "", "openai", "anthropic":
+	default:
+		return fmt.Errorf("invalid judge.provider: %s (must be openai or anthropic)", cfg.Judge.Provider)
+	}
+
+	labels := make(map[string]bool, len(cfg.Matrix))
+	for i, entry := range cfg.Matrix {
+		if entry.Model == "" {
+			return fmt.Errorf("matrix entry %d: model is required", i+1)
+		}
+		if entry.Provider != "" && !validProviders[entry.Provider] {
+			return fmt.Errorf("matrix entry %d: invalid provider type: %s", i+1, entry.Provider)
+		}
+		if labels[entry.Label()] {
+			return fmt.Errorf("matrix entry %d: duplicate name %q", i+1, entry.Label())
+		}
+		labels[entry.Label()] = true
+	}
+
+	if rl := cfg.Judge.RateLimit; rl != nil && (rl.RequestsPerMinute < 0 || rl.TokensPerMinute < 0) {
+		return fmt.Errorf("judge.rate_limit values must not be negative")
+	}
+	if rl := cfg.Embeddings.RateLimit; rl != nil && (rl.RequestsPerMinute < 0 || rl.TokensPerMinute < 0) {
+		return fmt.Errorf("embeddings.rate_limit values must not be negative")
+	}
+
+	switch cfg.Embeddings.Provider {
+	case "", "openai", "ollama":
+	default:
+		return fmt.Errorf("invalid embeddings.provider: %s (must be openai or ollama)", cfg.Embeddings.Provider)
+	}
+
+	for name, rp := range map[string]RatePolicy{"content_filter": cfg.Policies.ContentFilter, "truncation": cfg.Policies.Truncation} {
+		if rp.Max < 0 || rp.Max > 1 || rp.MaxDelta < 0 || rp.MaxDelta > 1 {
+			return fmt.Errorf("policies.%s max and max_delta must be between 0 and 1", name)
+		}
+	}
+	if cfg.Policies.Latency.MaxDelta < 0 {
+		return fmt.Errorf("policies.latency.max_delta must not be negative")
+	}
+	if cfg.Policies.Tokens.Max < 0 || cfg.Policies.Tokens.MaxDelta < 0 {
+		return fmt.Errorf("policies.tokens max and max_delta must not be negative")
+	}
+
+	for model, price := range cfg.Pricing {
+		if price.Input < 0 || price.Output < 0 {
+			return fmt.Errorf("pricing for %s must not be negative", model)
+		}
+	}
+
+	switch cfg.Cassettes.Mode {
+	case "", "off", "record", "replay", "auto":
+	default:
+		return fmt.Errorf("invalid cassettes.mode: %s (must be one of: off, record, replay, auto)", cfg.Cassettes.Mode)
+	}
+
+	if age := cfg.Policies.BaselineStaleness.MaxAge; age != "" {
+		if d, err := time.ParseDuration(age); err != nil || d <= 0 {
+			return fmt.Errorf("invalid policies.baseline_staleness.max_age: %s (must be a positive duration such as 720h)", age)
+		}
+	}
+
+	if cfg.Cache.TTL != "" {
+		if d, err := time.ParseDuration(cfg.Cache.TTL); err != nil || d <= 0 {
+			return fmt.Errorf("invalid cache.ttl: %s (must be a positive duration such as 24h)", cfg.Cache.TTL)
+		}
+	}
+
+	// Validate storage compression
+	if cfg.Storage.Compression != "" && cfg.Storage.Compression != "none" && cfg.Storage.Compression != "gzip" {
+		fmt.Fprintf(os.Stderr, "Warning: invalid storage.compression value '%s' (valid options: none, gzip)\n", cfg.Storage.Compression)
+	}
+
 	// Validate output format
 	if cfg.Output.Format != "" {
 		validFormats := map[string]bool{
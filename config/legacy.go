@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// legacyKeys maps keys of the schema used by earlier releases to their current names, per section.
+var legacyKeys = map[string][][2]string{
+	"capture": {{"inputs", "requests"}, {"outputs", "responses"}, {"tool_calls", "traces"}, {"metadata", "latency"}},
+	"evals":   {{"parallel", "concurrent"}},
+}
+
+// legacyProviders maps provider types of earlier releases to their current names.
+var legacyProviders = map[string]string{
+	"google": "gemini",
+	"azure":  "azure-openai",
+}
+
+// Upgrade rewrites a config written for an earlier schema to the current one, keeping
+// comments, and returns a note for each change. Notes is empty when data is current.
+func Upgrade(data []byte) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	notes := upgradeLegacy(&doc)
+	if len(notes) == 0 {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), notes, nil
+}
+
+// upgradeLegacy applies the schema changes since earlier releases to doc in place.
+func upgradeLegacy(doc *yaml.Node) []string {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	root := doc.Content[0]
+	var notes []string
+
+	if mappingValue(root, "version") == nil {
+		setValue(root, "version", scalar("!!str", "1"), 0)
+		notes = append(notes, `added version: "1"`)
+	}
+	if mappingValue(root, "project") == nil {
+		cwd, _ := os.Getwd()
+		project := filepath.Base(cwd)
+		setValue(root, "project", scalar("!!str", project), 1)
+		notes = append(notes, fmt.Sprintf("added project: %s", project))
+	}
+
+	for _, section := range []string{"capture", "evals"} {
+		node := mappingValue(root, section)
+		if node == nil || node.Kind != yaml.MappingNode {
+			continue
+		}
+		for _, rename := range legacyKeys[section] {
+			if renameKey(node, rename[0], rename[1]) {
+				notes = append(notes, fmt.Sprintf("renamed %s.%s to %s.%s", section, rename[0], section, rename[1]))
+			}
+		}
+	}
+
+	if provider := mappingValue(root, "provider"); provider != nil && provider.Kind == yaml.MappingNode {
+		if typ := mappingValue(provider, "type"); typ != nil {
+			if current, ok := legacyProviders[typ.Value]; ok {
+				notes = append(notes, fmt.Sprintf("changed provider.type %s to %s", typ.Value, current))
+				typ.Value = current
+			}
+		}
+	}
+
+	if gate := mappingValue(root, "gate"); gate != nil && gate.Kind == yaml.MappingNode {
+		notes = append(notes, upgradeGate(gate)...)
+	}
+
+	return notes
+}
+
+// upgradeGate replaces the max_regressions and min_pass_rate gate of earlier releases
+// with enabled, fail_on, and threshold. Regressions now always fail an enabled gate,
+// so max_regressions is dropped.
+func upgradeGate(gate *yaml.Node) []string {
+	var notes []string
+	maxRegressions := mappingValue(gate, "max_regressions")
+	minPassRate := mappingValue(gate, "min_pass_rate")
+	if maxRegressions == nil && minPassRate == nil {
+		return nil
+	}
+
+	if maxRegressions != nil {
+		removeKey(gate, "max_regressions")
+		if n, err := strconv.Atoi(maxRegressions.Value); err == nil && n > 0 {
+			notes = append(notes, fmt.Sprintf("removed gate.max_regressions: %d (any regression now fails the gate)", n))
+		} else {
+			notes = append(notes, "removed gate.max_regressions (any regression fails the gate)")
+		}
+	}
+	if mappingValue(gate, "enabled") == nil {
+		setValue(gate, "enabled", scalar("!!bool", "true"), 0)
+		notes = append(notes, "added gate.enabled: true")
+	}
+	if minPassRate != nil {
+		removeKey(gate, "min_pass_rate")
+		if mappingValue(gate, "threshold") == nil {
+			setValue(gate, "threshold", minPassRate, len(gate.Content)/2)
+		}
+		if mappingValue(gate, "fail_on") == nil {
+			setValue(gate, "fail_on", scalar("!!str", "threshold"), len(gate.Content)/2)
+		}
+		notes = append(notes, fmt.Sprintf("replaced gate.min_pass_rate with fail_on: threshold and threshold: %s", minPassRate.Value))
+	}
+	return notes
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// renameKey renames key to name in a mapping node unless name is already set.
+func renameKey(node *yaml.Node, key, name string) bool {
+	if mappingValue(node, name) != nil {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i].Value = name
+			return true
+		}
+	}
+	return false
+}
+
+// removeKey deletes key and its value from a mapping node.
+func removeKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// setValue inserts key with value as the pos-th entry of a mapping node.
+func setValue(node *yaml.Node, key string, value *yaml.Node, pos int) {
+	i := min(pos*2, len(node.Content))
+	entry := []*yaml.Node{scalar("!!str", key), value}
+	node.Content = append(node.Content[:i], append(entry, node.Content[i:]...)...)
+}
+
+func scalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
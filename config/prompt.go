@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// includePattern matches a partial include such as {{> partials/tone.md}}.
+var includePattern = regexp.MustCompile(`\{\{>\s*([^}\s]+)\s*\}\}`)
+
+// maxIncludeDepth bounds how deeply prompt partials may nest.
+const maxIncludeDepth = 10
+
+// LoadPrompt reads a prompt template and expands its partial includes. Each
+// {{> path}} is replaced by the contents of that file, resolved relative to the file
+// that includes it, without its trailing newline; partials may include others. It
+// returns the resolved prompt and every file it was built from, template first.
+func LoadPrompt(path string) (string, []string, error) {
+	var files []string
+	prompt, err := expandPrompt(filepath.Clean(path), nil, &files)
+	if err != nil {
+		return "", nil, err
+	}
+	return prompt, files, nil
+}
+
+func expandPrompt(path string, stack []string, files *[]string) (string, error) {
+	for _, p := range stack {
+		if p == path {
+			return "", fmt.Errorf("prompt include cycle: %s -> %s", strings.Join(stack, " -> "), path)
+		}
+	}
+	if len(stack) >= maxIncludeDepth {
+		return "", fmt.Errorf("prompt includes nest deeper than %d levels at %s", maxIncludeDepth, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if len(stack) > 0 {
+			return "", fmt.Errorf("%s: failed to read include: %w", stack[len(stack)-1], err)
+		}
+		return "", err
+	}
+	*files = append(*files, path)
+
+	stack = append(stack, path)
+	var includeErr error
+	prompt := includePattern.ReplaceAllStringFunc(string(data), func(match string) string {
+		if includeErr != nil {
+			return match
+		}
+		ref := includePattern.FindStringSubmatch(match)[1]
+		if !filepath.IsAbs(ref) {
+			ref = filepath.Join(filepath.Dir(path), ref)
+		}
+		partial, err := expandPrompt(filepath.Clean(ref), stack, files)
+		if err != nil {
+			includeErr = err
+			return match
+		}
+		return strings.TrimSuffix(partial, "\n")
+	})
+	if includeErr != nil {
+		return "", includeErr
+	}
+	return prompt, nil
+}
@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// checkHasAttachment verifies the request carried an attachment. The optional filter
+// matches the attachment kind (document, image, file), media type, or filename.
+func checkHasAttachment(tr *trace.LLMTrace, filter string) CheckResult {
+	result := CheckResult{Check: "has_attachment"}
+	if filter != "" {
+		result.Check += ": " + filter
+	}
+
+	for _, att := range tr.Attachments {
+		if filter == "" || strings.EqualFold(att.Kind, filter) ||
+			strings.EqualFold(att.MediaType, filter) || att.Filename == filter {
+			result.Passed = true
+			result.Message = fmt.Sprintf("Request included %s attachment %s", att.Kind, describeAttachment(att))
+			return result
+		}
+	}
+
+	result.Message = fmt.Sprintf("No matching attachment among %d sent", len(tr.Attachments))
+	return result
+}
+
+// checkAttachmentMatches verifies the request carried the exact contents of a local file.
+func checkAttachmentMatches(tr *trace.LLMTrace, path string) CheckResult {
+	result := CheckResult{Check: "attachment_matches: " + path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		result.Message = fmt.Sprintf("Could not read file: %v", err)
+		return result
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	for _, att := range tr.Attachments {
+		if att.SHA256 == digest {
+			result.Passed = true
+			result.Message = fmt.Sprintf("Request included the contents of %s", path)
+			return result
+		}
+	}
+
+	result.Message = fmt.Sprintf("No attachment matches the contents of %s", path)
+	return result
+}
+
+func describeAttachment(att trace.Attachment) string {
+	switch {
+	case att.Filename != "":
+		return att.Filename
+	case att.MediaType != "":
+		return "(" + att.MediaType + ")"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/trace"
+)
+
+// checkCacheVersion is part of every key, so entries written by a version of the
+// check engine with different semantics are never reused.
+const checkCacheVersion = "2"
+
+const (
+	// maxCheckCacheEntries caps the cache file; the least recently used entries go first.
+	maxCheckCacheEntries = 20000
+	// maxCheckCacheAge expires entries that no run has used for this long.
+	maxCheckCacheAge = 30 * 24 * time.Hour
+)
+
+// uncachedChecks read local files, so their result can change without the trace changing.
+var uncachedChecks = map[string]bool{
+	"schema_valid":       true,
+	"attachment_matches": true,
+	"tool_args_schema":   true,
+}
+
+// CheckCache memoizes check results keyed by (check definition hash, output hash), so
+// byte-identical outputs (temperature 0, response caching) are only evaluated once,
+// within a run and across runs when the cache is persisted. rubric and similar_to
+// results are also keyed by the judge or embeddings model that produced them.
+type CheckCache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]checkCacheEntry
+	dirty   bool
+
+	judge    string // Fingerprint of the judge grading rubric checks
+	embedder string // Fingerprint of the embeddings model behind similar_to checks
+
+	Hits   int
+	Misses int
+}
+
+type checkCacheEntry struct {
+	Result CheckResult `json:"result"`
+	Used   time.Time   `json:"used"` // Last run that looked the entry up, to the day
+}
+
+// activeCheckCache is consulted by RunTest when set with UseCheckCache.
+var activeCheckCache *CheckCache
+
+// UseCheckCache makes RunTest consult cache for every cacheable check. Pass nil to disable.
+func UseCheckCache(cache *CheckCache) {
+	activeCheckCache = cache
+}
+
+// LoadCheckCache opens the cache persisted at path. A missing or unreadable file
+// yields an empty cache that is created on Save. Entries unused for 30 days are dropped.
+func LoadCheckCache(path string) *CheckCache {
+	cache := &CheckCache{path: path, entries: make(map[string]checkCacheEntry)}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache.entries)
+	}
+	cutoff := time.Now().Add(-maxCheckCacheAge)
+	for key, entry := range cache.entries {
+		if entry.Used.Before(cutoff) {
+			delete(cache.entries, key)
+			cache.dirty = true
+		}
+	}
+	return cache
+}
+
+// useProviders keys rubric and similar_to results by the judge and embeddings models
+// in cfg. They come from the configuration rather than the providers in use, so an
+// --offline run still finds the results of the same models.
+func (c *CheckCache) useProviders(cfg *config.RegradaConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.judge = strings.Join([]string{cfg.Judge.Provider, cfg.Judge.Model, cfg.Judge.BaseURL}, "|")
+	c.embedder = strings.Join([]string{cfg.Embeddings.Provider, cfg.Embeddings.Model, cfg.Embeddings.BaseURL}, "|")
+}
+
+// Save writes the cache back to disk if new results were added.
+func (c *CheckCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty || c.path == "" {
+		return nil
+	}
+	c.prune()
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// run returns the cached result for check against the output identified by outputHash,
+// evaluating and storing it on a miss.
+func (c *CheckCache) run(check, outputHash string, tr *trace.LLMTrace) CheckResult {
+	checkType := check
+	if idx := strings.Index(check, ":"); idx > 0 {
+		checkType = strings.TrimSpace(check[:idx])
+	}
+	if uncachedChecks[checkType] {
+		return RunCheck(check, tr)
+	}
+
+	c.mu.Lock()
+	var model string
+	switch checkType {
+	case "rubric":
+		model = c.judge
+	case "similar_to":
+		model = c.embedder
+	}
+	sum := sha256.Sum256([]byte(checkCacheVersion + "\x00" + check + "\x00" + model))
+	key := hex.EncodeToString(sum[:]) + ":" + outputHash
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if entry, ok := c.entries[key]; ok {
+		c.Hits++
+		if entry.Used.Before(today) {
+			entry.Used = today
+			c.entries[key] = entry
+			c.dirty = true
+		}
+		c.mu.Unlock()
+		return entry.Result
+	}
+	c.Misses++
+	c.mu.Unlock()
+
+	result := RunCheck(check, tr)
+	if result.transient {
+		return result
+	}
+
+	c.mu.Lock()
+	c.entries[key] = checkCacheEntry{Result: result, Used: today}
+	c.dirty = true
+	c.mu.Unlock()
+	return result
+}
+
+// prune drops the least recently used entries beyond maxCheckCacheEntries.
+func (c *CheckCache) prune() {
+	if len(c.entries) <= maxCheckCacheEntries {
+		return
+	}
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].Used.After(c.entries[keys[j]].Used)
+	})
+	for _, key := range keys[maxCheckCacheEntries:] {
+		delete(c.entries, key)
+	}
+}
+
+// OutputHash identifies everything about a trace that checks can observe: the request
+// and response bodies and the fields extracted from them. Timing and IDs are excluded,
+// so repeated identical calls hash the same.
+func OutputHash(tr *trace.LLMTrace) string {
+	h := sha256.New()
+	json.NewEncoder(h).Encode(struct {
+		Request          []byte
+		Response         []byte
+		ToolCalls        []trace.ToolCall
+		ToolsAvailable   []string
+		Attachments      []trace.Attachment
+		FinishReason     string
+		ContentFiltered  bool
+		Thinking         string
+		RedactedThinking int
+	}{
+		[]byte(tr.Request.Body), []byte(tr.Response.Body), tr.ToolCalls, tr.ToolsAvailable, tr.Attachments,
+		tr.FinishReason, tr.ContentFiltered, tr.Thinking, tr.RedactedThinking,
+	})
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/matias/regrada/trace"
+)
+
+// Checkpoint appends each test result to disk as soon as it is evaluated, so a run
+// that crashes or is interrupted can be resumed without paying for the judge and
+// embeddings calls of the tests it already finished.
+type Checkpoint struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	results map[string]TestResult
+
+	RunID   string
+	Resumed int // Results loaded from an earlier attempt of the same run
+}
+
+// checkpointEntry is one line of the checkpoint file. The first line carries only the run ID.
+type checkpointEntry struct {
+	RunID   string      `json:"run_id,omitempty"`
+	Session string      `json:"session,omitempty"`
+	Result  *TestResult `json:"result,omitempty"`
+}
+
+// activeCheckpoint is consulted by the evaluation loop when set with UseCheckpoint.
+var activeCheckpoint *Checkpoint
+
+// UseCheckpoint makes evaluation reuse and record results in cp. Pass nil to disable.
+func UseCheckpoint(cp *Checkpoint) {
+	activeCheckpoint = cp
+}
+
+// RunID identifies a run by the suite definition and the sessions it evaluates, so a
+// resumed run never mixes in results for different tests or traces.
+func RunID(suite *TestSuite, sessions []*trace.TraceSession) string {
+	h := sha256.New()
+	data, _ := json.Marshal(suite)
+	h.Write(data)
+	for _, s := range sessions {
+		h.Write([]byte("\n" + s.ID))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// OpenCheckpoint starts recording results for runID at path. With resume, results
+// already recorded there for the same run ID are kept and reused; otherwise, or when
+// the file belongs to another run, it is started over.
+func OpenCheckpoint(path, runID string, resume bool) (*Checkpoint, error) {
+	cp := &Checkpoint{path: path, RunID: runID, results: make(map[string]TestResult)}
+	if resume {
+		cp.load()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if cp.Resumed == 0 {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+	cp.file = file
+
+	if cp.Resumed == 0 {
+		if err := cp.append(checkpointEntry{RunID: runID}); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return cp, nil
+}
+
+// load reads the results recorded for this run. A truncated last line, as left by a
+// crash mid-write, is ignored.
+func (c *Checkpoint) load() {
+	file, err := os.Open(c.path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	if !scanner.Scan() {
+		return
+	}
+	var header checkpointEntry
+	if json.Unmarshal(scanner.Bytes(), &header) != nil || header.RunID != c.RunID {
+		return
+	}
+	for scanner.Scan() {
+		var entry checkpointEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || entry.Result == nil {
+			continue
+		}
+		c.results[checkpointKey(entry.Session, entry.Result.Name)] = *entry.Result
+	}
+	c.Resumed = len(c.results)
+}
+
+func checkpointKey(session, test string) string {
+	return session + "\x00" + test
+}
+
+// lookup returns the result recorded for a test in a session.
+func (c *Checkpoint) lookup(session, test string) (TestResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[checkpointKey(session, test)]
+	return result, ok
+}
+
+// record appends a result. Errors are ignored: the run goes on, it just can't be resumed.
+func (c *Checkpoint) record(session string, result TestResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[checkpointKey(session, result.Name)] = result
+	c.append(checkpointEntry{Session: session, Result: &result})
+}
+
+func (c *Checkpoint) append(entry checkpointEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = c.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the checkpoint file, keeping it for a later --resume.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// Remove closes and deletes the checkpoint once the run has completed.
+func (c *Checkpoint) Remove() error {
+	c.file.Close()
+	return os.Remove(c.path)
+}
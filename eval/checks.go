@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/matias/regrada/trace"
@@ -16,11 +17,36 @@ import (
 //   - schema_valid:<path>           - Validates response against JSON schema
 //   - tool_called:<name>            - Verifies specific tool was called
 //   - no_tool_called                - Verifies no tools were called
+//   - tool_not_called:<name>        - Verifies a specific tool was not called
+//   - tool_call_order:[a, b]        - Verifies tools were called in this relative order
+//   - tool_call_count:<json>        - Checks the number of calls (name, min, max; no name counts all calls)
+//   - tool_args_schema:<json>       - Validates every call's arguments against a JSON Schema (name, schema path)
 //   - contains:<text>               - Checks if response contains text (case-insensitive)
 //   - not_contains:<text>           - Checks if response doesn't contain text (case-insensitive)
 //   - exact:<text>                  - Checks if response exactly matches text (case-sensitive)
+//   - matches:<regex>               - Checks if response matches a regular expression
+//   - not_matches:<regex>           - Checks if response doesn't match a regular expression
 //   - contains_any:[text1, text2]   - Checks if response contains any of the texts
+//   - similar_to:<text>             - Checks the response embedding is close to a reference text
+//     (map form: text and min cosine similarity, default 0.8)
+//   - rubric:<criteria>             - Has the judge grade the response against the criteria
+//     (map form: criteria, scale 0-1 or 1-5, and optional min_score)
 //   - tool_args_contains:<json>     - Checks if tool arguments contain specific values
+//   - not_content_filtered          - Verifies the response was not blocked by a safety filter
+//   - thinking_used                 - Verifies extended thinking was used
+//   - no_thinking                   - Verifies extended thinking was not used
+//   - max_thinking_length:<N>       - Checks thinking text is at most N characters
+//   - number_eq:<value>             - Checks the number extracted from the response equals value
+//   - number_within:<value>,<tol>   - Checks the extracted number is within an absolute tolerance
+//     (map form also accepts abs_tol, rel_tol, pattern, and path)
+//   - finish_reason:<reason>        - Checks why generation stopped (stop, length, tool_calls, content_filter)
+//   - recalls:<pattern>             - Checks the response repeats a fact matched in earlier turns
+//   - min_turns:<N>                 - Checks at least N prior messages were sent with the request
+//   - refuses                       - Checks the model declined (refusal phrase or provider refusal)
+//   - no_system_prompt_leak         - Checks the response doesn't repeat the system prompt
+//   - tool_available:<name>         - Checks the tool was offered to the model in the request
+//   - has_attachment[:<filter>]     - Checks a file/document/image was sent (filter: kind, media type, or filename)
+//   - attachment_matches:<path>     - Checks the contents of a local file were sent as an attachment
 func RunCheck(check string, tr *trace.LLMTrace) CheckResult {
 	// Handle YAML map format (e.g., contains: "text")
 	// First try to parse as "type: value" format
@@ -45,6 +71,18 @@ func RunCheck(check string, tr *trace.LLMTrace) CheckResult {
 	case "tool_called":
 		return checkToolCalled(tr, checkParam)
 
+	case "tool_not_called":
+		return checkToolNotCalled(tr, checkParam)
+
+	case "tool_call_order":
+		return checkToolCallOrder(tr, checkParam)
+
+	case "tool_call_count":
+		return checkToolCallCount(tr, checkParam)
+
+	case "tool_args_schema":
+		return checkToolArgsSchema(tr, checkParam)
+
 	case "no_tool_called":
 		if len(tr.ToolCalls) == 0 {
 			result.Passed = true
@@ -68,12 +106,74 @@ func RunCheck(check string, tr *trace.LLMTrace) CheckResult {
 	case "exact":
 		return checkExact(tr, checkParam)
 
+	case "matches", "not_matches":
+		return checkMatches(tr, checkParam, checkType == "not_matches")
+
+	case "similar_to":
+		return checkSimilarTo(tr, checkParam)
+
+	case "rubric":
+		return checkRubric(tr, checkParam)
+
 	case "contains_any":
 		return checkContainsAny(tr, checkParam)
 
 	case "tool_args_contains":
 		return checkToolArgsContains(tr, checkParam)
 
+	case "not_content_filtered":
+		if tr.ContentFiltered {
+			result.Passed = false
+			result.Message = "Response was blocked by the provider's content filter"
+		} else {
+			result.Message = "Response was not content filtered"
+		}
+		return result
+
+	case "thinking_used", "no_thinking":
+		return checkThinkingUsed(tr, checkType == "thinking_used")
+
+	case "max_thinking_length":
+		return checkMaxThinkingLength(tr, checkParam)
+
+	case "max_tokens_out":
+		return checkMaxTokensOut(tr, checkParam)
+
+	case "finish_reason":
+		return checkFinishReason(tr, checkParam)
+
+	case "number_eq", "number_within":
+		return checkNumber(tr, checkType, checkParam)
+
+	case "recalls":
+		return checkRecalls(tr, checkParam)
+
+	case "min_turns":
+		return checkMinTurns(tr, checkParam)
+
+	case "refuses":
+		return checkRefuses(tr)
+
+	case "no_system_prompt_leak":
+		return checkNoSystemPromptLeak(tr)
+
+	case "tool_available":
+		for _, name := range tr.ToolsAvailable {
+			if name == checkParam {
+				result.Message = fmt.Sprintf("Tool '%s' was offered to the model", checkParam)
+				return result
+			}
+		}
+		result.Passed = false
+		result.Message = fmt.Sprintf("Tool '%s' was not offered (offered: %s)", checkParam, strings.Join(tr.ToolsAvailable, ", "))
+		return result
+
+	case "has_attachment":
+		return checkHasAttachment(tr, checkParam)
+
+	case "attachment_matches":
+		return checkAttachmentMatches(tr, checkParam)
+
 	default:
 		// Unknown check type
 		result.Passed = false
@@ -316,6 +416,99 @@ func checkToolArgsContains(tr *trace.LLMTrace, argsParam string) CheckResult {
 	return result
 }
 
+// checkThinkingUsed verifies whether extended thinking was (or was not) used in the response.
+func checkThinkingUsed(tr *trace.LLMTrace, expected bool) CheckResult {
+	result := CheckResult{
+		Check:  "no_thinking",
+		Passed: false,
+	}
+	if expected {
+		result.Check = "thinking_used"
+	}
+
+	used := tr.Thinking != "" || tr.RedactedThinking > 0
+	result.Passed = used == expected
+	if used {
+		result.Message = fmt.Sprintf("Extended thinking was used (%d characters, %d redacted blocks)", len(tr.Thinking), tr.RedactedThinking)
+	} else {
+		result.Message = "Extended thinking was not used"
+	}
+
+	return result
+}
+
+// checkMaxThinkingLength verifies that the thinking text is at most the given number of characters.
+func checkMaxThinkingLength(tr *trace.LLMTrace, limitParam string) CheckResult {
+	result := CheckResult{
+		Check:  "max_thinking_length: " + limitParam,
+		Passed: false,
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid thinking length limit: %s", limitParam)
+		return result
+	}
+
+	length := len(tr.Thinking)
+	if length <= limit {
+		result.Passed = true
+		result.Message = fmt.Sprintf("Thinking length %d is within %d", length, limit)
+	} else {
+		result.Message = fmt.Sprintf("Thinking length %d exceeds %d", length, limit)
+	}
+
+	return result
+}
+
+// checkMaxTokensOut verifies that the response used at most limit output tokens,
+// as reported in the provider's usage data.
+func checkMaxTokensOut(tr *trace.LLMTrace, limitParam string) CheckResult {
+	result := CheckResult{
+		Check:  "max_tokens_out: " + limitParam,
+		Passed: false,
+	}
+
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid output token limit: %s", limitParam)
+		return result
+	}
+
+	if tr.TokensOut == 0 {
+		result.Message = "No output token usage was recorded for this trace"
+		return result
+	}
+
+	if tr.TokensOut <= limit {
+		result.Passed = true
+		result.Message = fmt.Sprintf("Output tokens %d are within %d", tr.TokensOut, limit)
+	} else {
+		result.Message = fmt.Sprintf("Output tokens %d exceed %d", tr.TokensOut, limit)
+	}
+
+	return result
+}
+
+// checkFinishReason verifies the normalized finish reason of the response.
+func checkFinishReason(tr *trace.LLMTrace, expected string) CheckResult {
+	result := CheckResult{
+		Check:  "finish_reason: " + expected,
+		Passed: false,
+	}
+
+	if tr.FinishReason == expected {
+		result.Passed = true
+		result.Message = fmt.Sprintf("Finish reason is '%s'", expected)
+	} else if tr.FinishReason == "" {
+		result.Message = "Response has no finish reason"
+	} else {
+		result.Message = fmt.Sprintf("Finish reason is '%s', expected '%s'", tr.FinishReason, expected)
+	}
+
+	return result
+}
+
 // extractResponseText extracts the text content from a trace response.
 func extractResponseText(tr *trace.LLMTrace) string {
 	var responseData map[string]interface{}
@@ -341,6 +534,10 @@ func extractResponseText(tr *trace.LLMTrace) string {
 		texts := []string{}
 		for _, c := range content {
 			if cMap, ok := c.(map[string]interface{}); ok {
+				// Only text blocks form the answer; thinking blocks are exposed separately
+				if blockType, ok := cMap["type"].(string); ok && blockType != "text" {
+					continue
+				}
 				if text, ok := cMap["text"].(string); ok {
 					texts = append(texts, text)
 				}
@@ -351,6 +548,17 @@ func extractResponseText(tr *trace.LLMTrace) string {
 		}
 	}
 
+	// Gemini format: candidates[0].content.parts[].text
+	if candidates, ok := responseData["candidates"].([]interface{}); ok && len(candidates) > 0 {
+		if candidate, ok := candidates[0].(map[string]interface{}); ok {
+			if content, ok := candidate["content"].(map[string]interface{}); ok {
+				if text := contentText(content["parts"]); text != "" {
+					return text
+				}
+			}
+		}
+	}
+
 	// Ollama/Custom format: message.content
 	if message, ok := responseData["message"].(map[string]interface{}); ok {
 		if content, ok := message["content"].(string); ok {
@@ -358,6 +566,34 @@ func extractResponseText(tr *trace.LLMTrace) string {
 		}
 	}
 
+	// Bedrock Converse format: output.message.content[].text
+	if output, ok := responseData["output"].(map[string]interface{}); ok {
+		if message, ok := output["message"].(map[string]interface{}); ok {
+			if text := contentText(message["content"]); text != "" {
+				return text
+			}
+		}
+	}
+
+	// Amazon Titan format: results[0].outputText
+	if results, ok := responseData["results"].([]interface{}); ok && len(results) > 0 {
+		if result, ok := results[0].(map[string]interface{}); ok {
+			if text, ok := result["outputText"].(string); ok {
+				return text
+			}
+		}
+	}
+
+	// Meta Llama on Bedrock: generation
+	if generation, ok := responseData["generation"].(string); ok {
+		return generation
+	}
+
+	// Ollama /api/generate format: response
+	if response, ok := responseData["response"].(string); ok {
+		return response
+	}
+
 	// Fallback: return JSON as string
 	return string(tr.Response.Body)
 }
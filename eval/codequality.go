@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// codeQualitySeverities maps test severities to GitLab code quality severities.
+var codeQualitySeverities = map[string]string{
+	SeverityLow:      "minor",
+	SeverityMedium:   "major",
+	SeverityHigh:     "critical",
+	SeverityCritical: "blocker",
+}
+
+// CodeQualityIssue is an entry of a GitLab code quality report.
+type CodeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    CodeQualityLocation `json:"location"`
+}
+
+// CodeQualityLocation points an issue at a line of a file.
+type CodeQualityLocation struct {
+	Path  string `json:"path"`
+	Lines struct {
+		Begin int `json:"begin"`
+	} `json:"lines"`
+}
+
+// WriteCodeQuality writes the failed checks and policy violations of the result's
+// gated tests as a GitLab code quality report, each pointing at the test's definition
+// in the suite file. Fingerprints depend on the test and check only, so GitLab can
+// tell new issues in a merge request from ones already failing on the target branch.
+func WriteCodeQuality(result *EvalResult, suitePath, path string) error {
+	lines := testLines(suitePath)
+	issues := []CodeQualityIssue{}
+	for _, tr := range result.TestResults {
+		if tr.State == StateDraft || tr.XFail != "" || (tr.Status != "failed" && tr.Status != "error") {
+			continue
+		}
+		severity := codeQualitySeverities[tr.Severity]
+		if severity == "" {
+			severity = codeQualitySeverities[SeverityMedium]
+		}
+
+		issue := func(check, message string) {
+			description := tr.Name + ": " + check
+			if message != "" {
+				description += ": " + message
+			}
+			if tr.Regression {
+				description = "Regression in " + description
+			}
+			entry := CodeQualityIssue{
+				Description: description,
+				CheckName:   "regrada/" + check,
+				Fingerprint: codeQualityFingerprint(result.TestSuite, tr.Name, check),
+				Severity:    severity,
+			}
+			entry.Location.Path = filepath.ToSlash(suitePath)
+			entry.Location.Lines.Begin = max(lines[tr.Name], 1)
+			issues = append(issues, entry)
+		}
+
+		if tr.Status == "error" {
+			issue("error", tr.Error)
+			continue
+		}
+		for _, cr := range tr.CheckResults {
+			if cr.Failed() {
+				issue(cr.Check, cr.Message)
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// codeQualityFingerprint identifies a failed check of a test across runs.
+func codeQualityFingerprint(suite, test, check string) string {
+	sum := sha256.Sum256([]byte(suite + "\x00" + test + "\x00" + check))
+	return hex.EncodeToString(sum[:16])
+}
+
+var testNameLine = regexp.MustCompile(`^\s*(?:-\s+)?name:\s*(.+?)\s*$`)
+
+// testLines maps the test names defined in a suite file to the line declaring them.
+// Tests expanded from datasets or defined elsewhere are missing from the map.
+func testLines(suitePath string) map[string]int {
+	lines := make(map[string]int)
+	f, err := os.Open(suitePath)
+	if err != nil {
+		return lines
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		m := testNameLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		name := strings.Trim(m[1], `"'`)
+		if _, seen := lines[name]; !seen {
+			lines[name] = n
+		}
+	}
+	return lines
+}
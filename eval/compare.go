@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/matias/regrada/trace"
+)
+
+// Aggregates summarizes how a model did on a suite, for comparing models side by side.
+// Pass rate covers gated tests; latency, cost, tokens, and refusals cover every call
+// in the session. Standard deviations are sample standard deviations.
+type Aggregates struct {
+	Tests       int           `json:"tests"`
+	Passed      int           `json:"passed"`
+	PassRate    float64       `json:"pass_rate"`
+	Calls       int           `json:"calls"`
+	LatencyMean time.Duration `json:"latency_mean_ms"`
+	LatencyP95  time.Duration `json:"latency_p95_ms"`
+	CostUSD     float64       `json:"cost_usd"`
+	TokensOut   int           `json:"tokens_out"`
+	Refusals    int           `json:"refusals"`
+	RefusalRate float64       `json:"refusal_rate"`
+
+	// PassRateCV is the coefficient of variation of the tests' pass rates: of their
+	// pass/fail outcomes, or with --runs of each test's pass rate across runs.
+	PassRateCV float64 `json:"pass_rate_cv"`
+
+	LatencyStdDev   time.Duration `json:"latency_stddev_ms"`
+	LatencyMin      time.Duration `json:"latency_min_ms"`
+	LatencyMax      time.Duration `json:"latency_max_ms"`
+	TokensOutMean   float64       `json:"tokens_out_mean"` // Per call
+	TokensOutStdDev float64       `json:"tokens_out_stddev"`
+	TokensOutMin    int           `json:"tokens_out_min"`
+	TokensOutMax    int           `json:"tokens_out_max"`
+}
+
+// Aggregate computes the aggregates of a run and the session it evaluated.
+func Aggregate(result *EvalResult, session *trace.TraceSession) Aggregates {
+	agg := Aggregates{
+		Tests:   result.Passed + result.Failed,
+		Passed:  result.Passed,
+		Calls:   len(session.Traces),
+		CostUSD: session.Summary.TotalCostUSD,
+	}
+	if agg.Tests > 0 {
+		agg.PassRate = float64(agg.Passed) / float64(agg.Tests)
+	}
+	agg.PassRateCV = testPassRateCV(result)
+
+	var latencies []time.Duration
+	var total time.Duration
+	var tokens []float64
+	for i := range session.Traces {
+		tr := &session.Traces[i]
+		agg.TokensOut += tr.TokensOut
+		if tr.TokensOut > 0 {
+			tokens = append(tokens, float64(tr.TokensOut))
+		}
+		if IsRefusal(tr) {
+			agg.Refusals++
+		}
+		if tr.Latency > 0 {
+			latencies = append(latencies, tr.Latency)
+			total += tr.Latency
+		}
+	}
+	if len(tokens) > 0 {
+		mean, stddev, low, high := spread(tokens)
+		agg.TokensOutMean, agg.TokensOutStdDev = mean, stddev
+		agg.TokensOutMin, agg.TokensOutMax = int(low), int(high)
+	}
+	if agg.Calls > 0 {
+		agg.RefusalRate = float64(agg.Refusals) / float64(agg.Calls)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		agg.LatencyMean = total / time.Duration(len(latencies))
+		rank := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+		agg.LatencyP95 = latencies[max(rank, 0)]
+
+		values := make([]float64, len(latencies))
+		for i, l := range latencies {
+			values[i] = float64(l)
+		}
+		_, stddev, low, high := spread(values)
+		agg.LatencyStdDev = time.Duration(stddev)
+		agg.LatencyMin, agg.LatencyMax = time.Duration(low), time.Duration(high)
+	}
+	return agg
+}
+
+// testPassRateCV is the coefficient of variation of the gated tests' pass rates, taken
+// across runs for tests evaluated with --runs and as 1 or 0 otherwise.
+func testPassRateCV(result *EvalResult) float64 {
+	var rates []float64
+	for _, tr := range result.TestResults {
+		if tr.State == StateDraft || tr.XFail != "" || tr.Status == "skipped" {
+			continue
+		}
+		switch {
+		case tr.Stats != nil:
+			rates = append(rates, tr.Stats.PassRate)
+		case tr.Status == "passed":
+			rates = append(rates, 1)
+		default:
+			rates = append(rates, 0)
+		}
+	}
+	if len(rates) == 0 {
+		return 0
+	}
+	mean, stddev, _, _ := spread(rates)
+	return coefficientOfVariation(mean, stddev)
+}
+
+// TestChange is a test whose status differs between two runs.
+type TestChange struct {
+	Name    string `json:"name"`
+	StatusA string `json:"status_a"`
+	StatusB string `json:"status_b"`
+}
+
+// DiffTests lists the tests that pass in one run and fail in the other: regressed are
+// tests that passed in a and fail in b, fixed the reverse.
+func DiffTests(a, b *EvalResult) (regressed, fixed []TestChange) {
+	statusA := make(map[string]string, len(a.TestResults))
+	for _, tr := range a.TestResults {
+		statusA[tr.Name] = tr.Status
+	}
+	for _, tr := range b.TestResults {
+		before, ok := statusA[tr.Name]
+		if !ok {
+			continue
+		}
+		change := TestChange{Name: tr.Name, StatusA: before, StatusB: tr.Status}
+		switch {
+		case before == "passed" && tr.Status == "failed":
+			regressed = append(regressed, change)
+		case before == "failed" && tr.Status == "passed":
+			fixed = append(fixed, change)
+		}
+	}
+	return regressed, fixed
+}
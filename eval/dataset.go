@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// RunTestInSession resolves a test's trace(s) in a session and runs its checks, with
+// {{var}} placeholders filled from the test's vars. Tests with a dataset or trace_ids
+// run once per row (see datasetRows), with the row's values taking precedence over
+// vars; the test passes only if every row passes. Resolution failures produce a
+// result with status "error".
+func RunTestInSession(test TestCase, session *trace.TraceSession) TestResult {
+	if test.sessionLevel {
+		return RunSessionChecks(interpolateChecks(test.Checks, test.Vars), session)
+	}
+	rows := datasetRows(test)
+	if rows == nil {
+		test = withVars(test, test.Vars)
+		tr, err := GetTraceForTest(test, session)
+		if err != nil {
+			return TestResult{Name: test.Name, Status: "error", Error: err.Error()}
+		}
+		return RunTest(test, tr)
+	}
+
+	combined := TestResult{Name: test.Name, Status: "passed"}
+	for _, row := range rows {
+		tr, err := GetTraceForTest(row.test, session)
+		if err != nil {
+			return TestResult{Name: test.Name, Status: "error", Error: err.Error()}
+		}
+
+		rowResult := RunTest(row.test, tr)
+		combined.Duration += rowResult.Duration
+		combined.TokensIn += rowResult.TokensIn
+		combined.TokensOut += rowResult.TokensOut
+		combined.CostUSD += rowResult.CostUSD
+		combined.Retries += rowResult.Retries
+		if combined.PromptFingerprint == "" {
+			combined.PromptFingerprint = rowResult.PromptFingerprint
+		}
+		for _, cr := range rowResult.CheckResults {
+			cr.Check = fmt.Sprintf("[%s] %s", row.label, cr.Check)
+			combined.CheckResults = append(combined.CheckResults, cr)
+		}
+		if rowResult.Status != "passed" && statusRank[rowResult.Status] >= statusRank[combined.Status] {
+			combined.Status = rowResult.Status
+			combined.Error = rowResult.Error
+		}
+	}
+	if combined.Status == "passed" && allSkipped(combined.CheckResults) {
+		combined.Status = "skipped"
+		combined.Error = combined.CheckResults[0].Message
+	}
+
+	combined.Score = meanScore(combined.CheckResults)
+	return combined
+}
+
+// statusRank orders the statuses of a test's rows, so a failing or erroring row
+// decides the combined status. A skipped row doesn't hide the rows that ran.
+var statusRank = map[string]int{"skipped": 0, "passed": 1, "failed": 2, "error": 3}
+
+// datasetRow is one parameterized run of a test, labeled in its check results.
+type datasetRow struct {
+	label string
+	test  TestCase
+}
+
+// datasetRows expands a test into one run per row, or returns nil for a plain test.
+// With trace_ids, row i evaluates the i-th listed trace. Otherwise each dataset row
+// evaluates the trace named by its trace_id column, or the one the test's select
+// matches once filled with the row's values, or else the trace at trace_index plus
+// the row number, for apps that process the dataset in order.
+func datasetRows(test TestCase) []datasetRow {
+	var rows []datasetRow
+	if len(test.TraceIDs) > 0 {
+		for i, id := range test.TraceIDs {
+			vars := test.Vars
+			if i < len(test.Dataset.Rows) {
+				vars = mergeVars(vars, test.Dataset.Rows[i])
+			}
+			row := TestCase{Name: test.Name, TraceID: id, Checks: interpolateChecks(test.Checks, vars), Ignore: test.Ignore}
+			rows = append(rows, datasetRow{label: id, test: row})
+		}
+		return rows
+	}
+
+	for i, values := range test.Dataset.Rows {
+		row := withVars(test, mergeVars(test.Vars, values))
+		row.Dataset = Dataset{}
+		label := fmt.Sprintf("row %d", i+1)
+		if id := values["trace_id"]; id != "" {
+			row.TraceID = id
+			label = id
+		} else if row.Select == nil {
+			row.TraceIndex = test.TraceIndex + i
+		}
+		rows = append(rows, datasetRow{label: label, test: row})
+	}
+	return rows
+}
+
+// interpolateChecks substitutes {{name}} placeholders in checks with values from vars.
+func interpolateChecks(checks []Check, vars map[string]string) []Check {
+	if len(vars) == 0 {
+		return checks
+	}
+	out := make([]Check, len(checks))
+	for i, c := range checks {
+		out[i] = Check{Raw: interpolate(c.Raw, vars)}
+	}
+	return out
+}
+
+// ExtractPromptText returns the text of the last user message in a trace's request body.
+// It understands OpenAI/Anthropic/Ollama style "messages" arrays with either string
+// content or arrays of text parts, Gemini "contents" arrays, and Ollama generate prompts.
+func ExtractPromptText(tr *trace.LLMTrace) string {
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(tr.Request.Body, &reqData); err != nil {
+		return ""
+	}
+
+	messages, ok := reqData["messages"].([]interface{})
+	if !ok {
+		messages, ok = reqData["contents"].([]interface{})
+		if !ok {
+			// Ollama /api/generate sends a bare prompt
+			prompt, _ := reqData["prompt"].(string)
+			return prompt
+		}
+	}
+
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg, ok := messages[i].(map[string]interface{})
+		if !ok || msg["role"] != "user" {
+			continue
+		}
+		return messageText(msg)
+	}
+
+	return ""
+}
+
+// messageText returns the text of a message, from "content" or Gemini "parts".
+func messageText(msg map[string]interface{}) string {
+	if parts, ok := msg["parts"]; ok {
+		return contentText(parts)
+	}
+	return contentText(msg["content"])
+}
+
+// contentText flattens message content (a string or an array of parts) into plain text.
+func contentText(content interface{}) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []interface{}:
+		var texts []string
+		for _, part := range c {
+			if p, ok := part.(map[string]interface{}); ok {
+				if text, ok := p["text"].(string); ok {
+					texts = append(texts, text)
+				}
+			}
+		}
+		return strings.Join(texts, " ")
+	default:
+		return ""
+	}
+}
+
+// ParameterizeStubs collapses groups of near-duplicate test stubs into a single
+// parameterized test. Traces are grouped by call shape and by prompt template:
+// prompts with the same number of words that differ only at some positions are
+// treated as one template, and the differing words become dataset variables.
+// Stubs must reference their traces by TraceID.
+func ParameterizeStubs(stubs []TestCase, session *trace.TraceSession) []TestCase {
+	byID := make(map[string]*trace.LLMTrace)
+	for i := range session.Traces {
+		byID[session.Traces[i].ID] = &session.Traces[i]
+	}
+
+	type group struct {
+		stubs  []TestCase
+		tokens [][]string
+	}
+	groups := make(map[string]*group)
+	var order []string
+
+	for _, stub := range stubs {
+		tr, ok := byID[stub.TraceID]
+		if !ok {
+			continue
+		}
+		tokens := strings.Fields(ExtractPromptText(tr))
+		key := fmt.Sprintf("%s|%d", traceShape(tr), len(tokens))
+		if len(tokens) == 0 {
+			key = "single|" + stub.TraceID
+		}
+
+		g, exists := groups[key]
+		if !exists {
+			g = &group{}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.stubs = append(g.stubs, stub)
+		g.tokens = append(g.tokens, tokens)
+	}
+
+	var out []TestCase
+	for _, key := range order {
+		g := groups[key]
+		if len(g.stubs) < 2 {
+			out = append(out, g.stubs...)
+			continue
+		}
+
+		// Positions where prompts differ become variables
+		var varying []int
+		for pos := range g.tokens[0] {
+			for _, tokens := range g.tokens[1:] {
+				if tokens[pos] != g.tokens[0][pos] {
+					varying = append(varying, pos)
+					break
+				}
+			}
+		}
+
+		// Prompts that differ in most positions are not really one template
+		if len(varying) == 0 || len(varying)*2 > len(g.tokens[0]) {
+			out = append(out, g.stubs...)
+			continue
+		}
+
+		test := g.stubs[0]
+		test.TraceIndex = 0
+		test.TraceID = ""
+		test.Description = fmt.Sprintf("Parameterized over %d similar traces", len(g.stubs))
+		for i, stub := range g.stubs {
+			row := make(map[string]string, len(varying))
+			for v, pos := range varying {
+				row[fmt.Sprintf("var%d", v+1)] = g.tokens[i][pos]
+			}
+			test.TraceIDs = append(test.TraceIDs, stub.TraceID)
+			test.Dataset.Rows = append(test.Dataset.Rows, row)
+		}
+		out = append(out, test)
+	}
+
+	return out
+}
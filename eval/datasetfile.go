@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dataset holds the rows a test is run with. In YAML it is either a list of rows
+// written inline or the path of a .csv (header row first) or .jsonl (one object per
+// line) file, whose rows are read when the suite is loaded.
+type Dataset struct {
+	File string
+	Rows []map[string]string
+}
+
+// UnmarshalYAML accepts a file path or a list of rows.
+func (d *Dataset) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&d.File)
+	}
+	if err := value.Decode(&d.Rows); err != nil {
+		return fmt.Errorf("dataset must be a file path or a list of rows")
+	}
+	return nil
+}
+
+// MarshalYAML writes the file path back when the rows came from a file.
+func (d Dataset) MarshalYAML() (interface{}, error) {
+	if d.File != "" {
+		return d.File, nil
+	}
+	return d.Rows, nil
+}
+
+// IsZero lets omitempty drop an empty dataset.
+func (d Dataset) IsZero() bool {
+	return d.File == "" && len(d.Rows) == 0
+}
+
+// load reads the rows of a file dataset.
+func (d *Dataset) load() error {
+	if d.File == "" {
+		return nil
+	}
+	data, err := os.ReadFile(d.File)
+	if err != nil {
+		return fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(d.File)) {
+	case ".csv":
+		d.Rows, err = parseCSVRows(data)
+	case ".jsonl", ".ndjson":
+		d.Rows, err = parseJSONLRows(data)
+	default:
+		return fmt.Errorf("unsupported dataset file %s (must be .csv or .jsonl)", d.File)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse dataset %s: %w", d.File, err)
+	}
+	return nil
+}
+
+// parseCSVRows reads CSV records keyed by the header row.
+func parseCSVRows(data []byte) ([]map[string]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(record) {
+				row[strings.TrimSpace(name)] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseJSONLRows reads one JSON object per line. Values that are not strings are kept
+// as their JSON encoding; blank lines are skipped.
+func parseJSONLRows(data []byte) ([]map[string]string, error) {
+	var rows []map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(text), &obj); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		row := make(map[string]string, len(obj))
+		for key, raw := range obj {
+			var s string
+			if json.Unmarshal(raw, &s) == nil {
+				row[key] = s
+			} else {
+				row[key] = string(raw)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
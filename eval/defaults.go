@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultsFileNames are the names of directory-level test defaults files.
+var defaultsFileNames = []string{"_defaults.yml", "_defaults.yaml"}
+
+// TestDefaults are settings shared by every test in the suites of a directory and its
+// subdirectories, read from a _defaults.yml file. A test's own settings win; tags,
+// checks, and ignore rules are added to the test's, and vars are defaults for the
+// suite's vars.
+type TestDefaults struct {
+	Owner    string            `yaml:"owner,omitempty"`
+	Severity string            `yaml:"severity,omitempty"`
+	State    string            `yaml:"state,omitempty"`
+	Provider string            `yaml:"provider,omitempty"`
+	Model    string            `yaml:"model,omitempty"`
+	Tags     []string          `yaml:"tags,omitempty"`
+	Vars     map[string]string `yaml:"vars,omitempty"`
+	Checks   []Check           `yaml:"checks,omitempty"`
+	Ignore   []IgnoreRule      `yaml:"ignore,omitempty"`
+}
+
+// loadDefaults merges the defaults files that apply to the suite at path: those in its
+// directory and each parent up to the working directory, with nearer files winning. It
+// returns the merged defaults and the files they came from.
+func loadDefaults(path string) (TestDefaults, []string, error) {
+	var merged TestDefaults
+	var files []string
+	for _, dir := range defaultsDirs(path) {
+		for _, name := range defaultsFileNames {
+			file := filepath.Join(dir, name)
+			data, err := os.ReadFile(file)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return merged, nil, fmt.Errorf("could not read %s: %w", file, err)
+			}
+			var d TestDefaults
+			if err := yaml.Unmarshal(data, &d); err != nil {
+				return merged, nil, fmt.Errorf("could not parse %s: %w", file, err)
+			}
+			merged = merged.overriddenBy(d)
+			files = append(files, file)
+		}
+	}
+	return merged, files, nil
+}
+
+// defaultsDirs lists the directories whose defaults apply to the suite at path, outermost
+// first. Suites outside the working directory only use defaults beside them.
+func defaultsDirs(path string) []string {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return []string{filepath.Dir(path)}
+	}
+	root, err := os.Getwd()
+	if err != nil || (dir != root && !strings.HasPrefix(dir, root+string(filepath.Separator))) {
+		return []string{filepath.Dir(path)}
+	}
+
+	var dirs []string
+	for {
+		rel, _ := filepath.Rel(root, dir)
+		dirs = append([]string{rel}, dirs...)
+		if dir == root {
+			return dirs
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// overriddenBy returns d with the settings of nearer defaults applied.
+func (d TestDefaults) overriddenBy(near TestDefaults) TestDefaults {
+	if near.Owner != "" {
+		d.Owner = near.Owner
+	}
+	if near.Severity != "" {
+		d.Severity = near.Severity
+	}
+	if near.State != "" {
+		d.State = near.State
+	}
+	if near.Provider != "" {
+		d.Provider = near.Provider
+	}
+	if near.Model != "" {
+		d.Model = near.Model
+	}
+	d.Tags = mergeTags(d.Tags, near.Tags)
+	d.Vars = mergeVars(d.Vars, near.Vars)
+	d.Checks = append(append([]Check(nil), d.Checks...), near.Checks...)
+	d.Ignore = append(append([]IgnoreRule(nil), d.Ignore...), near.Ignore...)
+	return d
+}
+
+// apply fills the test settings left unset from the defaults.
+func (d TestDefaults) apply(test *TestCase) {
+	if test.Severity == "" {
+		test.Severity = d.Severity
+	}
+	if test.State == "" {
+		test.State = d.State
+	}
+	if test.Provider == "" {
+		test.Provider = d.Provider
+	}
+	if test.Model == "" {
+		test.Model = d.Model
+	}
+	test.Tags = mergeTags(d.Tags, test.Tags)
+	if len(d.Checks) > 0 {
+		test.Checks = append(append([]Check(nil), test.Checks...), d.Checks...)
+	}
+	if len(d.Ignore) > 0 {
+		test.Ignore = append(append([]IgnoreRule(nil), d.Ignore...), test.Ignore...)
+	}
+}
+
+// mergeTags returns base followed by the tags in more that base lacks.
+func mergeTags(base, more []string) []string {
+	if len(more) == 0 {
+		return base
+	}
+	merged := append([]string(nil), base...)
+	for _, tag := range more {
+		if !containsTag(merged, tag) {
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
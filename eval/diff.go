@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"strings"
+	"time"
+)
+
+// ResultDiff compares two saved runs test by test.
+type ResultDiff struct {
+	Regressed []TestChange `json:"regressed,omitempty"` // Passed in A, fail in B
+	Fixed     []TestChange `json:"fixed,omitempty"`     // Failed in A, pass in B
+	Added     []string     `json:"added,omitempty"`     // Only in B
+	Removed   []string     `json:"removed,omitempty"`   // Only in A
+	Tests     []TestDelta  `json:"tests"`               // Tests in both runs, in B's order
+}
+
+// TestDelta is how a test's result changed from run A to run B.
+type TestDelta struct {
+	Name       string        `json:"name"`
+	StatusA    string        `json:"status_a"`
+	StatusB    string        `json:"status_b"`
+	LatencyA   time.Duration `json:"latency_a_ms,omitempty"`
+	LatencyB   time.Duration `json:"latency_b_ms,omitempty"`
+	TokensOutA int           `json:"tokens_out_a,omitempty"`
+	TokensOutB int           `json:"tokens_out_b,omitempty"`
+	CostA      float64       `json:"cost_usd_a,omitempty"`
+	CostB      float64       `json:"cost_usd_b,omitempty"`
+	ScoreA     *float64      `json:"score_a,omitempty"`
+	ScoreB     *float64      `json:"score_b,omitempty"`
+
+	// OutputDiff is a line diff of the outputs, empty when they are identical.
+	OutputDiff []string `json:"output_diff,omitempty"`
+}
+
+// Changed reports whether anything but the test's name differs between the runs.
+func (d TestDelta) Changed() bool {
+	return d.StatusA != d.StatusB || d.LatencyA != d.LatencyB || d.TokensOutA != d.TokensOutB ||
+		d.CostA != d.CostB || !sameScore(d.ScoreA, d.ScoreB) || len(d.OutputDiff) > 0
+}
+
+func sameScore(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// DiffResults compares run b with run a: the tests that regressed or were fixed, the
+// tests only one run has, and every shared test's metrics and output.
+func DiffResults(a, b *EvalResult) *ResultDiff {
+	diff := &ResultDiff{Tests: []TestDelta{}}
+	diff.Regressed, diff.Fixed = DiffTests(a, b)
+
+	inA := make(map[string]TestResult, len(a.TestResults))
+	for _, tr := range a.TestResults {
+		inA[tr.Name] = tr
+	}
+	inB := make(map[string]bool, len(b.TestResults))
+	for _, trB := range b.TestResults {
+		inB[trB.Name] = true
+		trA, ok := inA[trB.Name]
+		if !ok {
+			diff.Added = append(diff.Added, trB.Name)
+			continue
+		}
+		diff.Tests = append(diff.Tests, TestDelta{
+			Name:       trB.Name,
+			StatusA:    trA.Status,
+			StatusB:    trB.Status,
+			LatencyA:   trA.Latency,
+			LatencyB:   trB.Latency,
+			TokensOutA: trA.TokensOut,
+			TokensOutB: trB.TokensOut,
+			CostA:      trA.CostUSD,
+			CostB:      trB.CostUSD,
+			ScoreA:     trA.Score,
+			ScoreB:     trB.Score,
+			OutputDiff: DiffLines(trA.Output, trB.Output),
+		})
+	}
+	for _, tr := range a.TestResults {
+		if !inB[tr.Name] {
+			diff.Removed = append(diff.Removed, tr.Name)
+		}
+	}
+	return diff
+}
+
+// maxDiffLines bounds the quadratic line diff; longer texts are shown as replaced whole.
+const maxDiffLines = 2000
+
+// DiffLines returns a line diff of a and b, with unchanged lines prefixed by "  ",
+// removed lines by "- ", and added lines by "+ ". It is nil when a and b are equal.
+func DiffLines(a, b string) []string {
+	if a == b {
+		return nil
+	}
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	if len(linesA) > maxDiffLines || len(linesB) > maxDiffLines {
+		var diff []string
+		for _, line := range linesA {
+			diff = append(diff, "- "+line)
+		}
+		for _, line := range linesB {
+			diff = append(diff, "+ "+line)
+		}
+		return diff
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of linesA[i:] and linesB[j:]
+	lcs := make([][]int, len(linesA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(linesB)+1)
+	}
+	for i := len(linesA) - 1; i >= 0; i-- {
+		for j := len(linesB) - 1; j >= 0; j-- {
+			if linesA[i] == linesB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(linesA) || j < len(linesB) {
+		switch {
+		case i < len(linesA) && j < len(linesB) && linesA[i] == linesB[j]:
+			diff = append(diff, "  "+linesA[i])
+			i++
+			j++
+		case i < len(linesA) && (j == len(linesB) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "- "+linesA[i])
+			i++
+		default:
+			diff = append(diff, "+ "+linesB[j])
+			j++
+		}
+	}
+	return diff
+}
@@ -7,10 +7,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/matias/regrada/config"
 	"github.com/matias/regrada/trace"
+	"github.com/matias/regrada/vcs"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,7 +22,17 @@ import (
 type TestSuite struct {
 	Name        string     `yaml:"name"`
 	Description string     `yaml:"description"`
+	Owner       string     `yaml:"owner,omitempty"` // Default owner for tests that don't set one
 	Tests       []TestCase `yaml:"tests"`
+
+	// SessionChecks run over every trace in the session rather than a single trace.
+	// Their result is reported as a test named "session".
+	SessionChecks []Check `yaml:"session_checks,omitempty"`
+
+	// Vars are default {{var}} values for every test; a test's own vars win.
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// defaultsFiles are the _defaults.yml files applied when the suite was loaded.
+	defaultsFiles []string
 }
 
 // TestCase represents a single test.
@@ -28,8 +42,54 @@ type TestCase struct {
 	TraceIndex  int     `yaml:"trace_index"`
 	TraceID     string  `yaml:"trace_id,omitempty"`
 	Checks      []Check `yaml:"checks"`
-}
 
+	// Select picks the trace by endpoint, model, prompt, or case header instead of
+	// trace_index. trace_id takes precedence.
+	Select *TraceSelector `yaml:"select,omitempty"`
+
+	// Provider and Model restrict the test to calls made to that provider and model, so
+	// one suite can cover an app that mixes models. trace_index then counts among them.
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+
+	// Ignore lists accepted differences normalized away before checks run.
+	Ignore []IgnoreRule `yaml:"ignore,omitempty"`
+
+	// Vars fill {{var}} placeholders in the test's checks and select, so one test
+	// definition can be run with different inputs (see --var).
+	Vars map[string]string `yaml:"vars,omitempty"`
+
+	// TraceIDs runs the test once per listed trace; Dataset holds the {{var}}
+	// values for each trace, in the same order. Without trace_ids, the test runs
+	// once per dataset row, which may come from a CSV or JSONL file.
+	TraceIDs []string `yaml:"trace_ids,omitempty"`
+	Dataset  Dataset  `yaml:"dataset,omitempty"`
+
+	// State is the lifecycle state: active (default), draft, or deprecated.
+	// Drafts run but never gate CI; deprecated tests are skipped until Sunset (YYYY-MM-DD).
+	State  string `yaml:"state,omitempty"`
+	Sunset string `yaml:"sunset,omitempty"`
+
+	// Owner is the team or GitHub handle that failures are routed to.
+	Owner string `yaml:"owner,omitempty"`
+
+	// Tags group tests in reports, e.g. by feature or surface.
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Severity weights a failure in the run's quality score: low, medium (default), high, critical.
+	Severity string `yaml:"severity,omitempty"`
+
+	// Skip is the reason the test is not run. XFail is the reason the test is expected to
+	// fail: it runs but never gates, and an unexpected pass is flagged.
+	Skip  string `yaml:"skip,omitempty"`
+	XFail string `yaml:"xfail,omitempty"`
+
+	// Assert holds structured expectations, added to Checks when the suite is loaded.
+	Assert *Assertions `yaml:"assert,omitempty"`
+
+	// sessionLevel marks the pseudo-test that runs the suite's session checks.
+	sessionLevel bool
+}
 
 // Check represents a single check that can be unmarshaled from either string or map format.
 type Check struct {
@@ -99,24 +159,124 @@ func (c Check) MarshalYAML() (interface{}, error) {
 
 // EvalResult represents the result of running evaluations.
 type EvalResult struct {
+	Status      string              `json:"status"` // success, failure, regression
 	Timestamp   time.Time           `json:"timestamp"`
 	TestSuite   string              `json:"test_suite"`
 	TotalTests  int                 `json:"total_tests"`
 	Passed      int                 `json:"passed"`
 	Failed      int                 `json:"failed"`
+	Errored     int                 `json:"errored,omitempty"` // Failed tests whose checks errored (provider unavailable), not failed
 	Regressions int                 `json:"regressions"`
+	Skipped     int                 `json:"skipped,omitempty"`
+	Drafts      int                 `json:"drafts,omitempty"`
+	XFailed     int                 `json:"xfailed,omitempty"` // xfail tests that failed as expected
+	XPassed     int                 `json:"xpassed,omitempty"` // xfail tests that passed unexpectedly
 	TestResults []TestResult        `json:"test_results"`
 	Comparison  *BaselineComparison `json:"comparison,omitempty"`
+	SLOReport   []SLOCompliance     `json:"slo_report,omitempty"`
+	Sections    []SectionSummary    `json:"sections,omitempty"`
+	Footprint   *trace.Footprint    `json:"footprint,omitempty"`
+	Quality     *QualityScore       `json:"quality,omitempty"`
+	CostUSD     float64             `json:"cost_usd,omitempty"` // Estimated cost of the evaluated session
+	Scores      *ScoreDistribution  `json:"scores,omitempty"`   // Rubric scores, when any test was graded
+	Pairwise    *PairwiseSummary    `json:"pairwise,omitempty"` // Pairwise comparison with the baseline outputs
+
+	// PassAtK averages the tests' pass@k estimates and majority votes across runs (run --runs).
+	PassAtK map[string]float64 `json:"pass_at_k,omitempty"`
+
+	// Retries is the number of provider retries behind the evaluated traces.
+	Retries int `json:"retries,omitempty"`
+
+	// PromptSHA256 is the hash of the resolved system prompt the session was traced
+	// with (provider.system_prompt_file), so baselines are tied to the prompt they saw.
+	PromptSHA256 string `json:"prompt_sha256,omitempty"`
+
+	// PromptFiles maps each file the system prompt was built from to its SHA-256.
+	PromptFiles map[string]string `json:"prompt_files,omitempty"`
+
+	// Provenance records the commit, regrada version, and provider/model behind the
+	// result, which a baseline carries into the reports of runs compared with it.
+	Provenance *Provenance `json:"provenance,omitempty"`
+
+	// Filtered is set when only part of the suite ran (--tags, --exclude-tags,
+	// --filter, --only-failed). Such results are merged into a baseline instead of
+	// replacing it, and left out of trends and rolling baselines.
+	Filtered bool `json:"filtered,omitempty"`
+}
+
+// Overall run statuses recorded in EvalResult.Status.
+const (
+	RunSuccess    = "success"
+	RunFailure    = "failure"
+	RunRegression = "regression"
+	RunError      = "error"
+)
+
+// UpdateStatus derives the overall run status from the result counts.
+func (r *EvalResult) UpdateStatus() {
+	switch {
+	case r.Regressions > 0:
+		r.Status = RunRegression
+	case r.Failed > 0:
+		r.Status = RunFailure
+	default:
+		r.Status = RunSuccess
+	}
 }
 
 // TestResult represents a single test result.
 type TestResult struct {
 	Name         string        `json:"name"`
-	Status       string        `json:"status"` // passed, failed, error
+	Status       string        `json:"status"` // passed, failed, error, skipped
+	State        string        `json:"state,omitempty"`
+	Owner        string        `json:"owner,omitempty"`
+	Tags         []string      `json:"tags,omitempty"`
+	Severity     string        `json:"severity,omitempty"`
+	Model        string        `json:"model,omitempty"`
 	Duration     time.Duration `json:"duration_ms"`
 	CheckResults []CheckResult `json:"checks"`
 	Error        string        `json:"error,omitempty"`
 	Regression   bool          `json:"regression,omitempty"`
+	FinishReason string        `json:"finish_reason,omitempty"`
+
+	// ContentFiltered is set when the provider blocked or refused the evaluated response.
+	ContentFiltered bool `json:"content_filtered,omitempty"`
+
+	// XFail is the reason the test is expected to fail, when it is marked xfail.
+	XFail string `json:"xfail,omitempty"`
+
+	// Stats is set when the test was evaluated across several sessions (run --runs).
+	Stats *RunStats `json:"stats,omitempty"`
+
+	// Latency is the latency of the evaluated trace in milliseconds, as recorded on the trace.
+	Latency time.Duration `json:"latency_ms,omitempty"`
+
+	// TTFT is the time to first token of a streamed trace, in milliseconds.
+	TTFT time.Duration `json:"ttft_ms,omitempty"`
+
+	// TokensIn and TokensOut are the token usage recorded on the evaluated trace.
+	TokensIn  int `json:"tokens_in,omitempty"`
+	TokensOut int `json:"tokens_out,omitempty"`
+
+	// CostUSD is the estimated cost of the evaluated trace (summed over dataset rows).
+	CostUSD float64 `json:"cost_usd,omitempty"`
+
+	// Retries is the number of times the proxy retried the evaluated call after a
+	// transient provider failure (summed over dataset rows).
+	Retries int `json:"retries,omitempty"`
+
+	// Score is the mean normalized score (0-1) of the test's rubric checks.
+	Score *float64 `json:"score,omitempty"`
+
+	// PromptFingerprint is the SHA-256 of the system prompt sent in the evaluated call.
+	PromptFingerprint string `json:"prompt_fingerprint,omitempty"`
+
+	// Output is the response text of the evaluated trace, after ignore rules. A baseline's
+	// outputs are the golden texts for the semantic_drift policy.
+	Output string `json:"output,omitempty"`
+
+	// Pairwise is the judge's comparison of Output with the baseline's output.
+	Pairwise *PairwiseVerdict `json:"pairwise,omitempty"`
 }
 
 // CheckResult represents a single check result.
@@ -124,20 +284,53 @@ type CheckResult struct {
 	Check   string `json:"check"`
 	Passed  bool   `json:"passed"`
 	Message string `json:"message,omitempty"`
+
+	// Score is the graded score of a rubric check, normalized to 0-1.
+	Score *float64 `json:"score,omitempty"`
+
+	// Skipped marks a check that could not run, such as similar_to without an
+	// embeddings provider. It neither passes nor fails the test.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// transient marks a result that could not be evaluated for reasons outside the
+	// trace (e.g. an unreachable embeddings provider), so it is never cached.
+	transient bool
+
+	// errored marks a check whose provider call failed, which makes the test an error
+	// rather than a failure.
+	errored bool
+}
+
+// Failed reports whether the check ran and did not pass.
+func (c CheckResult) Failed() bool {
+	return !c.Passed && !c.Skipped
 }
 
 // BaselineComparison represents comparison with baseline.
 type BaselineComparison struct {
-	BaselineDate    time.Time `json:"baseline_date"`
-	NewFailures     []string  `json:"new_failures,omitempty"`
-	NewPasses       []string  `json:"new_passes,omitempty"`
-	RemovedTests    []string  `json:"removed_tests,omitempty"`
-	AddedTests      []string  `json:"added_tests,omitempty"`
-	BehaviorChanges []string  `json:"behavior_changes,omitempty"`
+	BaselineDate    time.Time   `json:"baseline_date"`
+	Baseline        *Provenance `json:"baseline,omitempty"` // Where the baseline came from, if recorded
+	BaselineAgeDays float64     `json:"baseline_age_days,omitempty"`
+	NewFailures     []string    `json:"new_failures,omitempty"`
+	NewPasses       []string    `json:"new_passes,omitempty"`
+	RemovedTests    []string    `json:"removed_tests,omitempty"`
+	AddedTests      []string    `json:"added_tests,omitempty"`
+	BehaviorChanges []string    `json:"behavior_changes,omitempty"`
+
+	// PromptEdits lists the prompt files edited, added, or removed since the baseline,
+	// the likely cause of behavior that changed without a code change.
+	PromptEdits []string `json:"prompt_edits,omitempty"`
+
+	// Stale lists why the baseline is out of date under policies.baseline_staleness.
+	Stale []string `json:"stale,omitempty"`
+
+	// SuspectCommits lists commits since the baseline that touched the suite, its referenced files, or the config.
+	SuspectCommits []vcs.Commit `json:"suspect_commits,omitempty"`
 }
 
-// LoadSuite loads a test suite from a YAML file.
-func LoadSuite(path string) (*TestSuite, error) {
+// ReadSuite reads a test suite file as written, without applying directory defaults,
+// vars, or assertions, so it can be edited and saved back with SaveSuite.
+func ReadSuite(path string) (*TestSuite, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("could not read test suite: %w", err)
@@ -147,10 +340,56 @@ func LoadSuite(path string) (*TestSuite, error) {
 	if err := yaml.Unmarshal(data, &suite); err != nil {
 		return nil, fmt.Errorf("could not parse test suite: %w", err)
 	}
-
 	return &suite, nil
 }
 
+// LoadSuite loads a test suite from a YAML file for evaluation, applying the
+// _defaults.yml files of its directories (see TestDefaults).
+func LoadSuite(path string) (*TestSuite, error) {
+	suite, err := ReadSuite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, defaultsFiles, err := loadDefaults(path)
+	if err != nil {
+		return nil, err
+	}
+	suite.defaultsFiles = defaultsFiles
+	if suite.Owner == "" {
+		suite.Owner = defaults.Owner
+	}
+	suite.Vars = mergeVars(defaults.Vars, suite.Vars)
+	for i := range suite.Tests {
+		defaults.apply(&suite.Tests[i])
+	}
+
+	for i, test := range suite.Tests {
+		suite.Tests[i].Vars = mergeVars(suite.Vars, test.Vars)
+		if test.Name == SessionTestName && len(suite.SessionChecks) > 0 {
+			return nil, fmt.Errorf("test name %q is reserved for session checks", SessionTestName)
+		}
+		if test.Assert != nil && test.Assert.Tools != nil {
+			checks, err := test.Assert.Tools.checks()
+			if err != nil {
+				return nil, fmt.Errorf("test %s has invalid assert.tools: %w", test.Name, err)
+			}
+			suite.Tests[i].Checks = append(test.Checks, checks...)
+		}
+		if err := suite.Tests[i].Dataset.load(); err != nil {
+			return nil, fmt.Errorf("test %s: %w", test.Name, err)
+		}
+		if !ValidState(test.State) {
+			return nil, fmt.Errorf("test %s has invalid state %q (must be one of: active, draft, deprecated)", test.Name, test.State)
+		}
+		if !ValidSeverity(test.Severity) {
+			return nil, fmt.Errorf("test %s has invalid severity %q (must be one of: low, medium, high, critical)", test.Name, test.Severity)
+		}
+	}
+
+	return suite, nil
+}
+
 // RunTest executes a single test case against a trace.
 func RunTest(test TestCase, tr *trace.LLMTrace) TestResult {
 	startTime := time.Now()
@@ -159,31 +398,102 @@ func RunTest(test TestCase, tr *trace.LLMTrace) TestResult {
 		Name:         test.Name,
 		Status:       "passed",
 		CheckResults: make([]CheckResult, 0, len(test.Checks)),
+		FinishReason: tr.FinishReason,
+		Latency:      tr.Latency,
+		Model:        tr.Model,
+		TokensIn:     tr.TokensIn,
+		TokensOut:    tr.TokensOut,
+		CostUSD:      tr.CostUSD,
+		Retries:      tr.Retries,
+
+		ContentFiltered: tr.ContentFiltered,
+	}
+	if system := systemPrompt(tr); system != "" {
+		result.PromptFingerprint = PromptFingerprint(system)
+	}
+	if tr.Stream != nil {
+		result.TTFT = tr.Stream.TTFT
+	}
+
+	var patterns []*regexp.Regexp
+	if len(test.Ignore) > 0 {
+		normalized, compiled, err := applyIgnoreRules(tr, test.Ignore)
+		if err != nil {
+			result.Status = "error"
+			result.Error = err.Error()
+			return result
+		}
+		tr, patterns = normalized, compiled
+	}
+
+	// Toggling redaction shouldn't look like a behavioral change, so when placeholders
+	// are involved both sides are compared in their redacted form.
+	redacted := needsRedactionNormalization(tr, test.Checks)
+	if redacted {
+		tr = normalizeRedactions(tr)
+	}
+
+	cache := activeCheckCache
+	var outputHash string
+	if cache != nil {
+		outputHash = OutputHash(tr)
 	}
 
 	// Run each check against the trace
 	for _, check := range test.Checks {
-		checkResult := RunCheck(check.Raw, tr)
+		raw := check.Raw
+		if len(patterns) > 0 {
+			raw = normalizeCheck(raw, patterns)
+		}
+		if redacted {
+			raw = normalizeRedactedCheck(raw)
+		}
+		var checkResult CheckResult
+		if cache != nil {
+			checkResult = cache.run(raw, outputHash, tr)
+		} else {
+			checkResult = RunCheck(raw, tr)
+		}
 		result.CheckResults = append(result.CheckResults, checkResult)
 
-		if !checkResult.Passed {
+		switch {
+		case checkResult.errored:
+			if result.Status != "error" {
+				result.Status = "error"
+				result.Error = checkResult.Message
+			}
+		case checkResult.Failed() && result.Status == "passed":
 			result.Status = "failed"
 		}
 	}
+	if result.Status == "passed" && allSkipped(result.CheckResults) {
+		// Nothing was evaluated, e.g. only similar_to checks under --offline
+		result.Status = "skipped"
+		result.Error = result.CheckResults[0].Message
+	}
 
+	result.Score = meanScore(result.CheckResults)
+	result.Output = extractResponseText(tr)
 	result.Duration = time.Since(startTime) / time.Millisecond
 
 	return result
 }
 
+// allSkipped reports whether there are checks and none of them ran.
+func allSkipped(checks []CheckResult) bool {
+	for _, cr := range checks {
+		if !cr.Skipped {
+			return false
+		}
+	}
+	return len(checks) > 0
+}
+
 // LoadLatestSession loads the most recent trace session from the traces directory.
 func LoadLatestSession() (*trace.TraceSession, error) {
-	traceDir := filepath.Join(".regrada", "traces")
-
-	// Find the most recent trace file
-	files, err := filepath.Glob(filepath.Join(traceDir, "*.json"))
-	if err != nil || len(files) == 0 {
-		return nil, fmt.Errorf("no trace files found in %s", traceDir)
+	files, err := sessionFiles()
+	if err != nil {
+		return nil, err
 	}
 
 	// Sort by modification time to get the latest
@@ -205,21 +515,78 @@ func LoadLatestSession() (*trace.TraceSession, error) {
 	}
 
 	// Load the trace session
-	data, err := os.ReadFile(latestFile)
+	session, err := trace.Load(latestFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read trace file: %w", err)
-	}
-
-	var session trace.TraceSession
-	if err := json.Unmarshal(data, &session); err != nil {
-		return nil, fmt.Errorf("failed to parse trace file: %w", err)
+		return nil, fmt.Errorf("failed to load trace file: %w", err)
 	}
 
 	if len(session.Traces) == 0 {
 		return nil, fmt.Errorf("no traces found in session")
 	}
 
-	return &session, nil
+	return session, nil
+}
+
+// LoadLatestSessions loads the n most recent trace sessions, oldest first.
+func LoadLatestSessions(n int) ([]*trace.TraceSession, error) {
+	sessions, err := LoadAllSessions()
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no readable trace sessions found")
+	}
+	if n > 0 && len(sessions) > n {
+		sessions = sessions[len(sessions)-n:]
+	}
+	return sessions, nil
+}
+
+// LoadAllSessions loads every stored trace session, oldest first.
+// Files that cannot be parsed are skipped.
+func LoadAllSessions() ([]*trace.TraceSession, error) {
+	files, err := sessionFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*trace.TraceSession, 0, len(files))
+	for _, file := range files {
+		session, err := trace.Load(file)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+
+	return sessions, nil
+}
+
+// sessionFiles lists the trace session files in .regrada/traces, compressed or not.
+// A session left at trace.LegacyPath by an earlier release is moved there first.
+func sessionFiles() ([]string, error) {
+	traceDir := filepath.Join(".regrada", "traces")
+
+	if converted, err := trace.ConvertLegacy(traceDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else if converted != "" {
+		fmt.Fprintf(os.Stderr, "Converted legacy trace file %s to %s\n", trace.LegacyPath, converted)
+	}
+
+	files, err := filepath.Glob(filepath.Join(traceDir, "*.json"))
+	if err == nil {
+		compressed, _ := filepath.Glob(filepath.Join(traceDir, "*.json"+trace.CompressedExt))
+		files = append(files, compressed...)
+	}
+	if err != nil || len(files) == 0 {
+		return nil, fmt.Errorf("no trace files found in %s", traceDir)
+	}
+
+	return files, nil
 }
 
 // GetTraceForTest retrieves the appropriate trace for a test case from a session.
@@ -234,6 +601,10 @@ func GetTraceForTest(test TestCase, session *trace.TraceSession) (*trace.LLMTrac
 		return nil, fmt.Errorf("trace with ID %s not found in session", test.TraceID)
 	}
 
+	if sel := testSelector(test); sel != nil {
+		return sel.selectTrace(session)
+	}
+
 	// Otherwise use TraceIndex
 	if test.TraceIndex < 0 || test.TraceIndex >= len(session.Traces) {
 		return nil, fmt.Errorf("trace_index %d out of range (session has %d traces)", test.TraceIndex, len(session.Traces))
@@ -242,25 +613,60 @@ func GetTraceForTest(test TestCase, session *trace.TraceSession) (*trace.LLMTrac
 	return &session.Traces[test.TraceIndex], nil
 }
 
+// MissingTraces lists the tests that have no recorded trace in at least one of the
+// sessions, as "name: reason". Deprecated and skipped tests are never run, so they are
+// left out.
+func MissingTraces(suite *TestSuite, sessions []*trace.TraceSession) []string {
+	var missing []string
+	for _, test := range suite.Tests {
+		if test.State == StateDeprecated || test.Skip != "" {
+			continue
+		}
+
+		rows := []TestCase{withVars(test, test.Vars)}
+		if expanded := datasetRows(test); expanded != nil {
+			rows = rows[:0]
+			for _, row := range expanded {
+				rows = append(rows, row.test)
+			}
+		}
+
+	sessionLoop:
+		for _, session := range sessions {
+			for _, row := range rows {
+				if _, err := GetTraceForTest(row, session); err != nil {
+					missing = append(missing, fmt.Sprintf("%s: %v", test.Name, err))
+					break sessionLoop
+				}
+			}
+		}
+	}
+	return missing
+}
+
 // CompareWithBaseline compares current results with a baseline file.
 func CompareWithBaseline(current *EvalResult, baselinePath string) (*BaselineComparison, error) {
-	data, err := os.ReadFile(baselinePath)
+	baseline, err := LoadResults(baselinePath)
 	if err != nil {
 		return nil, err
 	}
+	return CompareResults(current, baseline), nil
+}
 
-	var baseline EvalResult
-	if err := json.Unmarshal(data, &baseline); err != nil {
-		return nil, err
-	}
-
+// CompareResults compares current results with baseline results, such as a rolling
+// baseline built by RollingBaseline.
+func CompareResults(current, baseline *EvalResult) *BaselineComparison {
 	comparison := &BaselineComparison{
 		BaselineDate: baseline.Timestamp,
+		Baseline:     baseline.Provenance,
 		NewFailures:  []string{},
 		NewPasses:    []string{},
 		RemovedTests: []string{},
 		AddedTests:   []string{},
 	}
+	if !baseline.Timestamp.IsZero() && current.Timestamp.After(baseline.Timestamp) {
+		comparison.BaselineAgeDays = current.Timestamp.Sub(baseline.Timestamp).Hours() / 24
+	}
 
 	// Build maps for easy lookup
 	baselineTests := make(map[string]TestResult)
@@ -273,8 +679,14 @@ func CompareWithBaseline(current *EvalResult, baselinePath string) (*BaselineCom
 		currentTests[tr.Name] = tr
 	}
 
-	// Find new failures and new passes
-	for name, currentTest := range currentTests {
+	// Drafts, xfail tests, and skipped tests never count as regressions or fixes
+	gated := func(tr TestResult) bool {
+		return tr.State != StateDraft && tr.XFail == "" && tr.Status != "skipped"
+	}
+
+	// Find new failures and new passes, in suite order so artifacts are stable across runs
+	for _, currentTest := range current.TestResults {
+		name := currentTest.Name
 		baselineTest, existsInBaseline := baselineTests[name]
 
 		if !existsInBaseline {
@@ -282,6 +694,24 @@ func CompareWithBaseline(current *EvalResult, baselinePath string) (*BaselineCom
 			continue
 		}
 
+		if !gated(currentTest) {
+			continue
+		}
+
+		// With enough runs on both sides, only statistically significant shifts count
+		if statistical(baselineTest, currentTest) {
+			switch passRateShift(baselineTest.Stats, currentTest.Stats) {
+			case -1:
+				comparison.NewFailures = append(comparison.NewFailures, name)
+			case 1:
+				comparison.NewPasses = append(comparison.NewPasses, name)
+			}
+			if change := latencyIncrease(name, baselineTest.Stats, currentTest.Stats); change != "" {
+				comparison.BehaviorChanges = append(comparison.BehaviorChanges, change)
+			}
+			continue
+		}
+
 		// Check for regressions (new failures)
 		if baselineTest.Status == "passed" && currentTest.Status == "failed" {
 			comparison.NewFailures = append(comparison.NewFailures, name)
@@ -293,14 +723,104 @@ func CompareWithBaseline(current *EvalResult, baselinePath string) (*BaselineCom
 		}
 	}
 
+	// Baselines saved without a hash predate prompt tracking, so only a known prompt can change
+	if baseline.PromptSHA256 != "" && baseline.PromptSHA256 != current.PromptSHA256 {
+		comparison.BehaviorChanges = append(comparison.BehaviorChanges,
+			fmt.Sprintf("system prompt changed since the baseline (%s -> %s)", shortHash(baseline.PromptSHA256), shortHash(current.PromptSHA256)))
+		comparison.PromptEdits = PromptEdits(baseline.PromptFiles, current.PromptFiles)
+	}
+
 	// Find removed tests
-	for name := range baselineTests {
-		if _, exists := currentTests[name]; !exists {
-			comparison.RemovedTests = append(comparison.RemovedTests, name)
+	for _, tr := range baseline.TestResults {
+		if _, exists := currentTests[tr.Name]; !exists {
+			comparison.RemovedTests = append(comparison.RemovedTests, tr.Name)
+		}
+	}
+
+	return comparison
+}
+
+// shortHash abbreviates a prompt hash for display.
+func shortHash(sum string) string {
+	if sum == "" {
+		return "none"
+	}
+	if len(sum) > 12 {
+		return sum[:12]
+	}
+	return sum
+}
+
+// ReferencedFiles returns the files a suite depends on beyond the suite file itself,
+// such as JSON schemas referenced by schema_valid and tool_args_schema checks, and the
+// prompt files in cfg with their includes.
+func ReferencedFiles(cfg *config.RegradaConfig, suite *TestSuite) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, path := range suite.defaultsFiles {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	prompts := cfg.Policies.PromptDrift.Files
+	if cfg.Provider.SystemPromptFile != "" {
+		prompts = append([]string{cfg.Provider.SystemPromptFile}, prompts...)
+	}
+	for _, prompt := range prompts {
+		// A prompt that can't be resolved still counts, so edits that broke it are suspects
+		paths := []string{prompt}
+		if _, included, err := config.LoadPrompt(prompt); err == nil {
+			paths = included
+		}
+		for _, path := range paths {
+			if !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
+		}
+	}
+	for _, test := range suite.Tests {
+		if path := test.Dataset.File; path != "" && !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+		for _, check := range test.Checks {
+			idx := strings.Index(check.Raw, ":")
+			if idx <= 0 {
+				continue
+			}
+			var path string
+			switch strings.TrimSpace(check.Raw[:idx]) {
+			case "schema_valid", "attachment_matches":
+				path = strings.TrimSpace(check.Raw[idx+1:])
+			case "tool_args_schema":
+				var spec argsSchemaSpec
+				if json.Unmarshal([]byte(check.Raw[idx+1:]), &spec) == nil {
+					path = spec.Schema
+				}
+			}
+			if path != "" && !seen[path] {
+				seen[path] = true
+				files = append(files, path)
+			}
 		}
 	}
+	return files
+}
 
-	return comparison, nil
+// LoadResults loads evaluation results saved by SaveResults, such as a baseline.
+func LoadResults(path string) (*EvalResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result EvalResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
 
 // SaveResults saves evaluation results to a file.
@@ -335,7 +855,8 @@ func SaveSuite(suite *TestSuite, path string) error {
 	return os.WriteFile(path, data, 0644)
 }
 
-// GenerateTestStubs creates test cases from a trace session.
+// GenerateTestStubs creates test cases from a trace session. Stubs for traces that
+// called tools start with a tool_called check per tool, in call order.
 func GenerateTestStubs(session *trace.TraceSession) *TestSuite {
 	suite := &TestSuite{
 		Name:        fmt.Sprintf("Test Suite - %s", session.ID),
@@ -349,7 +870,7 @@ func GenerateTestStubs(session *trace.TraceSession) *TestSuite {
 			Name:        testName,
 			TraceIndex:  i,
 			Description: "",
-			Checks:      []Check{},
+			Checks:      toolCalledChecks(&tr),
 		}
 		suite.Tests = append(suite.Tests, test)
 	}
@@ -357,6 +878,20 @@ func GenerateTestStubs(session *trace.TraceSession) *TestSuite {
 	return suite
 }
 
+// toolCalledChecks returns a tool_called check for each distinct tool called in a trace.
+func toolCalledChecks(tr *trace.LLMTrace) []Check {
+	checks := []Check{}
+	seen := make(map[string]bool, len(tr.ToolCalls))
+	for _, tc := range tr.ToolCalls {
+		if tc.Name == "" || seen[tc.Name] {
+			continue
+		}
+		seen[tc.Name] = true
+		checks = append(checks, Check{Raw: "tool_called:" + tc.Name})
+	}
+	return checks
+}
+
 // generateTestName creates a descriptive name for a test based on its trace.
 func generateTestName(index int, tr *trace.LLMTrace) string {
 	// Extract meaningful parts from the trace
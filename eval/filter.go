@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FilterTags keeps the tests that carry at least one of the include tags (every test when
+// include is empty) and none of the exclude tags, and returns the names of the tests it
+// removed. Session checks are kept.
+func (s *TestSuite) FilterTags(include, exclude []string) []string {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+
+	var removed []string
+	kept := s.Tests[:0]
+	for _, test := range s.Tests {
+		if MatchesTags(test.Tags, include, exclude) {
+			kept = append(kept, test)
+		} else {
+			removed = append(removed, test.Name)
+		}
+	}
+	s.Tests = kept
+	return removed
+}
+
+// MatchesTags reports whether tags include one of include (or include is empty) and
+// none of exclude.
+func MatchesTags(tags, include, exclude []string) bool {
+	for _, tag := range exclude {
+		if containsTag(tags, tag) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, tag := range include {
+		if containsTag(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterNames keeps the tests whose name or select case matches pattern, and returns the
+// names of the tests it removed. A pattern with *, ?, or [ and no other regex syntax is a
+// glob matched against the whole name; any other pattern is a regular expression that may
+// match anywhere in it. Session checks are kept.
+func (s *TestSuite) FilterNames(pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	match, err := namePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []string
+	kept := s.Tests[:0]
+	for _, test := range s.Tests {
+		if match(test.Name) || (test.Select != nil && test.Select.Case != "" && match(test.Select.Case)) {
+			kept = append(kept, test)
+		} else {
+			removed = append(removed, test.Name)
+		}
+	}
+	s.Tests = kept
+	return removed, nil
+}
+
+// namePattern compiles a --filter pattern into a matcher.
+func namePattern(pattern string) (func(string) bool, error) {
+	if strings.ContainsAny(pattern, "*?[") && !strings.ContainsAny(pattern, `^$+(){}|\`) && !strings.Contains(pattern, ".*") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %w", pattern, err)
+		}
+		return func(name string) bool {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		}, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", pattern, err)
+	}
+	return re.MatchString, nil
+}
+
+// KeepTests keeps only the named tests and returns the names of the tests it removed.
+// Session checks are kept.
+func (s *TestSuite) KeepTests(names []string) []string {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	var removed []string
+	kept := s.Tests[:0]
+	for _, test := range s.Tests {
+		if keep[test.Name] {
+			kept = append(kept, test)
+		} else {
+			removed = append(removed, test.Name)
+		}
+	}
+	s.Tests = kept
+	return removed
+}
+
+// FailedTests returns the names of the tests that failed, including policy violations,
+// or errored in a run. The session checks' pseudo-test is left out, as it always runs.
+func (r *EvalResult) FailedTests() []string {
+	var names []string
+	for _, tr := range r.TestResults {
+		if (tr.Status == "failed" || tr.Status == "error") && tr.Name != SessionTestName {
+			names = append(names, tr.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,214 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyLayout names saved results so that they sort chronologically.
+const historyLayout = "20060102T150405.000000000"
+
+// historyIndex is the file in a history directory that lists every run's summary.
+const historyIndex = "index.jsonl"
+
+// RunSummary is the entry a run leaves in the history index, kept after the run's full
+// results are pruned.
+type RunSummary struct {
+	ID          string        `json:"id"`
+	Timestamp   time.Time     `json:"timestamp"`
+	Status      string        `json:"status"`
+	TestSuite   string        `json:"test_suite,omitempty"`
+	GitSHA      string        `json:"git_sha,omitempty"`
+	GitBranch   string        `json:"git_branch,omitempty"`
+	Model       string        `json:"model,omitempty"`
+	TotalTests  int           `json:"total_tests"`
+	Passed      int           `json:"passed"`
+	Failed      int           `json:"failed"`
+	Regressions int           `json:"regressions"`
+	Skipped     int           `json:"skipped,omitempty"`
+	PassRate    float64       `json:"pass_rate"` // Of gated tests
+	Quality     *float64      `json:"quality_score,omitempty"`
+	LatencyMean time.Duration `json:"latency_mean_ms,omitempty"`
+	LatencyP95  time.Duration `json:"latency_p95_ms,omitempty"`
+	TokensOut   int           `json:"tokens_out,omitempty"`
+	CostUSD     float64       `json:"cost_usd,omitempty"`
+	Filtered    bool          `json:"filtered,omitempty"` // Only part of the suite ran
+}
+
+// HistoryID is the ID a result is saved under in the history.
+func HistoryID(result *EvalResult) string {
+	return result.Timestamp.UTC().Format(historyLayout)
+}
+
+// Summarize returns the history entry of a result.
+func Summarize(result *EvalResult) RunSummary {
+	summary := RunSummary{
+		ID:          HistoryID(result),
+		Timestamp:   result.Timestamp,
+		Status:      result.Status,
+		TestSuite:   result.TestSuite,
+		TotalTests:  result.TotalTests,
+		Passed:      result.Passed,
+		Failed:      result.Failed,
+		Regressions: result.Regressions,
+		Skipped:     result.Skipped,
+		CostUSD:     result.CostUSD,
+		Filtered:    result.Filtered,
+	}
+	if p := result.Provenance; p != nil {
+		summary.GitSHA, summary.GitBranch, summary.Model = p.GitSHA, p.GitBranch, p.Model
+	}
+	if gated := result.Passed + result.Failed; gated > 0 {
+		summary.PassRate = float64(result.Passed) / float64(gated)
+	}
+	if result.Quality != nil {
+		score := result.Quality.Score
+		summary.Quality = &score
+	}
+
+	var latencies []time.Duration
+	var total time.Duration
+	for _, tr := range result.TestResults {
+		summary.TokensOut += tr.TokensOut
+		if tr.Latency > 0 {
+			latencies = append(latencies, tr.Latency)
+			total += tr.Latency
+		}
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		summary.LatencyMean = total / time.Duration(len(latencies))
+		rank := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+		summary.LatencyP95 = latencies[max(rank, 0)]
+	}
+	return summary
+}
+
+// SaveHistory records a run in dir: its summary is appended to the index and its full
+// results are saved, removing the oldest saved results beyond keep.
+func SaveHistory(result *EvalResult, dir string, keep int) error {
+	name := "results-" + HistoryID(result) + ".json"
+	if err := SaveResults(result, filepath.Join(dir, name)); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(Summarize(result))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, historyIndex), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	files, err := historyFiles(dir)
+	if err != nil {
+		return err
+	}
+	for len(files) > keep {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+// ReadHistory returns the summaries of the runs recorded in dir, oldest first.
+// Malformed lines are skipped.
+func ReadHistory(dir string) ([]RunSummary, error) {
+	f, err := os.Open(filepath.Join(dir, historyIndex))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []RunSummary
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var run RunSummary
+		if json.Unmarshal(scanner.Bytes(), &run) == nil && run.ID != "" {
+			runs = append(runs, run)
+		}
+	}
+	return runs, scanner.Err()
+}
+
+// FindRun returns the recorded run with the given ID, or the only one whose ID starts
+// with it, and its full results when they are still kept in dir.
+func FindRun(dir, prefix string) (*RunSummary, *EvalResult, error) {
+	runs, err := ReadHistory(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var matches []RunSummary
+	for _, run := range runs {
+		if run.ID == prefix {
+			matches = []RunSummary{run}
+			break
+		}
+		if strings.HasPrefix(run.ID, prefix) {
+			matches = append(matches, run)
+		}
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, nil, fmt.Errorf("no run %q in the history", prefix)
+	case len(matches) > 1:
+		return nil, nil, fmt.Errorf("run ID %q is ambiguous (%d runs match)", prefix, len(matches))
+	}
+
+	run := matches[0]
+	result, err := LoadResults(filepath.Join(dir, "results-"+run.ID+".json"))
+	if err != nil {
+		return &run, nil, nil
+	}
+	return &run, result, nil
+}
+
+// LoadHistory loads the n most recent results of the named suite saved in dir by
+// SaveHistory, oldest first. Unreadable files, other suites' runs, and filtered runs,
+// which cover only part of the suite, are skipped.
+func LoadHistory(dir, suite string, n int) ([]*EvalResult, error) {
+	files, err := historyFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*EvalResult
+	for i := len(files) - 1; i >= 0 && len(results) < n; i-- {
+		if result, err := LoadResults(files[i]); err == nil && result.TestSuite == suite && !result.Filtered {
+			results = append([]*EvalResult{result}, results...)
+		}
+	}
+	return results, nil
+}
+
+func historyFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "results-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
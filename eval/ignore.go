@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// ignoredPlaceholder replaces text spans matched by an ignore pattern.
+const ignoredPlaceholder = "<ignored>"
+
+// IgnoreRule declares an accepted per-run difference that is normalized away before checks run.
+// Exactly one of Pattern or Path should be set.
+type IgnoreRule struct {
+	// Pattern is a regular expression; matching spans in response text are replaced with a placeholder.
+	Pattern string `yaml:"pattern,omitempty"`
+	// Path is a dot-separated JSON path (e.g. "id" or "choices.0.message.refusal") removed from the response body.
+	Path string `yaml:"path,omitempty"`
+}
+
+// applyIgnoreRules returns a copy of the trace whose response body has ignored paths removed
+// and ignored text spans replaced, along with the compiled patterns for normalizing expectations.
+func applyIgnoreRules(tr *trace.LLMTrace, rules []IgnoreRule) (*trace.LLMTrace, []*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid ignore pattern %q: %w", rule.Pattern, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(tr.Response.Body, &body); err != nil {
+		// Non-JSON body: only text patterns apply
+		normalized := *tr
+		text := normalizeText(string(tr.Response.Body), patterns)
+		quoted, _ := json.Marshal(text)
+		normalized.Response.Body = quoted
+		return &normalized, patterns, nil
+	}
+
+	for _, rule := range rules {
+		if rule.Path != "" {
+			body = deletePath(body, strings.Split(strings.TrimPrefix(rule.Path, "$."), "."))
+		}
+	}
+	body = normalizeValue(body, patterns)
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	normalized := *tr
+	normalized.Response.Body = data
+	return &normalized, patterns, nil
+}
+
+// normalizeText replaces every span matched by the patterns with a placeholder.
+func normalizeText(text string, patterns []*regexp.Regexp) string {
+	for _, re := range patterns {
+		text = re.ReplaceAllString(text, ignoredPlaceholder)
+	}
+	return text
+}
+
+// normalizeCheck applies ignore patterns to the expected text of text-comparison checks,
+// so expectations and responses are normalized the same way.
+func normalizeCheck(raw string, patterns []*regexp.Regexp) string {
+	idx := strings.Index(raw, ":")
+	if idx <= 0 {
+		return raw
+	}
+
+	switch strings.TrimSpace(raw[:idx]) {
+	case "exact", "contains", "not_contains", "contains_any":
+		return raw[:idx+1] + normalizeText(raw[idx+1:], patterns)
+	default:
+		return raw
+	}
+}
+
+// normalizeValue applies normalizeText to every string within a decoded JSON value.
+func normalizeValue(v interface{}, patterns []*regexp.Regexp) interface{} {
+	if len(patterns) == 0 {
+		return v
+	}
+
+	switch val := v.(type) {
+	case string:
+		return normalizeText(val, patterns)
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = normalizeValue(child, patterns)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = normalizeValue(child, patterns)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// deletePath removes the value at the given path segments from a decoded JSON value.
+func deletePath(v interface{}, segments []string) interface{} {
+	if len(segments) == 0 {
+		return v
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(segments) == 1 {
+			delete(val, segments[0])
+		} else if child, ok := val[segments[0]]; ok {
+			val[segments[0]] = deletePath(child, segments[1:])
+		}
+		return val
+	case []interface{}:
+		idx, err := strconv.Atoi(segments[0])
+		if err != nil || idx < 0 || idx >= len(val) {
+			return val
+		}
+		if len(segments) == 1 {
+			return append(val[:idx], val[idx+1:]...)
+		}
+		val[idx] = deletePath(val[idx], segments[1:])
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// loadJSONSchema reads and parses a JSON Schema file.
+func loadJSONSchema(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema file: %w", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return schema, nil
+}
+
+// validateJSONSchema validates a decoded JSON value against the structural subset of
+// JSON Schema that tool parameter schemas use: type (a name or a list), enum, required,
+// properties, additionalProperties: false, and items, applied recursively. It returns
+// the first violation, naming its location as a dotted path from where.
+func validateJSONSchema(value interface{}, schema map[string]interface{}, where string) error {
+	switch t := schema["type"].(type) {
+	case string:
+		if !matchesJSONType(value, t) {
+			return fmt.Errorf("%s has type '%s', expected '%s'", where, getJSONType(value), t)
+		}
+	case []interface{}:
+		var names []string
+		matched := false
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+				matched = matched || matchesJSONType(value, s)
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s has type '%s', expected one of %s", where, getJSONType(value), strings.Join(names, ", "))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			encoded, _ := json.Marshal(value)
+			return fmt.Errorf("%s is %s, which is not one of the allowed values", where, encoded)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, field := range required {
+				name, _ := field.(string)
+				if _, exists := v[name]; !exists {
+					return fmt.Errorf("required field '%s' missing from %s", name, where)
+				}
+			}
+		}
+
+		properties, _ := schema["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			propSchema, ok := properties[key].(map[string]interface{})
+			if !ok {
+				if additional, isBool := schema["additionalProperties"].(bool); isBool && !additional {
+					return fmt.Errorf("%s has unexpected field '%s'", where, key)
+				}
+				continue
+			}
+			if err := validateJSONSchema(v[key], propSchema, where+"."+key); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateJSONSchema(item, items, fmt.Sprintf("%s[%d]", where, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesJSONType reports whether value has the JSON Schema type t. Integers are
+// numbers without a fractional part.
+func matchesJSONType(value interface{}, t string) bool {
+	if t == "integer" {
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	}
+	return getJSONType(value) == t
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// Judge asks a model to grade a response. Complete returns the model's reply to the
+// system and user prompts.
+type Judge interface {
+	Complete(system, user string) (string, error)
+}
+
+// activeJudge grades rubric checks when set with UseJudge.
+var activeJudge Judge
+
+// UseJudge makes rubric checks use j. Pass nil to disable them.
+func UseJudge(j Judge) {
+	activeJudge = j
+}
+
+// httpJudge calls an OpenAI-style /chat/completions endpoint or the Anthropic Messages API.
+type httpJudge struct {
+	provider string
+	model    string
+	baseURL  string
+	apiKey   string
+	client   *http.Client
+	limiter  *rateLimiter
+}
+
+// NewJudge creates a judge for the configured provider: openai (default, also any
+// OpenAI-compatible endpoint via base_url, such as Ollama's /v1) or anthropic.
+func NewJudge(cfg config.JudgeConfig) (Judge, error) {
+	j := &httpJudge{
+		provider: cfg.Provider,
+		model:    cfg.Model,
+		baseURL:  strings.TrimSuffix(cfg.BaseURL, "/"),
+		client:   &http.Client{Timeout: 120 * time.Second},
+		limiter:  newRateLimiter(cfg.RateLimit),
+	}
+
+	keyEnv := cfg.APIKeyEnv
+	switch j.provider {
+	case "", "openai":
+		j.provider = "openai"
+		if j.model == "" {
+			j.model = "gpt-4o-mini"
+		}
+		if j.baseURL == "" {
+			j.baseURL = "https://api.openai.com/v1"
+		}
+		if keyEnv == "" {
+			keyEnv = "OPENAI_API_KEY"
+		}
+	case "anthropic":
+		if j.model == "" {
+			j.model = "claude-3-5-haiku-latest"
+		}
+		if j.baseURL == "" {
+			j.baseURL = "https://api.anthropic.com"
+		}
+		if keyEnv == "" {
+			keyEnv = "ANTHROPIC_API_KEY"
+		}
+	default:
+		return nil, fmt.Errorf("invalid judge provider: %s (must be openai or anthropic)", cfg.Provider)
+	}
+	j.apiKey = os.Getenv(keyEnv)
+
+	return j, nil
+}
+
+// Complete sends one deterministic (temperature 0) request and returns the reply text.
+func (j *httpJudge) Complete(system, user string) (string, error) {
+	var url string
+	var payload map[string]interface{}
+	if j.provider == "anthropic" {
+		url = j.baseURL + "/v1/messages"
+		payload = map[string]interface{}{
+			"model":       j.model,
+			"max_tokens":  1024,
+			"temperature": 0,
+			"system":      system,
+			"messages":    []map[string]string{{"role": "user", "content": user}},
+		}
+	} else {
+		url = j.baseURL + "/chat/completions"
+		payload = map[string]interface{}{
+			"model":       j.model,
+			"temperature": 0,
+			"messages": []map[string]string{
+				{"role": "system", "content": system},
+				{"role": "user", "content": user},
+			},
+		}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if j.provider == "anthropic" {
+		req.Header.Set("x-api-key", j.apiKey)
+		req.Header.Set("anthropic-version", "2023-06-01")
+	} else if j.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+j.apiKey)
+	}
+
+	j.limiter.wait(estimateTokens(system, user))
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("judge request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("judge returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var parsed struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return "", fmt.Errorf("invalid judge response: %w", err)
+	}
+	if len(parsed.Choices) > 0 {
+		return parsed.Choices[0].Message.Content, nil
+	}
+	var text strings.Builder
+	for _, c := range parsed.Content {
+		if c.Type == "text" {
+			text.WriteString(c.Text)
+		}
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,259 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxJUnitOutput caps the output written to a test case's <system-out>.
+const maxJUnitOutput = 4000
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	Classname  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Failure    *junitMessage   `xml:"failure,omitempty"`
+	Error      *junitMessage   `xml:"error,omitempty"`
+	Skipped    *junitMessage   `xml:"skipped,omitempty"`
+	SystemOut  string          `xml:"system-out,omitempty"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the result as a JUnit XML report for CI test UIs. Failed checks
+// and policy violations become the failure details, each test's output goes to its
+// <system-out>, and tokens, cost, latency, and pass rates are recorded as properties.
+// Drafts and expected failures are reported as skipped so they don't fail the build.
+func WriteJUnit(result *EvalResult, path string) error {
+	suite := junitTestSuite{
+		Name:      result.TestSuite,
+		Timestamp: result.Timestamp.UTC().Format("2006-01-02T15:04:05"),
+	}
+	if suite.Name == "" {
+		suite.Name = "regrada"
+	}
+
+	var total time.Duration
+	var tokensIn, tokensOut int
+	for _, tr := range result.TestResults {
+		tc := junitCase(suite.Name, tr)
+		switch {
+		case tc.Failure != nil:
+			suite.Failures++
+		case tc.Error != nil:
+			suite.Errors++
+		case tc.Skipped != nil:
+			suite.Skipped++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		total += junitDuration(tr)
+		tokensIn += tr.TokensIn
+		tokensOut += tr.TokensOut
+	}
+	suite.Tests = len(suite.Cases)
+	suite.Time = junitSeconds(total)
+
+	suite.Properties = []junitProperty{
+		{"status", result.Status},
+		{"regressions", strconv.Itoa(result.Regressions)},
+		{"tokens_in", strconv.Itoa(tokensIn)},
+		{"tokens_out", strconv.Itoa(tokensOut)},
+		{"cost_usd", strconv.FormatFloat(result.CostUSD, 'f', 6, 64)},
+	}
+	if gated := result.Passed + result.Failed; gated > 0 {
+		rate := float64(result.Passed) / float64(gated)
+		suite.Properties = append(suite.Properties, junitProperty{"pass_rate", strconv.FormatFloat(rate, 'f', 4, 64)})
+	}
+	if q := result.Quality; q != nil {
+		suite.Properties = append(suite.Properties, junitProperty{"quality_score", strconv.FormatFloat(q.Score, 'f', 1, 64)})
+	}
+	if p := result.Provenance; p != nil {
+		if p.GitSHA != "" {
+			suite.Properties = append(suite.Properties, junitProperty{"git_sha", p.GitSHA})
+		}
+		if p.Model != "" {
+			suite.Properties = append(suite.Properties, junitProperty{"model", p.Model})
+		}
+	}
+
+	report := junitTestSuites{
+		Name:     "regrada",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+// junitCase converts a test result to a JUnit test case.
+func junitCase(classname string, tr TestResult) junitTestCase {
+	tc := junitTestCase{
+		Name:      tr.Name,
+		Classname: classname,
+		Time:      junitSeconds(junitDuration(tr)),
+		SystemOut: truncateOutput(tr.Output, maxJUnitOutput),
+	}
+
+	props := []junitProperty{
+		{"tokens_in", strconv.Itoa(tr.TokensIn)},
+		{"tokens_out", strconv.Itoa(tr.TokensOut)},
+		{"cost_usd", strconv.FormatFloat(tr.CostUSD, 'f', 6, 64)},
+	}
+	if tr.Latency > 0 {
+		props = append(props, junitProperty{"latency_ms", strconv.FormatInt(int64(tr.Latency), 10)})
+	}
+	if tr.Stats != nil {
+		props = append(props,
+			junitProperty{"pass_rate", strconv.FormatFloat(tr.Stats.PassRate, 'f', 4, 64)},
+			junitProperty{"runs", strconv.Itoa(tr.Stats.Runs)})
+	}
+	if tr.Score != nil {
+		props = append(props, junitProperty{"score", strconv.FormatFloat(*tr.Score, 'f', 2, 64)})
+	}
+	for _, attr := range []struct{ name, value string }{
+		{"model", tr.Model},
+		{"owner", tr.Owner},
+		{"severity", tr.Severity},
+		{"tags", strings.Join(tr.Tags, ",")},
+		{"finish_reason", tr.FinishReason},
+	} {
+		if attr.value != "" {
+			props = append(props, junitProperty{attr.name, attr.value})
+		}
+	}
+	tc.Properties = props
+
+	switch {
+	case tr.Status == "skipped":
+		tc.Skipped = &junitMessage{Message: tr.Error}
+	case tr.State == StateDraft:
+		tc.Skipped = &junitMessage{Message: fmt.Sprintf("draft (%s)", tr.Status)}
+	case tr.XFail != "":
+		tc.Skipped = &junitMessage{Message: fmt.Sprintf("expected failure (%s): %s", tr.Status, tr.XFail)}
+	case tr.Status == "error":
+		tc.Error = &junitMessage{Message: tr.Error, Type: "error", Text: junitFailedChecks(tr)}
+	case tr.Status == "failed":
+		failure := &junitMessage{Type: "check", Text: junitFailedChecks(tr)}
+		if tr.Regression {
+			failure.Type = "regression"
+		}
+		failure.Message = junitFailureMessage(tr)
+		tc.Failure = failure
+	}
+	return tc
+}
+
+// junitFailureMessage summarizes why a test failed in one line.
+func junitFailureMessage(tr TestResult) string {
+	var failed []string
+	for _, cr := range tr.CheckResults {
+		if cr.Failed() {
+			failed = append(failed, cr.Check)
+		}
+	}
+	message := fmt.Sprintf("%d check(s) failed: %s", len(failed), strings.Join(failed, ", "))
+	if tr.Regression {
+		message = "regression: " + message
+	}
+	return message
+}
+
+// junitFailedChecks lists the failed checks and policy violations of a test with
+// their messages, one per line.
+func junitFailedChecks(tr TestResult) string {
+	var b strings.Builder
+	if tr.Error != "" {
+		fmt.Fprintf(&b, "error: %s\n", tr.Error)
+	}
+	for _, cr := range tr.CheckResults {
+		if !cr.Failed() {
+			continue
+		}
+		if cr.Message != "" {
+			fmt.Fprintf(&b, "%s: %s\n", cr.Check, cr.Message)
+		} else {
+			fmt.Fprintf(&b, "%s\n", cr.Check)
+		}
+	}
+	return b.String()
+}
+
+// junitDuration is the latency of a test's evaluated call, or how long evaluating it
+// took when no latency was recorded.
+func junitDuration(tr TestResult) time.Duration {
+	if tr.Latency > 0 {
+		return tr.Latency
+	}
+	return tr.Duration
+}
+
+// junitSeconds formats a duration in milliseconds as JUnit's seconds.
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/1000, 'f', 3, 64)
+}
+
+// truncateOutput shortens s to at most n bytes, marking the cut.
+func truncateOutput(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "\n... (truncated)"
+}
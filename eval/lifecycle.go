@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"time"
+)
+
+// Test lifecycle states. Tests without a state are active.
+const (
+	StateActive     = "active"
+	StateDraft      = "draft"
+	StateDeprecated = "deprecated"
+)
+
+// sunsetLayout is the date format of a test's sunset field.
+const sunsetLayout = "2006-01-02"
+
+// CheckLifecycle returns the result for a test that should not run because it is
+// marked skip or because of its lifecycle state, or nil if the test should run
+// normally. Deprecated tests are skipped until their sunset date and fail afterwards
+// so they get cleaned up.
+func CheckLifecycle(test TestCase, now time.Time) *TestResult {
+	if test.Skip != "" {
+		return &TestResult{Name: test.Name, State: test.State, Status: "skipped", Error: test.Skip}
+	}
+	if test.State != StateDeprecated {
+		return nil
+	}
+
+	result := &TestResult{
+		Name:   test.Name,
+		State:  test.State,
+		Status: "skipped",
+		Error:  "deprecated",
+	}
+
+	if test.Sunset != "" {
+		sunset, err := time.Parse(sunsetLayout, test.Sunset)
+		if err != nil {
+			result.Status = "error"
+			result.Error = fmt.Sprintf("invalid sunset date %q (expected YYYY-MM-DD)", test.Sunset)
+			return result
+		}
+		if !now.Before(sunset) {
+			result.Status = "failed"
+			result.Error = fmt.Sprintf("deprecated test is past its sunset date (%s); remove it from the suite", test.Sunset)
+			return result
+		}
+		result.Error = fmt.Sprintf("deprecated, sunset on %s", test.Sunset)
+	}
+
+	return result
+}
+
+// ValidState reports whether a lifecycle state is recognized.
+func ValidState(state string) bool {
+	switch state {
+	case "", StateActive, StateDraft, StateDeprecated:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// excerptContext is how many characters of surrounding text an excerpt shows.
+const excerptContext = 40
+
+// checkMatches verifies the response text matches (or, with negate, doesn't match) a
+// regular expression. Patterns use Go RE2 syntax; prefix them with (?i) to ignore case.
+// Failures quote the part of the response that explains them: its opening for a missing
+// match, or the offending match in context.
+func checkMatches(tr *trace.LLMTrace, pattern string, negate bool) CheckResult {
+	checkType := "matches"
+	if negate {
+		checkType = "not_matches"
+	}
+	result := CheckResult{Check: checkType + ": " + pattern}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid pattern: %v", err)
+		return result
+	}
+
+	responseText := extractResponseText(tr)
+	loc := re.FindStringIndex(responseText)
+
+	switch {
+	case loc != nil && !negate:
+		result.Passed = true
+		result.Message = fmt.Sprintf("Response matches /%s/ at %q", pattern, responseText[loc[0]:loc[1]])
+	case loc == nil && negate:
+		result.Passed = true
+		result.Message = fmt.Sprintf("Response does not match /%s/", pattern)
+	case negate:
+		result.Message = fmt.Sprintf("Response matches /%s/: %s", pattern, excerpt(responseText, loc[0], loc[1]))
+	default:
+		result.Message = fmt.Sprintf("Response does not match /%s/: %s", pattern, excerpt(responseText, 0, 0))
+	}
+
+	return result
+}
+
+// excerpt quotes text[start:end] with up to excerptContext characters on each side,
+// marking cut-off text with an ellipsis. Whitespace runs are collapsed to one space.
+func excerpt(text string, start, end int) string {
+	from := max(start-excerptContext, 0)
+	to := min(end+excerptContext, len(text))
+	if start == end {
+		to = min(start+2*excerptContext, len(text))
+	}
+	// Don't cut UTF-8 sequences in half
+	for from > 0 && !isRuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !isRuneStart(text[to]) {
+		to++
+	}
+
+	quoted := strings.Join(strings.Fields(text[from:to]), " ")
+	if from > 0 {
+		quoted = "…" + quoted
+	}
+	if to < len(text) {
+		quoted += "…"
+	}
+	return fmt.Sprintf("%q", quoted)
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
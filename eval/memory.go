@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// conversationHistory returns the texts of every message sent before the final user turn,
+// including any system prompt, in conversation order.
+func conversationHistory(tr *trace.LLMTrace) []string {
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(tr.Request.Body, &reqData); err != nil {
+		return nil
+	}
+
+	var history []string
+	if system := contentText(reqData["system"]); system != "" {
+		history = append(history, system)
+	}
+	if instruction, ok := reqData["systemInstruction"].(map[string]interface{}); ok {
+		if system := messageText(instruction); system != "" {
+			history = append(history, system)
+		}
+	}
+
+	messages, ok := reqData["messages"].([]interface{})
+	if !ok {
+		messages, _ = reqData["contents"].([]interface{})
+	}
+	last := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if msg, ok := messages[i].(map[string]interface{}); ok && msg["role"] == "user" {
+			last = i
+			break
+		}
+	}
+
+	for i, m := range messages {
+		if i == last {
+			break
+		}
+		if msg, ok := m.(map[string]interface{}); ok {
+			if text := messageText(msg); text != "" {
+				history = append(history, text)
+			}
+		}
+	}
+	return history
+}
+
+// checkRecalls verifies the response repeats a fact established earlier in the conversation.
+// The pattern is matched against prior turns; its first capture group (or the whole match)
+// is the fact the response must contain.
+func checkRecalls(tr *trace.LLMTrace, pattern string) CheckResult {
+	result := CheckResult{Check: "recalls: " + pattern}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid pattern: %v", err)
+		return result
+	}
+
+	var fact string
+	for _, text := range conversationHistory(tr) {
+		if m := re.FindStringSubmatch(text); m != nil {
+			fact = m[0]
+			if len(m) > 1 {
+				fact = m[1]
+			}
+			break
+		}
+	}
+	if fact == "" {
+		result.Message = fmt.Sprintf("No earlier turn matches '%s'", pattern)
+		return result
+	}
+
+	if strings.Contains(strings.ToLower(extractResponseText(tr)), strings.ToLower(fact)) {
+		result.Passed = true
+		result.Message = fmt.Sprintf("Response recalls '%s' from earlier in the conversation", fact)
+	} else {
+		result.Message = fmt.Sprintf("Response does not recall '%s' from earlier in the conversation", fact)
+	}
+	return result
+}
+
+// checkMinTurns verifies that at least N prior messages were sent with the request,
+// catching memory or context-window changes that drop conversation history.
+func checkMinTurns(tr *trace.LLMTrace, param string) CheckResult {
+	result := CheckResult{Check: "min_turns: " + param}
+
+	minTurns, err := strconv.Atoi(param)
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid turn count: %s", param)
+		return result
+	}
+
+	turns := len(conversationHistory(tr))
+	if turns >= minTurns {
+		result.Passed = true
+		result.Message = fmt.Sprintf("Request carried %d prior turns", turns)
+	} else {
+		result.Message = fmt.Sprintf("Request carried %d prior turns, expected at least %d", turns, minTurns)
+	}
+	return result
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// numberPattern matches the first number in free text, allowing thousands separators.
+var numberPattern = regexp.MustCompile(`-?\d+(?:,\d{3})*(?:\.\d+)?`)
+
+// numericSpec describes how to extract and compare a number from a response.
+type numericSpec struct {
+	Value   float64 `json:"value"`
+	AbsTol  float64 `json:"abs_tol"`
+	RelTol  float64 `json:"rel_tol"`
+	Pattern string  `json:"pattern"`
+	Path    string  `json:"path"`
+}
+
+// parseNumericSpec parses a numeric check parameter. It accepts a JSON object
+// (from the YAML map form), "<value>", or "<value>,<abs_tol>".
+func parseNumericSpec(param string) (numericSpec, error) {
+	var spec numericSpec
+	param = strings.TrimSpace(param)
+
+	if strings.HasPrefix(param, "{") {
+		if err := json.Unmarshal([]byte(param), &spec); err != nil {
+			return spec, fmt.Errorf("invalid numeric check parameters: %w", err)
+		}
+		return spec, nil
+	}
+
+	parts := strings.SplitN(param, ",", 2)
+	value, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return spec, fmt.Errorf("invalid expected number: %s", parts[0])
+	}
+	spec.Value = value
+
+	if len(parts) == 2 {
+		tol, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return spec, fmt.Errorf("invalid tolerance: %s", parts[1])
+		}
+		spec.AbsTol = tol
+	}
+
+	return spec, nil
+}
+
+// extractNumber pulls a number out of the response using the spec's JSON path or regex,
+// falling back to the first number that appears in the response text.
+func extractNumber(tr *trace.LLMTrace, spec numericSpec) (float64, error) {
+	text := extractResponseText(tr)
+
+	if spec.Path != "" {
+		var data interface{}
+		if err := json.Unmarshal([]byte(text), &data); err != nil {
+			if err := json.Unmarshal(tr.Response.Body, &data); err != nil {
+				return 0, fmt.Errorf("response is not JSON, cannot apply path %s", spec.Path)
+			}
+		}
+
+		value, ok := lookupPath(data, strings.Split(strings.TrimPrefix(spec.Path, "$."), "."))
+		if !ok {
+			return 0, fmt.Errorf("path %s not found in response", spec.Path)
+		}
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			text = v
+		default:
+			return 0, fmt.Errorf("value at %s is not a number", spec.Path)
+		}
+	}
+
+	match := ""
+	if spec.Pattern != "" {
+		re, err := regexp.Compile(spec.Pattern)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pattern: %w", err)
+		}
+		groups := re.FindStringSubmatch(text)
+		if groups == nil {
+			return 0, fmt.Errorf("pattern %s did not match response", spec.Pattern)
+		}
+		match = groups[0]
+		if len(groups) > 1 {
+			match = groups[1]
+		}
+	} else {
+		match = numberPattern.FindString(text)
+		if match == "" {
+			return 0, fmt.Errorf("no number found in response")
+		}
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(match), ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse number from %q", match)
+	}
+	return value, nil
+}
+
+// checkNumber compares an extracted number to the expected value within tolerance.
+// number_eq uses a negligible tolerance unless one is given explicitly.
+func checkNumber(tr *trace.LLMTrace, checkType, param string) CheckResult {
+	result := CheckResult{
+		Check:  checkType + ": " + param,
+		Passed: false,
+	}
+
+	spec, err := parseNumericSpec(param)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	actual, err := extractNumber(tr, spec)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	tolerance := math.Max(spec.AbsTol, spec.RelTol*math.Abs(spec.Value))
+	if checkType == "number_eq" && tolerance == 0 {
+		tolerance = 1e-9
+	}
+
+	diff := math.Abs(actual - spec.Value)
+	if diff <= tolerance {
+		result.Passed = true
+		result.Message = fmt.Sprintf("Extracted %v is within %v of %v", actual, tolerance, spec.Value)
+	} else {
+		result.Message = fmt.Sprintf("Extracted %v differs from %v by %v (tolerance %v)", actual, spec.Value, diff, tolerance)
+	}
+
+	return result
+}
+
+// lookupPath returns the value at the given path segments in a decoded JSON value.
+func lookupPath(v interface{}, segments []string) (interface{}, bool) {
+	for _, seg := range segments {
+		switch val := v.(type) {
+		case map[string]interface{}:
+			child, ok := val[seg]
+			if !ok {
+				return nil, false
+			}
+			v = child
+		case []interface{}:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(val) {
+				return nil, false
+			}
+			v = val[idx]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
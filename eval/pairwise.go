@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/matias/regrada/config"
+)
+
+// Pairwise comparison outcomes, from the point of view of the new output.
+const (
+	PairwiseWin  = "win"
+	PairwiseTie  = "tie"
+	PairwiseLoss = "loss"
+)
+
+const defaultPairwiseCriteria = "helpfulness, correctness, and clarity"
+
+// PairwiseVerdict is the judge's comparison of a test's output with its baseline output.
+type PairwiseVerdict struct {
+	Outcome string `json:"outcome"` // win, tie, loss
+	Reason  string `json:"reason,omitempty"`
+}
+
+// PairwiseSummary counts the pairwise outcomes of a run.
+type PairwiseSummary struct {
+	Wins     int     `json:"wins"`
+	Ties     int     `json:"ties"`
+	Losses   int     `json:"losses"`
+	WinRate  float64 `json:"win_rate"`
+	LossRate float64 `json:"loss_rate"`
+}
+
+const pairwiseSystemPrompt = `You are a strict, impartial judge comparing two answers from an AI assistant to the same request.
+Judge on the given criteria only; ignore answer order and length unless they matter to the criteria.
+Reply with a single JSON object and nothing else:
+{"winner": "A" | "B" | "tie", "reason": "<one sentence>"}`
+
+// ComparePairwise has the judge compare each test's output with the baseline's output
+// for the same test (the golden answer) and records a win, tie, or loss on the test,
+// plus a summary on the result. Identical outputs tie without a judge call. Each pair
+// is judged twice with the answers swapped, and only agreeing verdicts count as a win
+// or loss, so the judge's position bias shows up as ties. Comparisons don't change
+// test statuses; gate on the loss rate instead. It stops at the first judge error and
+// returns it, summarizing the tests compared so far.
+func ComparePairwise(result, baseline *EvalResult, policy config.PairwisePolicy) error {
+	if !policy.Enabled || baseline == nil {
+		return nil
+	}
+	if activeJudge == nil {
+		return fmt.Errorf("pairwise comparison skipped: no judge is available")
+	}
+
+	criteria := policy.Criteria
+	if criteria == "" {
+		criteria = defaultPairwiseCriteria
+	}
+	golden := make(map[string]string, len(baseline.TestResults))
+	for _, tr := range baseline.TestResults {
+		golden[tr.Name] = tr.Output
+	}
+
+	defer func() { result.Pairwise = summarizePairwise(result.TestResults) }()
+	for i := range result.TestResults {
+		tr := &result.TestResults[i]
+		base := golden[tr.Name]
+		if base == "" || tr.Output == "" || tr.Status == "skipped" || tr.Status == "error" {
+			continue
+		}
+		if tr.Output == base {
+			tr.Pairwise = &PairwiseVerdict{Outcome: PairwiseTie, Reason: "Output is unchanged"}
+			continue
+		}
+
+		// New output as A, then as B
+		first, reason, err := judgePair(tr.Name, criteria, tr.Output, base)
+		if err != nil {
+			return fmt.Errorf("pairwise comparison of %s: %w", tr.Name, err)
+		}
+		second, _, err := judgePair(tr.Name, criteria, base, tr.Output)
+		if err != nil {
+			return fmt.Errorf("pairwise comparison of %s: %w", tr.Name, err)
+		}
+
+		verdict := &PairwiseVerdict{Outcome: PairwiseTie, Reason: reason}
+		switch {
+		case first == "A" && second == "B":
+			verdict.Outcome = PairwiseWin
+		case first == "B" && second == "A":
+			verdict.Outcome = PairwiseLoss
+		case first != second && first != "tie" && second != "tie":
+			verdict.Reason = "The judge preferred whichever answer came first"
+		}
+		tr.Pairwise = verdict
+	}
+	return nil
+}
+
+// judgePair asks the judge which of two answers is better and returns "A", "B", or "tie".
+func judgePair(testName, criteria, a, b string) (string, string, error) {
+	prompt := fmt.Sprintf("Test case: %s\nCriteria: %s\n\nAnswer A:\n%s\n\nAnswer B:\n%s", testName, criteria, a, b)
+	reply, err := activeJudge.Complete(pairwiseSystemPrompt, prompt)
+	if err != nil {
+		return "", "", err
+	}
+
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return "", "", fmt.Errorf("judge reply has no JSON object: %q", reply)
+	}
+	var parsed struct {
+		Winner string `json:"winner"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return "", "", fmt.Errorf("invalid judge reply: %q", reply)
+	}
+	switch winner := strings.ToUpper(strings.TrimSpace(parsed.Winner)); winner {
+	case "A", "B":
+		return winner, parsed.Reason, nil
+	case "TIE":
+		return "tie", parsed.Reason, nil
+	default:
+		return "", "", fmt.Errorf("judge reply has no winner: %q", reply)
+	}
+}
+
+// summarizePairwise counts the pairwise verdicts of non-draft tests, or returns nil when
+// no test was compared.
+func summarizePairwise(results []TestResult) *PairwiseSummary {
+	s := &PairwiseSummary{}
+	for _, tr := range results {
+		if tr.Pairwise == nil || tr.State == StateDraft || tr.XFail != "" {
+			continue
+		}
+		switch tr.Pairwise.Outcome {
+		case PairwiseWin:
+			s.Wins++
+		case PairwiseLoss:
+			s.Losses++
+		default:
+			s.Ties++
+		}
+	}
+	total := s.Wins + s.Ties + s.Losses
+	if total == 0 {
+		return nil
+	}
+	s.WinRate = float64(s.Wins) / float64(total)
+	s.LossRate = float64(s.Losses) / float64(total)
+	return s
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"sync"
+
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/trace"
+)
+
+// Env holds what checks and policies call out to while a suite is evaluated. A nil
+// judge or embeddings provider skips the rubric or similar_to checks that need it.
+type Env struct {
+	Judge       Judge
+	Embedder    Embedder
+	CheckCache  *CheckCache
+	Checkpoint  *Checkpoint
+	Concurrency int                        // Tests evaluated at once (default: evals.concurrent)
+	Progress    func(done, total int)      // See UseProgress
+	OnResult    func(TestCase, TestResult) // Called with each test's result, in suite order
+}
+
+// NewEnv creates the judge and embeddings provider configured in cfg.
+func NewEnv(cfg *config.RegradaConfig) (Env, error) {
+	env := Env{Concurrency: cfg.Evals.Concurrent}
+	embedder, err := NewEmbedder(cfg.Embeddings)
+	if err != nil {
+		return env, err
+	}
+	judge, err := NewJudge(cfg.Judge)
+	if err != nil {
+		return env, err
+	}
+	env.Embedder, env.Judge = embedder, judge
+	return env, nil
+}
+
+// RunReport lists what an evaluation could not do fully, for the caller to report.
+type RunReport struct {
+	TooFewRuns        []string // Tests the sampling policies skipped for lack of runs
+	UnapprovedPrompts []string // Fingerprints of unapproved system prompts sent in the session
+	Warnings          []error  // Policies that could not be applied
+}
+
+// evaluateMu serializes Evaluate, since checks reach the environment through package state.
+var evaluateMu sync.Mutex
+
+// Evaluate evaluates suite against sessions (oldest first; with several, each test
+// gets statistics across them) with env, applies cfg's policies, and compares the
+// result with baseline, which may be nil. It is the evaluation behind `regrada run`
+// and the Go API, so both produce the same result for the same inputs. env is only
+// in effect during the call.
+func Evaluate(cfg *config.RegradaConfig, suite *TestSuite, sessions []*trace.TraceSession, baseline *EvalResult, env Env) (*EvalResult, RunReport) {
+	evaluateMu.Lock()
+	defer evaluateMu.Unlock()
+	defer env.use()()
+	if env.CheckCache != nil {
+		env.CheckCache.useProviders(cfg)
+	}
+
+	var report RunReport
+	session := sessions[len(sessions)-1]
+	result := EvaluateRuns(suite, sessions, env.OnResult)
+
+	ApplyTokensPolicy(result, baseline, cfg.Policies.Tokens)
+	ApplyLatencyPolicy(result, baseline, cfg.Policies.Latency)
+	ApplyContentFilterPolicy(result, baseline, cfg.Policies.ContentFilter)
+	ApplyTruncationPolicy(result, baseline, cfg.Policies.Truncation)
+	ApplyScorePolicy(result, baseline, cfg.Policies.Score)
+	report.TooFewRuns = ApplySamplingPolicies(result, cfg.Policies.Sampling)
+	unapproved, err := ApplyPromptDriftPolicy(result, session, cfg.Policies.PromptDrift)
+	if err != nil {
+		report.Warnings = append(report.Warnings, err)
+	}
+	report.UnapprovedPrompts = unapproved
+	if err := ApplySemanticDriftPolicy(result, baseline, cfg.Policies.SemanticDrift); err != nil {
+		report.Warnings = append(report.Warnings, err)
+	}
+	if err := ComparePairwise(result, baseline, cfg.Policies.Pairwise); err != nil {
+		report.Warnings = append(report.Warnings, err)
+	}
+
+	if baseline != nil {
+		comp := ApplyBaselineResult(result, baseline)
+		comp.Stale = BaselineStaleness(result, baseline, cfg.Policies.BaselineStaleness)
+	}
+
+	result.Sections = GroupSections(result, cfg.Output.Sections)
+	result.Footprint = session.Summary.Footprint
+	result.CostUSD = session.Summary.TotalCostUSD
+	result.Quality = ScoreRun(result, baseline, cfg.Quality)
+	return result, report
+}
+
+// use installs the environment and returns a function that restores the previous one.
+func (env Env) use() func() {
+	judge, embedder, cache, checkpoint := activeJudge, activeEmbedder, activeCheckCache, activeCheckpoint
+	workers, report := concurrency, progress
+
+	UseJudge(env.Judge)
+	UseEmbedder(env.Embedder)
+	UseCheckCache(env.CheckCache)
+	UseCheckpoint(env.Checkpoint)
+	UseConcurrency(env.Concurrency)
+	UseProgress(env.Progress)
+
+	return func() {
+		activeJudge, activeEmbedder, activeCheckCache, activeCheckpoint = judge, embedder, cache, checkpoint
+		concurrency, progress = workers, report
+	}
+}
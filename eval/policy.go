@@ -0,0 +1,392 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/trace"
+)
+
+// Check names of the results added to tests that violate a policy.
+const (
+	TokensPolicyCheck  = "tokens_policy"
+	LatencyPolicyCheck = "latency_policy"
+	ContentFilterCheck = "content_filter_policy"
+	TruncationCheck    = "truncation_policy"
+	SemanticDriftCheck = "semantic_drift"
+	ScorePolicyCheck   = "score_policy"
+	SamplingCheck      = "sampling_policy"
+	PromptDriftCheck   = "prompt_drift"
+)
+
+// ApplyTokensPolicy fails tests whose output token usage exceeds policy.Max, or grew
+// by more than policy.MaxDelta over the same test in baseline. Tests without recorded
+// usage, skipped tests, and errored tests are left alone. Apply it before comparing
+// with the baseline so violations in previously passing tests count as regressions.
+func ApplyTokensPolicy(result, baseline *EvalResult, policy config.TokensPolicy) {
+	if policy.Max <= 0 && policy.MaxDelta <= 0 {
+		return
+	}
+
+	previous := make(map[string]int)
+	if baseline != nil {
+		for _, tr := range baseline.TestResults {
+			previous[tr.Name] = tr.TokensOut
+		}
+	}
+
+	for i := range result.TestResults {
+		tr := &result.TestResults[i]
+		if tr.TokensOut == 0 || tr.Status == "skipped" || tr.Status == "error" {
+			continue
+		}
+
+		var violation string
+		if policy.Max > 0 && tr.TokensOut > policy.Max {
+			violation = fmt.Sprintf("Output tokens %d exceed the policy maximum %d", tr.TokensOut, policy.Max)
+		} else if base := previous[tr.Name]; policy.MaxDelta > 0 && base > 0 {
+			if growth := float64(tr.TokensOut-base) / float64(base); growth > policy.MaxDelta {
+				violation = fmt.Sprintf("Output tokens grew %.0f%% over the baseline (%d → %d), policy allows +%.0f%%",
+					growth*100, base, tr.TokensOut, policy.MaxDelta*100)
+			}
+		}
+		if violation == "" {
+			continue
+		}
+
+		failPolicy(result, tr, TokensPolicyCheck, violation)
+	}
+
+	result.UpdateStatus()
+}
+
+// ApplyLatencyPolicy fails tests whose latency grew by more than policy.MaxDelta over
+// the same test in baseline. When both sides were evaluated across at least MinStatRuns
+// runs, their mean latencies are compared and the growth must also be significant: the
+// current 95% interval lies entirely above the baseline's. Like ApplyTokensPolicy, apply
+// it before comparing with the baseline.
+func ApplyLatencyPolicy(result, baseline *EvalResult, policy config.LatencyPolicy) {
+	if policy.MaxDelta <= 0 || baseline == nil {
+		return
+	}
+
+	previous := make(map[string]TestResult, len(baseline.TestResults))
+	for _, tr := range baseline.TestResults {
+		previous[tr.Name] = tr
+	}
+
+	for i := range result.TestResults {
+		tr := &result.TestResults[i]
+		base, ok := previous[tr.Name]
+		if !ok || tr.Status == "skipped" || tr.Status == "error" {
+			continue
+		}
+
+		before, after := float64(base.Latency), float64(tr.Latency)
+		significant := true
+		if statistical(base, *tr) {
+			before, after = base.Stats.LatencyMean, tr.Stats.LatencyMean
+			significant = latencyIncrease(tr.Name, base.Stats, tr.Stats) != ""
+		}
+		if before <= 0 || after <= 0 || !significant {
+			continue
+		}
+		if growth := (after - before) / before; growth > policy.MaxDelta {
+			failPolicy(result, tr, LatencyPolicyCheck, fmt.Sprintf("Latency grew %.0f%% over the baseline (%.0fms → %.0fms), policy allows +%.0f%%",
+				growth*100, before, after, policy.MaxDelta*100))
+		}
+	}
+
+	result.UpdateStatus()
+}
+
+// ApplyContentFilterPolicy fails the content-filtered tests when the share of gated
+// tests whose response the provider blocked or refused exceeds policy.Max, or grew by
+// more than policy.MaxDelta over the baseline's share. Like ApplyTokensPolicy, apply it
+// before comparing with the baseline.
+func ApplyContentFilterPolicy(result, baseline *EvalResult, policy config.RatePolicy) {
+	applyRatePolicy(result, baseline, policy, ContentFilterCheck, "content filtered",
+		func(tr TestResult) bool { return tr.ContentFiltered })
+}
+
+// ApplyTruncationPolicy fails the truncated tests, whose response stopped at the token
+// limit (finish_reason "length"), when their share of the gated tests exceeds
+// policy.Max or grew by more than policy.MaxDelta over the baseline's share.
+func ApplyTruncationPolicy(result, baseline *EvalResult, policy config.RatePolicy) {
+	applyRatePolicy(result, baseline, policy, TruncationCheck, "truncated",
+		func(tr TestResult) bool { return tr.FinishReason == "length" })
+}
+
+// applyRatePolicy fails the gated tests matching hit when their share of the gated
+// tests breaks policy, each with a check result naming the rates.
+func applyRatePolicy(result, baseline *EvalResult, policy config.RatePolicy, check, what string, hit func(TestResult) bool) {
+	if policy.Max <= 0 && policy.MaxDelta <= 0 {
+		return
+	}
+
+	current, total := matchRate(result, hit)
+	if total == 0 {
+		return
+	}
+	var violation string
+	if policy.Max > 0 && current > policy.Max {
+		violation = fmt.Sprintf("%.0f%% of tests were %s, policy allows %.0f%%", current*100, what, policy.Max*100)
+	} else if baseline != nil && policy.MaxDelta > 0 {
+		if before, n := matchRate(baseline, hit); n > 0 && current-before > policy.MaxDelta {
+			violation = fmt.Sprintf("%s tests rose from %.0f%% in the baseline to %.0f%%, policy allows +%.0f points",
+				strings.ToUpper(what[:1])+what[1:], before*100, current*100, policy.MaxDelta*100)
+		}
+	}
+	if violation == "" {
+		return
+	}
+
+	for i := range result.TestResults {
+		tr := &result.TestResults[i]
+		if rateGated(*tr) && hit(*tr) {
+			failPolicy(result, tr, check, violation)
+		}
+	}
+	result.UpdateStatus()
+}
+
+// matchRate returns the share of the gated, evaluated tests in result matching hit, and
+// how many such tests there are.
+func matchRate(result *EvalResult, hit func(TestResult) bool) (float64, int) {
+	total, hits := 0, 0
+	for _, tr := range result.TestResults {
+		if !rateGated(tr) {
+			continue
+		}
+		total++
+		if hit(tr) {
+			hits++
+		}
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	return float64(hits) / float64(total), total
+}
+
+// rateGated reports whether a test counts toward a rate policy: it gates the run and
+// its response was evaluated.
+func rateGated(tr TestResult) bool {
+	return tr.State != StateDraft && tr.XFail == "" && (tr.Status == "passed" || tr.Status == "failed")
+}
+
+// ApplySemanticDriftPolicy fails tests whose output no longer means what the baseline's
+// output (the golden text) meant: the cosine similarity of their embeddings must be at
+// least policy.MinSimilarity. Outputs identical to the baseline are not embedded. Like
+// ApplyTokensPolicy, apply it before comparing with the baseline. It stops at the first
+// embeddings error and returns it; tests checked so far keep their verdicts.
+func ApplySemanticDriftPolicy(result, baseline *EvalResult, policy config.SemanticDriftPolicy) error {
+	if policy.MinSimilarity <= 0 || baseline == nil {
+		return nil
+	}
+	if activeEmbedder == nil {
+		return fmt.Errorf("semantic_drift policy skipped: no embeddings provider is available")
+	}
+
+	golden := make(map[string]string, len(baseline.TestResults))
+	for _, tr := range baseline.TestResults {
+		golden[tr.Name] = tr.Output
+	}
+
+	defer result.UpdateStatus()
+	for i := range result.TestResults {
+		tr := &result.TestResults[i]
+		base := golden[tr.Name]
+		if base == "" || tr.Output == "" || tr.Output == base || tr.Status == "skipped" || tr.Status == "error" {
+			continue
+		}
+
+		vectors, err := activeEmbedder.Embed([]string{base, tr.Output})
+		if err != nil {
+			return fmt.Errorf("semantic_drift policy: %w", err)
+		}
+		if similarity := cosineSimilarity(vectors[0], vectors[1]); similarity < policy.MinSimilarity {
+			failPolicy(result, tr, SemanticDriftCheck, fmt.Sprintf(
+				"Output drifted from the baseline: similarity %.3f is below %.2f", similarity, policy.MinSimilarity))
+		}
+	}
+	return nil
+}
+
+// ApplyScorePolicy fails rubric-graded tests scoring below policy.Min, or whose score
+// dropped by more than policy.MaxDelta from the same test in baseline. Tests without a
+// score are left alone. Like ApplyTokensPolicy, apply it before comparing with the baseline.
+func ApplyScorePolicy(result, baseline *EvalResult, policy config.ScorePolicy) {
+	if policy.Min <= 0 && policy.MaxDelta <= 0 {
+		return
+	}
+
+	previous := make(map[string]float64)
+	if baseline != nil {
+		for _, tr := range baseline.TestResults {
+			if tr.Score != nil {
+				previous[tr.Name] = *tr.Score
+			}
+		}
+	}
+
+	for i := range result.TestResults {
+		tr := &result.TestResults[i]
+		if tr.Score == nil || tr.Status == "skipped" || tr.Status == "error" {
+			continue
+		}
+		score := *tr.Score
+
+		if policy.Min > 0 && score < policy.Min {
+			failPolicy(result, tr, ScorePolicyCheck, fmt.Sprintf("Score %.2f is below the policy minimum %.2f", score, policy.Min))
+		} else if base, ok := previous[tr.Name]; ok && policy.MaxDelta > 0 && base-score > policy.MaxDelta {
+			failPolicy(result, tr, ScorePolicyCheck, fmt.Sprintf("Score dropped from %.2f to %.2f, policy allows -%.2f",
+				base, score, policy.MaxDelta))
+		}
+	}
+
+	result.UpdateStatus()
+}
+
+// ApplySamplingPolicies fails tests evaluated across several runs whose pass@k or
+// majority vote falls below a policy's minimum, or whose variance metric exceeds a
+// policy's maximum. A pass@k policy skips tests with fewer than k runs and returns their
+// names, so the caller can report that the policy was not applied. Tests evaluated in a
+// single run have no aggregates and are left alone.
+func ApplySamplingPolicies(result *EvalResult, policies []config.SamplingPolicy) []string {
+	var tooFew []string
+	seen := make(map[string]bool)
+	for _, policy := range policies {
+		for i := range result.TestResults {
+			tr := &result.TestResults[i]
+			s := tr.Stats
+			if s == nil || tr.Status == "skipped" {
+				continue
+			}
+
+			if config.IsVarianceMetric(policy.Metric) {
+				if value, ok := varianceMetric(s, policy.Metric); ok && value > policy.Max {
+					failPolicy(result, tr, SamplingCheck, fmt.Sprintf("%s is %.2f over %d runs, policy allows %.2f",
+						policy.Metric, value, s.Runs, policy.Max))
+				}
+				continue
+			}
+
+			var value float64
+			if policy.Metric == "majority" {
+				if s.MajorityPassed {
+					value = 1
+				}
+			} else {
+				var k int
+				fmt.Sscanf(policy.Metric, "pass@%d", &k)
+				if k > s.Runs {
+					if !seen[tr.Name] {
+						seen[tr.Name] = true
+						tooFew = append(tooFew, tr.Name)
+					}
+					continue
+				}
+				value = PassAtK(s.Runs, s.Passes, k)
+			}
+			if value < policy.Min {
+				failPolicy(result, tr, SamplingCheck, fmt.Sprintf("%s is %.2f over %d runs, policy requires %.2f",
+					policy.Metric, value, s.Runs, policy.Min))
+			}
+		}
+	}
+
+	result.UpdateStatus()
+	return tooFew
+}
+
+// varianceMetric returns a variance metric of a test's runs, or false when the runs
+// recorded no latency or token usage to measure.
+func varianceMetric(s *RunStats, metric string) (float64, bool) {
+	switch metric {
+	case "pass_rate_cv":
+		return s.PassRateCV, true
+	case "latency_cv":
+		return coefficientOfVariation(s.LatencyMean, s.LatencyStdDev), s.LatencyMean > 0
+	case "latency_stddev":
+		return s.LatencyStdDev, s.LatencyMean > 0
+	case "tokens_out_cv":
+		return coefficientOfVariation(s.TokensOutMean, s.TokensOutStdDev), s.TokensOutMean > 0
+	case "tokens_out_stddev":
+		return s.TokensOutStdDev, s.TokensOutMean > 0
+	}
+	return 0, false
+}
+
+// ApplyPromptDriftPolicy fails tests whose evaluated call used a system prompt that is not
+// approved by policy, so traffic running an unreviewed prompt version is caught. It
+// returns the unapproved fingerprints seen anywhere in the session, including calls no
+// test evaluates, sorted. Like ApplyTokensPolicy, apply it before comparing with the baseline.
+func ApplyPromptDriftPolicy(result *EvalResult, session *trace.TraceSession, policy config.PromptDriftPolicy) ([]string, error) {
+	if len(policy.Approved) == 0 && len(policy.Files) == 0 {
+		return nil, nil
+	}
+
+	approved := append([]string(nil), policy.Approved...)
+	for _, file := range policy.Files {
+		prompt, _, err := config.LoadPrompt(file)
+		if err != nil {
+			return nil, fmt.Errorf("prompt_drift policy: %w", err)
+		}
+		approved = append(approved, PromptFingerprint(prompt))
+	}
+	isApproved := func(fingerprint string) bool {
+		for _, a := range approved {
+			if a = strings.ToLower(strings.TrimSpace(a)); a != "" && strings.HasPrefix(fingerprint, a) {
+				return true
+			}
+		}
+		return false
+	}
+
+	defer result.UpdateStatus()
+	for i := range result.TestResults {
+		tr := &result.TestResults[i]
+		if tr.PromptFingerprint == "" || tr.Status == "skipped" || tr.Status == "error" || isApproved(tr.PromptFingerprint) {
+			continue
+		}
+		failPolicy(result, tr, PromptDriftCheck, fmt.Sprintf(
+			"System prompt %s is not an approved version", shortHash(tr.PromptFingerprint)))
+	}
+
+	seen := make(map[string]bool)
+	var unapproved []string
+	for i := range session.Traces {
+		system := systemPrompt(&session.Traces[i])
+		if system == "" {
+			continue
+		}
+		if fingerprint := PromptFingerprint(system); !seen[fingerprint] && !isApproved(fingerprint) {
+			seen[fingerprint] = true
+			unapproved = append(unapproved, fingerprint)
+		}
+	}
+	sort.Strings(unapproved)
+	return unapproved, nil
+}
+
+// failPolicy records a policy violation on a test and fails it, keeping the run's counts in step.
+func failPolicy(result *EvalResult, tr *TestResult, check, message string) {
+	tr.CheckResults = append(tr.CheckResults, CheckResult{Check: check, Message: message})
+	if tr.Status == "passed" {
+		tr.Status = "failed"
+		switch {
+		case tr.State == StateDraft:
+		case tr.XFail != "":
+			result.XPassed--
+			result.XFailed++
+		default:
+			result.Passed--
+			result.Failed++
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import "sync"
+
+// concurrency is the number of tests evaluated at once, set with UseConcurrency.
+var concurrency = 1
+
+// progress, if set with UseProgress, is told how many evaluations have finished.
+var progress func(done, total int)
+
+// UseConcurrency makes EvaluateSuite and EvaluateRuns evaluate up to n tests at once.
+// Results are still reported in suite order. n < 1 means one at a time.
+func UseConcurrency(n int) {
+	concurrency = max(n, 1)
+}
+
+// UseProgress makes evaluations report progress to fn after each test evaluation
+// (one per test and session with --runs). Calls are never concurrent. Pass nil to disable.
+func UseProgress(fn func(done, total int)) {
+	progress = fn
+}
+
+// forEachOrdered calls run(i) for every i in [0, n) on up to concurrency goroutines and
+// then done(i) in index order, each as soon as run(i) and every earlier index finished.
+// done may be nil. done and progress calls happen on the calling goroutine.
+func forEachOrdered(n int, run func(i int), done func(i int)) {
+	finished := make(chan int, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(concurrency, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				run(i)
+				finished <- i
+			}
+		}()
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(finished)
+	}()
+
+	ready := make([]bool, n)
+	next, count := 0, 0
+	for i := range finished {
+		count++
+		if progress != nil {
+			progress(count, n)
+		}
+		ready[i] = true
+		for next < n && ready[next] {
+			if done != nil {
+				done(next)
+			}
+			next++
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Promote returns the baseline that results from accepting current, without its
+// comparison with the previous baseline. With no names, it is current. With names, only
+// those tests' results are taken from current: they replace or are added to baseline's,
+// and the rest of baseline, including when it was saved and its provenance, is kept.
+// A filtered current is merged the same way, since it has no results for the tests it
+// left out. baseline may be nil when there is none yet.
+func Promote(baseline, current *EvalResult, names []string) (*EvalResult, error) {
+	if missing := missingTests(current, names); len(missing) > 0 {
+		return nil, fmt.Errorf("no results for %s", strings.Join(missing, ", "))
+	}
+
+	var promoted EvalResult
+	if (len(names) == 0 && !current.Filtered) || baseline == nil {
+		promoted = *current
+		promoted.TestResults = nil
+	} else {
+		promoted = *baseline
+		promoted.TestResults = append([]TestResult(nil), baseline.TestResults...)
+	}
+	promoted.Comparison, promoted.Regressions = nil, 0
+
+	for _, tr := range current.TestResults {
+		if len(names) > 0 && !containsTag(names, tr.Name) {
+			continue
+		}
+		tr.Regression = false
+		replaced := false
+		for i := range promoted.TestResults {
+			if promoted.TestResults[i].Name == tr.Name {
+				promoted.TestResults[i] = tr
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			promoted.TestResults = append(promoted.TestResults, tr)
+		}
+	}
+	promoted.Recount()
+	return &promoted, nil
+}
+
+// missingTests returns the names that have no result in r.
+func missingTests(r *EvalResult, names []string) []string {
+	var missing []string
+	for _, name := range names {
+		found := false
+		for _, tr := range r.TestResults {
+			if tr.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
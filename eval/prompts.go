@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// PromptFileMetadataPrefix prefixes the session metadata keys that record the SHA-256 of
+// each file the system prompt was built from, keyed by path.
+const PromptFileMetadataPrefix = "prompt_file_sha256:"
+
+// PromptFingerprint identifies a version of a system prompt: the SHA-256 of its text. A
+// prompt sent from provider.system_prompt_file has the session's system_prompt_sha256.
+func PromptFingerprint(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// PromptFiles returns the prompt files a session was traced with and their hashes.
+func PromptFiles(session *trace.TraceSession) map[string]string {
+	var files map[string]string
+	for key, sum := range session.Metadata {
+		path, ok := strings.CutPrefix(key, PromptFileMetadataPrefix)
+		if !ok {
+			continue
+		}
+		if files == nil {
+			files = make(map[string]string)
+		}
+		files[path] = sum
+	}
+	return files
+}
+
+// PromptEdits lists the prompt files that differ between two runs, sorted by path, as
+// "path (edited)", "path (added)", or "path (removed)".
+func PromptEdits(before, after map[string]string) []string {
+	var edits []string
+	for path, sum := range after {
+		previous, ok := before[path]
+		switch {
+		case !ok:
+			edits = append(edits, path+" (added)")
+		case previous != sum:
+			edits = append(edits, path+" (edited)")
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			edits = append(edits, path+" (removed)")
+		}
+	}
+	sort.Strings(edits)
+	return edits
+}
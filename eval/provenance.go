@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Provenance records where a result came from, so a run compared with it as a
+// baseline can say what it is being compared against.
+type Provenance struct {
+	GitSHA    string `json:"git_sha,omitempty"`
+	GitBranch string `json:"git_branch,omitempty"`
+	Version   string `json:"regrada_version,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+	Model     string `json:"model,omitempty"`
+}
+
+// String summarizes the provenance as "branch@sha, provider/model, regrada version",
+// leaving out what is unknown.
+func (p *Provenance) String() string {
+	if p == nil {
+		return ""
+	}
+	var parts []string
+	if p.GitSHA != "" {
+		rev := shortHash(p.GitSHA)
+		if p.GitBranch != "" {
+			rev = p.GitBranch + "@" + rev
+		}
+		parts = append(parts, rev)
+	}
+	switch {
+	case p.Provider != "" && p.Model != "":
+		parts = append(parts, p.Provider+"/"+p.Model)
+	case p.Provider != "" || p.Model != "":
+		parts = append(parts, p.Provider+p.Model)
+	}
+	if p.Version != "" {
+		parts = append(parts, fmt.Sprintf("regrada %s", p.Version))
+	}
+	return strings.Join(parts, ", ")
+}
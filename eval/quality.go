@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/matias/regrada/config"
+)
+
+// Test severities weight failures in the quality score. Tests without a severity are medium.
+const (
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// defaultSeverityWeights double the cost of a failure at each severity step.
+var defaultSeverityWeights = map[string]float64{
+	SeverityLow:      1,
+	SeverityMedium:   2,
+	SeverityHigh:     4,
+	SeverityCritical: 8,
+}
+
+// Default penalties, in score points.
+const (
+	defaultRegressionPenalty = 5
+	defaultLatencyPenalty    = 10
+	defaultCostPenalty       = 10
+)
+
+// ValidSeverity reports whether s is a known severity. The empty string is valid (medium).
+func ValidSeverity(s string) bool {
+	_, ok := defaultSeverityWeights[s]
+	return s == "" || ok
+}
+
+// QualityScore is a single 0-100 number summarizing a run: the severity-weighted pass rate,
+// minus penalties for regressions and for mean latency and cost growth against the baseline.
+type QualityScore struct {
+	Score             float64  `json:"score"`
+	WeightedPassRate  float64  `json:"weighted_pass_rate"`
+	RegressionPenalty float64  `json:"regression_penalty,omitempty"`
+	LatencyPenalty    float64  `json:"latency_penalty,omitempty"`
+	CostPenalty       float64  `json:"cost_penalty,omitempty"`
+	BaselineScore     *float64 `json:"baseline_score,omitempty"`
+}
+
+// Delta returns the change from the baseline score, or 0 without a baseline.
+func (q *QualityScore) Delta() float64 {
+	if q.BaselineScore == nil {
+		return 0
+	}
+	return q.Score - *q.BaselineScore
+}
+
+// ScoreRun computes the quality score of a result. Drafts, xfail tests, and skipped tests are left out.
+// Each regression costs regression_penalty points, and a slower mean latency than the
+// baseline costs up to latency_penalty points (reached at twice the baseline latency), and
+// a higher total cost up to cost_penalty points (reached at twice the baseline cost).
+func ScoreRun(result, baseline *EvalResult, cfg config.QualityConfig) *QualityScore {
+	weights := make(map[string]float64, len(defaultSeverityWeights))
+	for severity, w := range defaultSeverityWeights {
+		weights[severity] = w
+	}
+	for severity, w := range cfg.SeverityWeights {
+		weights[severity] = w
+	}
+
+	var total, passed float64
+	for _, tr := range result.TestResults {
+		if tr.State == StateDraft || tr.XFail != "" || tr.Status == "skipped" {
+			continue
+		}
+		severity := tr.Severity
+		if severity == "" {
+			severity = SeverityMedium
+		}
+		w := weights[severity]
+		total += w
+		if tr.Status == "passed" {
+			passed += w
+		}
+	}
+
+	q := &QualityScore{WeightedPassRate: 1}
+	if total > 0 {
+		q.WeightedPassRate = passed / total
+	}
+
+	regressionPenalty := float64(defaultRegressionPenalty)
+	if cfg.RegressionPenalty != nil {
+		regressionPenalty = *cfg.RegressionPenalty
+	}
+	q.RegressionPenalty = regressionPenalty * float64(result.Regressions)
+
+	if baseline != nil {
+		latencyPenalty := float64(defaultLatencyPenalty)
+		if cfg.LatencyPenalty != nil {
+			latencyPenalty = *cfg.LatencyPenalty
+		}
+		current, previous := meanLatency(result), meanLatency(baseline)
+		if previous > 0 && current > previous {
+			q.LatencyPenalty = latencyPenalty * math.Min((current-previous)/previous, 1)
+		}
+		costPenalty := float64(defaultCostPenalty)
+		if cfg.CostPenalty != nil {
+			costPenalty = *cfg.CostPenalty
+		}
+		if previous := baseline.CostUSD; previous > 0 && result.CostUSD > previous {
+			q.CostPenalty = costPenalty * math.Min((result.CostUSD-previous)/previous, 1)
+		}
+		if baseline.Quality != nil {
+			score := baseline.Quality.Score
+			q.BaselineScore = &score
+		}
+	}
+
+	q.Score = math.Max(0, math.Min(100, 100*q.WeightedPassRate-q.RegressionPenalty-q.LatencyPenalty-q.CostPenalty))
+	q.Score = math.Round(q.Score*10) / 10
+	return q
+}
+
+// meanLatency is the mean recorded latency of the tests in a result, in milliseconds.
+func meanLatency(result *EvalResult) float64 {
+	var sum float64
+	n := 0
+	for _, tr := range result.TestResults {
+		if tr.Latency > 0 {
+			sum += float64(tr.Latency)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// WriteScoreBadge writes the score as a shields.io endpoint badge
+// (https://shields.io/badges/endpoint-badge).
+func WriteScoreBadge(q *QualityScore, path string) error {
+	color := "red"
+	switch {
+	case q.Score >= 90:
+		color = "brightgreen"
+	case q.Score >= 75:
+		color = "yellow"
+	case q.Score >= 50:
+		color = "orange"
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"schemaVersion": 1,
+		"label":         "quality",
+		"message":       fmt.Sprintf("%.1f", q.Score),
+		"color":         color,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"sync"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// rateWindow is the period request and token limits are counted over.
+const rateWindow = time.Minute
+
+// rateLimiter holds back judge and embeddings calls so concurrent checks stay under
+// the provider's requests- and tokens-per-minute limits instead of failing with 429s.
+type rateLimiter struct {
+	mu       sync.Mutex
+	requests int // Per minute; 0 means unlimited
+	tokens   int // Per minute; 0 means unlimited
+	sent     []rateEvent
+}
+
+type rateEvent struct {
+	at     time.Time
+	tokens int
+}
+
+// newRateLimiter returns nil when cfg sets no limits.
+func newRateLimiter(cfg *config.RateLimitConfig) *rateLimiter {
+	if cfg == nil || (cfg.RequestsPerMinute <= 0 && cfg.TokensPerMinute <= 0) {
+		return nil
+	}
+	return &rateLimiter{requests: max(cfg.RequestsPerMinute, 0), tokens: max(cfg.TokensPerMinute, 0)}
+}
+
+// wait blocks until a request of about tokens tokens fits in the last minute's limits,
+// then counts it. A request larger than the token limit is sent once the window is empty.
+// A nil limiter never waits.
+func (l *rateLimiter) wait(tokens int) {
+	if l == nil {
+		return
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		for len(l.sent) > 0 && now.Sub(l.sent[0].at) >= rateWindow {
+			l.sent = l.sent[1:]
+		}
+		used := 0
+		for _, e := range l.sent {
+			used += e.tokens
+		}
+		fits := (l.requests == 0 || len(l.sent) < l.requests) &&
+			(l.tokens == 0 || used+tokens <= l.tokens || len(l.sent) == 0)
+		if fits {
+			l.sent = append(l.sent, rateEvent{at: now, tokens: tokens})
+			l.mu.Unlock()
+			return
+		}
+		// Nothing changes until the oldest call leaves the window
+		delay := rateWindow - now.Sub(l.sent[0].at)
+		l.mu.Unlock()
+		time.Sleep(delay)
+	}
+}
+
+// estimateTokens approximates the token count of texts at about 4 characters per token.
+func estimateTokens(texts ...string) int {
+	n := 0
+	for _, text := range texts {
+		n += len(text)
+	}
+	return (n + 3) / 4
+}
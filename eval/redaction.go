@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/matias/regrada/redact"
+	"github.com/matias/regrada/trace"
+)
+
+// needsRedactionNormalization reports whether the response or any check expectation
+// contains a redaction placeholder such as [REDACTED_EMAIL].
+func needsRedactionNormalization(tr *trace.LLMTrace, checks []Check) bool {
+	if redact.HasPlaceholder(string(tr.Response.Body)) {
+		return true
+	}
+	for _, check := range checks {
+		if redact.HasPlaceholder(check.Raw) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRedactions returns a copy of the trace whose response strings have detected
+// sensitive values replaced by placeholders, matching how redacted baselines look.
+func normalizeRedactions(tr *trace.LLMTrace) *trace.LLMTrace {
+	normalized := *tr
+
+	var body interface{}
+	if err := json.Unmarshal(tr.Response.Body, &body); err != nil {
+		normalized.Response.Body = []byte(redact.Normalize(string(tr.Response.Body)))
+		return &normalized
+	}
+
+	if data, err := json.Marshal(redactValue(body)); err == nil {
+		normalized.Response.Body = data
+	}
+	return &normalized
+}
+
+// normalizeRedactedCheck applies redact.Normalize to the expected text of text-comparison checks.
+func normalizeRedactedCheck(raw string) string {
+	idx := strings.Index(raw, ":")
+	if idx <= 0 {
+		return raw
+	}
+
+	switch strings.TrimSpace(raw[:idx]) {
+	case "exact", "contains", "not_contains", "contains_any":
+		return raw[:idx+1] + redact.Normalize(raw[idx+1:])
+	default:
+		return raw
+	}
+}
+
+// redactValue applies redact.Normalize to every string within a decoded JSON value.
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return redact.Normalize(val)
+	case map[string]interface{}:
+		for k, child := range val {
+			val[k] = redactValue(child)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// RollingBaseline aggregates results, oldest first, into one baseline. Each test passes
+// when its median pass rate across the results is at least one half (a test's pass rate
+// is 1 or 0, or its rate across runs with --runs), and its latency is the p95 of its
+// latencies, so one lucky or unlucky run doesn't set the bar. Token usage and rubric
+// scores are medians, and run statistics pool the runs of every result; outputs and
+// everything else come from the most recent result.
+func RollingBaseline(results []*EvalResult) *EvalResult {
+	if len(results) == 0 {
+		return nil
+	}
+	latest := results[len(results)-1]
+	baseline := *latest
+	baseline.Comparison, baseline.Regressions = nil, 0
+	baseline.TestResults = nil
+
+	// Tests keep the order of the most recent result they appear in
+	var order []string
+	history := make(map[string][]TestResult)
+	for i := len(results) - 1; i >= 0; i-- {
+		for _, tr := range results[i].TestResults {
+			if _, seen := history[tr.Name]; !seen {
+				order = append(order, tr.Name)
+			}
+			history[tr.Name] = append([]TestResult{tr}, history[tr.Name]...)
+		}
+	}
+
+	for _, name := range order {
+		baseline.TestResults = append(baseline.TestResults, rollingTestResult(history[name]))
+	}
+	baseline.Recount()
+	return &baseline
+}
+
+// rollingTestResult merges a test's results, oldest first.
+func rollingTestResult(runs []TestResult) TestResult {
+	merged := runs[len(runs)-1]
+
+	var rates, tokens, scores []float64
+	var latencies []time.Duration
+	// Pooled outcomes for the run statistics: a result with stats counts all its runs
+	var passes, evaluated int
+	sampled := false
+	for _, tr := range runs {
+		if tr.Status == "skipped" {
+			continue
+		}
+		switch {
+		case tr.Stats != nil:
+			rates = append(rates, tr.Stats.PassRate)
+			passes += tr.Stats.Passes
+			evaluated += tr.Stats.Runs
+			sampled = true
+		case tr.Status == "passed":
+			rates = append(rates, 1)
+			passes++
+			evaluated++
+		default:
+			rates = append(rates, 0)
+			evaluated++
+		}
+		if tr.Latency > 0 {
+			latencies = append(latencies, tr.Latency)
+		}
+		if tr.TokensOut > 0 {
+			tokens = append(tokens, float64(tr.TokensOut))
+		}
+		if tr.Score != nil {
+			scores = append(scores, *tr.Score)
+		}
+	}
+	// The latest result's statistics describe one result, not the aggregate
+	merged.Stats = nil
+	if len(rates) == 0 {
+		return merged
+	}
+	if sampled {
+		ms := make([]float64, len(latencies))
+		for i, l := range latencies {
+			ms[i] = float64(l)
+		}
+		merged.Stats = newRunStats(passes, evaluated, ms, tokens)
+	}
+
+	merged.Status = "failed"
+	if median(rates) >= 0.5 {
+		merged.Status = "passed"
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		rank := int(math.Ceil(0.95*float64(len(latencies)))) - 1
+		merged.Latency = latencies[max(rank, 0)]
+	}
+	if len(tokens) > 0 {
+		merged.TokensOut = int(median(tokens))
+	}
+	if len(scores) > 0 {
+		score := median(scores)
+		merged.Score = &score
+	}
+	return merged
+}
+
+// median returns the median of values, which must not be empty.
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
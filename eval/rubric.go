@@ -0,0 +1,175 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// Rubric scales. Scores are reported on the scale and normalized to 0-1 for aggregation.
+var rubricScales = map[string][2]float64{
+	"0-1": {0, 1},
+	"1-5": {1, 5},
+}
+
+// rubricSpec is the parameter of a rubric check.
+type rubricSpec struct {
+	Criteria string   `json:"criteria"`
+	Scale    string   `json:"scale"`
+	MinScore *float64 `json:"min_score"`
+}
+
+// parseRubricSpec accepts a JSON object (from the YAML map form) with criteria, an
+// optional scale (1-5 by default), and an optional min_score, or the criteria alone.
+func parseRubricSpec(param string) (rubricSpec, error) {
+	var spec rubricSpec
+	if strings.HasPrefix(strings.TrimSpace(param), "{") {
+		if err := json.Unmarshal([]byte(param), &spec); err != nil {
+			return spec, fmt.Errorf("invalid rubric parameters: %w", err)
+		}
+	} else {
+		spec.Criteria = param
+	}
+	if spec.Criteria == "" {
+		return spec, fmt.Errorf("rubric needs criteria")
+	}
+	if spec.Scale == "" {
+		spec.Scale = "1-5"
+	}
+	if _, ok := rubricScales[spec.Scale]; !ok {
+		return spec, fmt.Errorf("invalid rubric scale %q (must be 0-1 or 1-5)", spec.Scale)
+	}
+	return spec, nil
+}
+
+const rubricSystemPrompt = `You are a strict, consistent grader of AI assistant responses.
+Grade the response against the rubric only. Reply with a single JSON object and nothing else:
+{"score": <number on the given scale>, "reason": "<one sentence>"}`
+
+// checkRubric has the judge grade the response against rubric criteria. The check
+// records the score (normalized to 0-1) and passes when no min_score is set or the
+// score reaches it, so rubric checks can be used for graded scoring alone.
+func checkRubric(tr *trace.LLMTrace, param string) CheckResult {
+	result := CheckResult{Check: "rubric: " + param}
+
+	spec, err := parseRubricSpec(param)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	if activeJudge == nil {
+		result.Message = "No judge is available (rubric checks are disabled with --offline)"
+		result.Skipped, result.transient = true, true
+		return result
+	}
+
+	scale := rubricScales[spec.Scale]
+	prompt := fmt.Sprintf("Rubric: %s\nScale: %g (worst) to %g (best)\n\nConversation:\n%s\n\nResponse to grade:\n%s",
+		spec.Criteria, scale[0], scale[1], ExtractPromptText(tr), extractResponseText(tr))
+	reply, err := activeJudge.Complete(rubricSystemPrompt, prompt)
+	if err != nil {
+		result.Message = fmt.Sprintf("rubric: %v", err)
+		result.errored, result.transient = true, true
+		return result
+	}
+
+	score, reason, err := parseJudgeReply(reply)
+	if err != nil {
+		result.Message = fmt.Sprintf("rubric: %v", err)
+		result.errored, result.transient = true, true
+		return result
+	}
+	score = math.Max(scale[0], math.Min(scale[1], score))
+	normalized := (score - scale[0]) / (scale[1] - scale[0])
+	result.Score = &normalized
+
+	result.Passed = spec.MinScore == nil || score >= *spec.MinScore
+	result.Message = fmt.Sprintf("Scored %g/%g", score, scale[1])
+	if spec.MinScore != nil {
+		result.Message += fmt.Sprintf(" (min %g)", *spec.MinScore)
+	}
+	if reason != "" {
+		result.Message += ": " + reason
+	}
+	return result
+}
+
+// parseJudgeReply extracts the score and reason from the judge's JSON reply, tolerating
+// surrounding prose or code fences.
+func parseJudgeReply(reply string) (float64, string, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return 0, "", fmt.Errorf("judge reply has no JSON object: %q", reply)
+	}
+	var parsed struct {
+		Score  *float64 `json:"score"`
+		Reason string   `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil || parsed.Score == nil {
+		return 0, "", fmt.Errorf("judge reply has no score: %q", reply)
+	}
+	return *parsed.Score, parsed.Reason, nil
+}
+
+// meanScore returns the mean of the scores recorded on checks, or nil if none has one.
+func meanScore(checks []CheckResult) *float64 {
+	var sum float64
+	n := 0
+	for _, c := range checks {
+		if c.Score != nil {
+			sum += *c.Score
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	mean := sum / float64(n)
+	return &mean
+}
+
+// ScoreDistribution summarizes the rubric scores (0-1) of the tests in a run.
+type ScoreDistribution struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	Min   float64 `json:"min"`
+	P50   float64 `json:"p50"`
+	Max   float64 `json:"max"`
+
+	// Histogram counts scores in five equal buckets: [0,0.2), [0.2,0.4), ..., [0.8,1].
+	Histogram [5]int `json:"histogram"`
+}
+
+// summarizeScores returns the distribution of test scores, or nil when no test was scored.
+// Drafts and skipped tests are left out.
+func summarizeScores(results []TestResult) *ScoreDistribution {
+	var scores []float64
+	for _, tr := range results {
+		if tr.Score != nil && tr.State != StateDraft && tr.Status != "skipped" {
+			scores = append(scores, *tr.Score)
+		}
+	}
+	if len(scores) == 0 {
+		return nil
+	}
+	sort.Float64s(scores)
+
+	d := &ScoreDistribution{Count: len(scores), Min: scores[0], Max: scores[len(scores)-1]}
+	var sum float64
+	for _, s := range scores {
+		sum += s
+		d.Histogram[min(int(s*5), 4)]++
+	}
+	d.Mean = sum / float64(len(scores))
+	d.P50 = scores[(len(scores)-1)/2]
+	if len(scores)%2 == 0 {
+		d.P50 = (scores[len(scores)/2-1] + scores[len(scores)/2]) / 2
+	}
+	return d
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/trace"
+)
+
+// SampleTraces selects up to n trace indices from a session.
+// Supported strategies:
+//   - diverse: round-robin across groups of traces sharing provider, endpoint, model,
+//     and tool set, so every distinct kind of call is represented before any repeats
+//   - random: uniform random sample
+//   - first: the first n traces in capture order
+//
+// Returned indices are sorted in capture order.
+func SampleTraces(session *trace.TraceSession, n int, strategy string) ([]int, error) {
+	total := len(session.Traces)
+	if n <= 0 || n > total {
+		n = total
+	}
+
+	var picked []int
+	switch strategy {
+	case "first":
+		for i := 0; i < n; i++ {
+			picked = append(picked, i)
+		}
+
+	case "random":
+		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+		picked = rng.Perm(total)[:n]
+
+	case "diverse", "":
+		groups := make(map[string][]int)
+		var keys []string
+		for i := range session.Traces {
+			key := traceShape(&session.Traces[i])
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], i)
+		}
+
+		for round := 0; len(picked) < n; round++ {
+			for _, key := range keys {
+				if round < len(groups[key]) && len(picked) < n {
+					picked = append(picked, groups[key][round])
+				}
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown sampling strategy: %s (must be one of: diverse, random, first)", strategy)
+	}
+
+	sort.Ints(picked)
+	return picked, nil
+}
+
+// traceShape summarizes the structural features of a trace used to group similar calls.
+func traceShape(tr *trace.LLMTrace) string {
+	tools := make([]string, 0, len(tr.ToolCalls))
+	for _, tc := range tr.ToolCalls {
+		tools = append(tools, tc.Name)
+	}
+	sort.Strings(tools)
+
+	return strings.Join([]string{tr.Provider, tr.Endpoint, tr.Model, strings.Join(tools, ",")}, "|")
+}
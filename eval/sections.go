@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import "github.com/matias/regrada/config"
+
+// OtherSection titles the section collecting tests that match no configured section.
+const OtherSection = "Other"
+
+// SectionSummary tallies the tests of one report section.
+type SectionSummary struct {
+	Title       string   `json:"title"`
+	Total       int      `json:"total"`
+	Passed      int      `json:"passed"`
+	Failed      int      `json:"failed"`
+	Regressions int      `json:"regressions,omitempty"`
+	FailedTests []string `json:"failed_tests,omitempty"`
+}
+
+// GroupSections groups test results into the configured report sections by tag.
+// A test appears in every section sharing one of its tags; tests in no section are
+// collected under "Other". Sections are returned in configuration order.
+func GroupSections(result *EvalResult, sections []config.ReportSection) []SectionSummary {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	summaries := make([]SectionSummary, len(sections))
+	for i, s := range sections {
+		summaries[i].Title = s.Title
+	}
+	other := SectionSummary{Title: OtherSection}
+
+	for _, tr := range result.TestResults {
+		matched := false
+		for i, s := range sections {
+			if sharesTag(tr.Tags, s.Tags) {
+				addToSection(&summaries[i], tr)
+				matched = true
+			}
+		}
+		if !matched {
+			addToSection(&other, tr)
+		}
+	}
+
+	if other.Total > 0 {
+		summaries = append(summaries, other)
+	}
+	return summaries
+}
+
+func addToSection(s *SectionSummary, tr TestResult) {
+	if tr.Status == "skipped" || tr.State == StateDraft || tr.XFail != "" {
+		return
+	}
+	s.Total++
+	if tr.Status == "passed" {
+		s.Passed++
+		return
+	}
+	s.Failed++
+	s.FailedTests = append(s.FailedTests, tr.Name)
+	if tr.Regression {
+		s.Regressions++
+	}
+}
+
+func sharesTag(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// TraceSelector picks a test's trace by what the call was rather than its position in
+// the session. All set fields must match.
+type TraceSelector struct {
+	Endpoint string `yaml:"endpoint,omitempty"` // Substring of the request path, e.g. /embeddings
+	Provider string `yaml:"provider,omitempty"` // Provider the call went to, e.g. anthropic
+	Model    string `yaml:"model,omitempty"`    // Model name, case-insensitive
+	Prompt   string `yaml:"prompt,omitempty"`   // Substring of the prompt text, case-insensitive
+	Case     string `yaml:"case,omitempty"`     // Test case the call was tagged with (X-Regrada-Case header or REGRADA_CASE)
+
+	// Index chooses among the matching traces: 0 for the first, -1 for the last.
+	Index int `yaml:"index,omitempty"`
+}
+
+// String describes the selector for error messages.
+func (s *TraceSelector) String() string {
+	var parts []string
+	if s.Endpoint != "" {
+		parts = append(parts, "endpoint="+s.Endpoint)
+	}
+	if s.Provider != "" {
+		parts = append(parts, "provider="+s.Provider)
+	}
+	if s.Model != "" {
+		parts = append(parts, "model="+s.Model)
+	}
+	if s.Prompt != "" {
+		parts = append(parts, fmt.Sprintf("prompt=%q", s.Prompt))
+	}
+	if s.Case != "" {
+		parts = append(parts, "case="+s.Case)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matches reports whether a trace satisfies every field of the selector.
+func (s *TraceSelector) matches(tr *trace.LLMTrace) bool {
+	if s.Endpoint != "" && !strings.Contains(tr.Endpoint, s.Endpoint) {
+		return false
+	}
+	if s.Provider != "" && !strings.EqualFold(tr.Provider, s.Provider) {
+		return false
+	}
+	if s.Model != "" && !strings.EqualFold(tr.Model, s.Model) {
+		return false
+	}
+	if s.Prompt != "" && !strings.Contains(strings.ToLower(ExtractPromptText(tr)), strings.ToLower(s.Prompt)) {
+		return false
+	}
+	if s.Case != "" && traceCase(tr) != s.Case {
+		return false
+	}
+	return true
+}
+
+// selectTrace returns the selector's Index-th matching trace in the session.
+func (s *TraceSelector) selectTrace(session *trace.TraceSession) (*trace.LLMTrace, error) {
+	var matching []*trace.LLMTrace
+	for i := range session.Traces {
+		if s.matches(&session.Traces[i]) {
+			matching = append(matching, &session.Traces[i])
+		}
+	}
+	if len(matching) == 0 {
+		return nil, fmt.Errorf("no trace matches select (%s)", s)
+	}
+
+	index := s.Index
+	if index < 0 {
+		index += len(matching)
+	}
+	if index < 0 || index >= len(matching) {
+		return nil, fmt.Errorf("select index %d out of range (%d traces match %s)", s.Index, len(matching), s)
+	}
+	return matching[index], nil
+}
+
+// testSelector returns the selector for a test's trace, or nil when the test picks it by
+// trace_index alone. The test's provider and model narrow its select; without one,
+// trace_index counts among the calls to that provider and model.
+func testSelector(test TestCase) *TraceSelector {
+	if test.Provider == "" && test.Model == "" {
+		return test.Select
+	}
+	sel := TraceSelector{Index: test.TraceIndex}
+	if test.Select != nil {
+		sel = *test.Select
+	}
+	if sel.Provider == "" {
+		sel.Provider = test.Provider
+	}
+	if sel.Model == "" {
+		sel.Model = test.Model
+	}
+	return &sel
+}
+
+// traceCase returns the test case a trace was tagged with. Traces recorded before the
+// proxy stored the case in metadata still carry it in their request headers, whose
+// names depend on the client, so those are matched case-insensitively.
+func traceCase(tr *trace.LLMTrace) string {
+	if c := tr.Case(); c != "" {
+		return c
+	}
+	for key, value := range tr.Request.Headers {
+		if strings.EqualFold(key, trace.CaseHeader) {
+			return value
+		}
+	}
+	return ""
+}
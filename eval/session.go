@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/trace"
+)
+
+// SessionTestName is the name of the result holding a suite's session checks.
+const SessionTestName = "session"
+
+// evaluatedTests returns the suite's tests, followed by a pseudo-test carrying its
+// session checks when it has any.
+func evaluatedTests(suite *TestSuite) []TestCase {
+	if len(suite.SessionChecks) == 0 {
+		return suite.Tests
+	}
+	tests := make([]TestCase, 0, len(suite.Tests)+1)
+	tests = append(tests, suite.Tests...)
+	return append(tests, TestCase{Name: SessionTestName, Checks: suite.SessionChecks, Vars: suite.Vars, sessionLevel: true})
+}
+
+// RunSessionChecks runs session checks over every trace in the session and returns
+// them as the result of the session pseudo-test.
+// Supported checks:
+//   - total_calls:<op><N>          - Compares the number of LLM calls (op: <=, <, >=, >, ==; default ==)
+//   - tool_sequence:[a, b]         - Verifies tools were called in this relative order across all calls
+//   - no_duplicate_calls           - Verifies no LLM request and no tool call (name and arguments) repeats
+func RunSessionChecks(checks []Check, session *trace.TraceSession) TestResult {
+	startTime := time.Now()
+	result := TestResult{
+		Name:         SessionTestName,
+		Status:       "passed",
+		CheckResults: make([]CheckResult, 0, len(checks)),
+	}
+
+	for _, check := range checks {
+		checkResult := runSessionCheck(check.Raw, session)
+		result.CheckResults = append(result.CheckResults, checkResult)
+		if !checkResult.Passed {
+			result.Status = "failed"
+		}
+	}
+
+	result.Duration = time.Since(startTime) / time.Millisecond
+	return result
+}
+
+func runSessionCheck(check string, session *trace.TraceSession) CheckResult {
+	checkType := check
+	var checkParam string
+	if idx := strings.Index(check, ":"); idx > 0 {
+		checkType = strings.TrimSpace(check[:idx])
+		checkParam = strings.TrimSpace(check[idx+1:])
+	}
+
+	switch checkType {
+	case "total_calls":
+		return checkTotalCalls(session, checkParam)
+	case "tool_sequence":
+		return checkToolSequence(session, checkParam)
+	case "no_duplicate_calls":
+		return checkNoDuplicateCalls(session)
+	default:
+		return CheckResult{Check: check, Message: fmt.Sprintf("Unknown session check type: %s", checkType)}
+	}
+}
+
+// checkTotalCalls compares the number of LLM calls in the session with a bound such as "<= 5".
+func checkTotalCalls(session *trace.TraceSession, param string) CheckResult {
+	result := CheckResult{Check: "total_calls: " + param}
+
+	op, operand := "==", strings.TrimSpace(param)
+	for _, candidate := range []string{"<=", ">=", "==", "<", ">"} {
+		if strings.HasPrefix(operand, candidate) {
+			op, operand = candidate, strings.TrimSpace(operand[len(candidate):])
+			break
+		}
+	}
+	limit, err := strconv.Atoi(operand)
+	if err != nil {
+		result.Message = fmt.Sprintf("Invalid call count: %q (expected e.g. <= 5)", param)
+		return result
+	}
+
+	n := len(session.Traces)
+	switch op {
+	case "<=":
+		result.Passed = n <= limit
+	case ">=":
+		result.Passed = n >= limit
+	case "<":
+		result.Passed = n < limit
+	case ">":
+		result.Passed = n > limit
+	default:
+		result.Passed = n == limit
+	}
+	result.Message = fmt.Sprintf("Session made %d calls (expected %s %d)", n, op, limit)
+	return result
+}
+
+// checkToolSequence verifies that the tools in a JSON array were called in that relative
+// order over the whole session. Other calls may come in between.
+func checkToolSequence(session *trace.TraceSession, param string) CheckResult {
+	result := CheckResult{Check: "tool_sequence: " + param}
+
+	var sequence []string
+	if err := json.Unmarshal([]byte(param), &sequence); err != nil {
+		result.Message = fmt.Sprintf("Invalid tool sequence (expected a JSON array of tool names): %v", err)
+		return result
+	}
+
+	var called []string
+	for i := range session.Traces {
+		called = append(called, toolNames(&session.Traces[i])...)
+	}
+	next := 0
+	for _, name := range called {
+		if next < len(sequence) && name == sequence[next] {
+			next++
+		}
+	}
+
+	result.Passed = next == len(sequence)
+	if result.Passed {
+		result.Message = fmt.Sprintf("Tools were called in sequence: %s", strings.Join(sequence, " → "))
+	} else {
+		result.Message = fmt.Sprintf("Expected tool sequence %s, but '%s' was not called after the previous ones (called: %s)",
+			strings.Join(sequence, " → "), sequence[next], describeCalls(called))
+	}
+	return result
+}
+
+// checkNoDuplicateCalls fails when two LLM calls in the session sent the same request
+// to the same endpoint, or the model made the same tool call (name and arguments) twice,
+// as an agent stuck in a loop does.
+func checkNoDuplicateCalls(session *trace.TraceSession) CheckResult {
+	result := CheckResult{Check: "no_duplicate_calls"}
+
+	requests := make(map[string]int)
+	tools := make(map[string]int)
+	for i, tr := range session.Traces {
+		key := tr.Request.Method + " " + tr.Request.Path + " " + string(compactJSON(tr.Request.Body))
+		if first, ok := requests[key]; ok {
+			result.Message = fmt.Sprintf("Calls %d and %d sent the same request to %s", first+1, i+1, tr.Request.Path)
+			return result
+		}
+		requests[key] = i
+
+		for _, tc := range tr.ToolCalls {
+			key := tc.Name + " " + string(compactJSON(tc.Args))
+			if first, ok := tools[key]; ok {
+				result.Message = fmt.Sprintf("Tool '%s' was called with the same arguments in calls %d and %d", tc.Name, first+1, i+1)
+				return result
+			}
+			tools[key] = i
+		}
+	}
+
+	result.Passed = true
+	result.Message = fmt.Sprintf("No duplicate calls in %d calls", len(session.Traces))
+	return result
+}
+
+// compactJSON removes insignificant whitespace from JSON so formatting differences
+// don't hide duplicates. Invalid JSON is returned unchanged.
+func compactJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/trace"
+)
+
+// defaultMinSimilarity is the cosine similarity similar_to requires when no min is given.
+const defaultMinSimilarity = 0.8
+
+// Embedder turns texts into embedding vectors, one per text, in order.
+type Embedder interface {
+	Embed(texts []string) ([][]float64, error)
+}
+
+// activeEmbedder computes embeddings for similar_to checks when set with UseEmbedder.
+var activeEmbedder Embedder
+
+// UseEmbedder makes similar_to checks use e. Pass nil to disable them.
+func UseEmbedder(e Embedder) {
+	activeEmbedder = e
+}
+
+// httpEmbedder calls an OpenAI-style /embeddings endpoint or Ollama's /api/embed,
+// remembering vectors for texts it has already embedded in this run.
+type httpEmbedder struct {
+	provider string
+	model    string
+	baseURL  string
+	apiKey   string
+	client   *http.Client
+	limiter  *rateLimiter
+
+	mu    sync.Mutex
+	cache map[string][]float64
+}
+
+// NewEmbedder creates an embedder for the configured provider: openai (default, also
+// any OpenAI-compatible endpoint via base_url) or ollama.
+func NewEmbedder(cfg config.EmbeddingsConfig) (Embedder, error) {
+	e := &httpEmbedder{
+		provider: cfg.Provider,
+		model:    cfg.Model,
+		baseURL:  strings.TrimSuffix(cfg.BaseURL, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+		limiter:  newRateLimiter(cfg.RateLimit),
+		cache:    make(map[string][]float64),
+	}
+
+	switch e.provider {
+	case "", "openai":
+		e.provider = "openai"
+		if e.model == "" {
+			e.model = "text-embedding-3-small"
+		}
+		if e.baseURL == "" {
+			e.baseURL = "https://api.openai.com/v1"
+		}
+		keyEnv := cfg.APIKeyEnv
+		if keyEnv == "" {
+			keyEnv = "OPENAI_API_KEY"
+		}
+		e.apiKey = os.Getenv(keyEnv)
+	case "ollama":
+		if e.model == "" {
+			e.model = "nomic-embed-text"
+		}
+		if e.baseURL == "" {
+			e.baseURL = "http://localhost:11434"
+		}
+	default:
+		return nil, fmt.Errorf("invalid embeddings provider: %s (must be openai or ollama)", cfg.Provider)
+	}
+
+	return e, nil
+}
+
+// Embed returns the embeddings of texts, calling the provider only for texts not seen before.
+func (e *httpEmbedder) Embed(texts []string) ([][]float64, error) {
+	var missing []string
+	e.mu.Lock()
+	for _, text := range texts {
+		if _, ok := e.cache[text]; !ok {
+			missing = append(missing, text)
+		}
+	}
+	e.mu.Unlock()
+
+	if len(missing) > 0 {
+		vectors, err := e.request(missing)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(missing) {
+			return nil, fmt.Errorf("embeddings provider returned %d vectors for %d texts", len(vectors), len(missing))
+		}
+		e.mu.Lock()
+		for i, text := range missing {
+			e.cache[text] = vectors[i]
+		}
+		e.mu.Unlock()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		out[i] = e.cache[text]
+	}
+	return out, nil
+}
+
+// request calls the provider's embeddings endpoint.
+func (e *httpEmbedder) request(texts []string) ([][]float64, error) {
+	url := e.baseURL + "/embeddings"
+	if e.provider == "ollama" {
+		url = e.baseURL + "/api/embed"
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"model": e.model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	e.limiter.wait(estimateTokens(texts...))
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embeddings request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embeddings provider returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var parsed struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid embeddings response: %w", err)
+	}
+	if e.provider == "ollama" {
+		return parsed.Embeddings, nil
+	}
+
+	vectors := make([][]float64, len(parsed.Data))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("invalid embeddings response: index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// similaritySpec is the parameter of a similar_to check.
+type similaritySpec struct {
+	Text string   `json:"text"`
+	Min  *float64 `json:"min"`
+}
+
+// parseSimilaritySpec accepts a JSON object (from the YAML map form) with text and an
+// optional min, or the reference text itself.
+func parseSimilaritySpec(param string) (similaritySpec, error) {
+	var spec similaritySpec
+	if strings.HasPrefix(strings.TrimSpace(param), "{") {
+		if err := json.Unmarshal([]byte(param), &spec); err != nil {
+			return spec, fmt.Errorf("invalid similar_to parameters: %w", err)
+		}
+	} else {
+		spec.Text = param
+	}
+	if spec.Text == "" {
+		return spec, fmt.Errorf("similar_to needs a reference text")
+	}
+	if spec.Min == nil {
+		threshold := defaultMinSimilarity
+		spec.Min = &threshold
+	}
+	return spec, nil
+}
+
+// checkSimilarTo verifies the response means the same as a reference text: the cosine
+// similarity of their embeddings must be at least min. Paraphrases pass; answers that
+// say something different fail.
+func checkSimilarTo(tr *trace.LLMTrace, param string) CheckResult {
+	result := CheckResult{Check: "similar_to: " + param}
+
+	spec, err := parseSimilaritySpec(param)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	// Embeddings APIs reject empty input, and an empty response is simply not similar
+	response := extractResponseText(tr)
+	if strings.TrimSpace(response) == "" {
+		result.Message = fmt.Sprintf("Response is empty (similarity 0.000, minimum %.2f)", *spec.Min)
+		return result
+	}
+	if activeEmbedder == nil {
+		result.Message = "No embeddings provider is available (similar_to is disabled with --offline)"
+		result.Skipped, result.transient = true, true
+		return result
+	}
+
+	vectors, err := activeEmbedder.Embed([]string{spec.Text, response})
+	if err != nil {
+		result.Message = fmt.Sprintf("similar_to: %v", err)
+		result.errored, result.transient = true, true
+		return result
+	}
+
+	similarity := cosineSimilarity(vectors[0], vectors[1])
+	result.Passed = similarity >= *spec.Min
+	if result.Passed {
+		result.Message = fmt.Sprintf("Similarity %.3f to the reference is at least %.2f", similarity, *spec.Min)
+	} else {
+		result.Message = fmt.Sprintf("Similarity %.3f to the reference is below %.2f", similarity, *spec.Min)
+	}
+	return result
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0 if either is
+// empty or they differ in length.
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// AllTag labels SLO rows that cover every test in the run.
+const AllTag = "all"
+
+// renamedAllTag labels the rows of tests tagged "all", which would otherwise be merged
+// into the AllTag rows.
+const renamedAllTag = "all (tag)"
+
+// SLOCompliance is the share of tests in a tag/model group that met a latency SLO.
+type SLOCompliance struct {
+	SLO        string   `json:"slo"`
+	Tag        string   `json:"tag"`
+	Model      string   `json:"model"`
+	Threshold  string   `json:"threshold"`
+	Target     float64  `json:"target"`
+	Total      int      `json:"total"`
+	Met        int      `json:"met"`
+	Compliance float64  `json:"compliance"`
+	Compliant  bool     `json:"compliant"`
+	Baseline   *float64 `json:"baseline_compliance,omitempty"`
+}
+
+// LatencySLOReport computes, for each latency SLO, the share of tests meeting it per tag
+// and model, plus an "all" row per model covering the whole run. Tests without a recorded
+// latency (or, for ttft SLOs, without a streamed trace) are left out. When baseline is
+// non-nil, each row carries the baseline's compliance for the same group.
+func LatencySLOReport(result, baseline *EvalResult, slos []config.LatencySLO) []SLOCompliance {
+	var report []SLOCompliance
+	for _, slo := range slos {
+		threshold, err := time.ParseDuration(slo.Threshold)
+		if err != nil {
+			continue
+		}
+		name := slo.Name
+		if name == "" {
+			name = fmt.Sprintf("%.0f%% under %s", slo.Target*100, slo.Threshold)
+			if slo.Metric == "ttft" {
+				name += " to first token"
+			}
+		}
+
+		current := sloGroups(result, slo.Metric, threshold)
+		var previous map[sloKey]*sloCount
+		if baseline != nil {
+			previous = sloGroups(baseline, slo.Metric, threshold)
+		}
+
+		keys := make([]sloKey, 0, len(current))
+		for key := range current {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if (keys[i].tag == AllTag) != (keys[j].tag == AllTag) {
+				return keys[i].tag == AllTag
+			}
+			if keys[i].tag != keys[j].tag {
+				return keys[i].tag < keys[j].tag
+			}
+			return keys[i].model < keys[j].model
+		})
+
+		for _, key := range keys {
+			count := current[key]
+			row := SLOCompliance{
+				SLO:        name,
+				Tag:        key.tag,
+				Model:      key.model,
+				Threshold:  slo.Threshold,
+				Target:     slo.Target,
+				Total:      count.total,
+				Met:        count.met,
+				Compliance: count.rate(),
+			}
+			row.Compliant = row.Compliance >= slo.Target
+			if prev, ok := previous[key]; ok {
+				rate := prev.rate()
+				row.Baseline = &rate
+			}
+			report = append(report, row)
+		}
+	}
+	return report
+}
+
+type sloKey struct {
+	tag   string
+	model string
+}
+
+type sloCount struct {
+	total int
+	met   int
+}
+
+func (c *sloCount) rate() float64 {
+	if c.total == 0 {
+		return 0
+	}
+	return float64(c.met) / float64(c.total)
+}
+
+// sloGroups counts tests whose metric (latency or ttft) meets the threshold per tag/model group.
+func sloGroups(result *EvalResult, metric string, threshold time.Duration) map[sloKey]*sloCount {
+	groups := make(map[sloKey]*sloCount)
+	add := func(key sloKey, met bool) {
+		c, ok := groups[key]
+		if !ok {
+			c = &sloCount{}
+			groups[key] = c
+		}
+		c.total++
+		if met {
+			c.met++
+		}
+	}
+
+	for _, tr := range result.TestResults {
+		value := tr.Latency
+		if metric == "ttft" {
+			value = tr.TTFT
+		}
+		if value <= 0 {
+			continue
+		}
+		// Trace latencies are recorded in milliseconds
+		met := value*time.Millisecond <= threshold
+		add(sloKey{tag: AllTag, model: tr.Model}, met)
+		for _, tag := range tr.Tags {
+			if tag == AllTag {
+				tag = renamedAllTag
+			}
+			add(sloKey{tag: tag, model: tr.Model}, met)
+		}
+	}
+	return groups
+}
+
+// WriteSLOCSV writes an SLO report as CSV for dashboards.
+func WriteSLOCSV(report []SLOCompliance, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"slo", "tag", "model", "threshold", "target", "total", "met", "compliance", "compliant", "baseline_compliance"})
+	for _, row := range report {
+		baseline := ""
+		if row.Baseline != nil {
+			baseline = strconv.FormatFloat(*row.Baseline, 'f', 4, 64)
+		}
+		w.Write([]string{
+			row.SLO,
+			row.Tag,
+			row.Model,
+			row.Threshold,
+			strconv.FormatFloat(row.Target, 'f', 4, 64),
+			strconv.Itoa(row.Total),
+			strconv.Itoa(row.Met),
+			strconv.FormatFloat(row.Compliance, 'f', 4, 64),
+			strconv.FormatBool(row.Compliant),
+			baseline,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
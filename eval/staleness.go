@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// BaselineStaleness returns why baseline is out of date for comparing current with
+// under the policy: it was saved more than MaxAge before current, or it recorded calls
+// to a retired model.
+func BaselineStaleness(current, baseline *EvalResult, policy config.BaselineStalenessPolicy) []string {
+	var reasons []string
+	if maxAge, err := time.ParseDuration(policy.MaxAge); err == nil && maxAge > 0 && !baseline.Timestamp.IsZero() {
+		if age := current.Timestamp.Sub(baseline.Timestamp); age > maxAge {
+			reasons = append(reasons, fmt.Sprintf("saved %s ago (max %s)", formatAge(age), formatAge(maxAge)))
+		}
+	}
+
+	models := make(map[string]bool)
+	if baseline.Provenance != nil && baseline.Provenance.Model != "" {
+		models[baseline.Provenance.Model] = true
+	}
+	for _, tr := range baseline.TestResults {
+		if tr.Model != "" {
+			models[tr.Model] = true
+		}
+	}
+	var retired []string
+	for model := range models {
+		for _, prefix := range policy.RetiredModels {
+			if prefix != "" && strings.HasPrefix(model, prefix) {
+				retired = append(retired, model)
+				break
+			}
+		}
+	}
+	if len(retired) > 0 {
+		sort.Strings(retired)
+		reasons = append(reasons, "recorded with retired model "+strings.Join(retired, ", "))
+	}
+	return reasons
+}
+
+// formatAge formats a duration in whole days, or hours under two days.
+func formatAge(d time.Duration) string {
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%.0fh", d.Hours())
+	}
+	return fmt.Sprintf("%.0f days", d.Hours()/24)
+}
@@ -0,0 +1,348 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/trace"
+)
+
+// MinStatRuns is the number of runs needed before baseline comparisons use
+// significance tests instead of comparing pass/fail status directly.
+const MinStatRuns = 5
+
+// z95 is the two-sided critical value for 95% confidence.
+const z95 = 1.959964
+
+// RunStats summarizes a test evaluated across several trace sessions.
+// Intervals are 95% confidence intervals; latencies are in milliseconds.
+type RunStats struct {
+	Runs         int     `json:"runs"`
+	Passes       int     `json:"passes"`
+	PassRate     float64 `json:"pass_rate"`
+	PassRateLow  float64 `json:"pass_rate_low"`
+	PassRateHigh float64 `json:"pass_rate_high"`
+	LatencyMean  float64 `json:"latency_mean_ms,omitempty"`
+	LatencyLow   float64 `json:"latency_low_ms,omitempty"`
+	LatencyHigh  float64 `json:"latency_high_ms,omitempty"`
+
+	// PassRateCV is the coefficient of variation (stddev / mean) of the pass/fail outcome
+	// across runs: 0 for a test that always passes, growing as it gets flakier.
+	PassRateCV float64 `json:"pass_rate_cv,omitempty"`
+
+	// Spread of latency and output tokens across runs (sample standard deviation).
+	LatencyStdDev   float64 `json:"latency_stddev_ms,omitempty"`
+	LatencyMin      float64 `json:"latency_min_ms,omitempty"`
+	LatencyMax      float64 `json:"latency_max_ms,omitempty"`
+	TokensOutMean   float64 `json:"tokens_out_mean,omitempty"`
+	TokensOutStdDev float64 `json:"tokens_out_stddev,omitempty"`
+	TokensOutMin    int     `json:"tokens_out_min,omitempty"`
+	TokensOutMax    int     `json:"tokens_out_max,omitempty"`
+
+	// ScoreMean, ScoreMin, and ScoreMax describe the rubric scores (0-1) across runs.
+	ScoreMean *float64 `json:"score_mean,omitempty"`
+	ScoreMin  *float64 `json:"score_min,omitempty"`
+	ScoreMax  *float64 `json:"score_max,omitempty"`
+
+	// PassAtK holds unbiased pass@k estimates ("pass@1", "pass@3", ...) for the k in
+	// passAtKValues up to the number of runs: the chance that at least one of k samples passes.
+	PassAtK map[string]float64 `json:"pass_at_k,omitempty"`
+
+	// MajorityPassed is the majority vote: whether the runs giving the most common output
+	// passed. MajorityShare is the fraction of runs that gave it.
+	MajorityPassed bool    `json:"majority_passed"`
+	MajorityShare  float64 `json:"majority_share,omitempty"`
+}
+
+// passAtKValues are the k reported in RunStats.PassAtK and EvalResult.PassAtK.
+var passAtKValues = []int{1, 3, 5, 10}
+
+// EvaluateRuns evaluates the suite against each session and merges the results per test.
+// With a single session it is equivalent to EvaluateSuite. Otherwise each test's status is
+// "passed" when it passed in at least half of the runs it was evaluated in, and its
+// RunStats carry the pass rate and latency with confidence intervals.
+func EvaluateRuns(suite *TestSuite, sessions []*trace.TraceSession, onResult func(TestCase, TestResult)) *EvalResult {
+	if len(sessions) == 1 {
+		return EvaluateSuite(suite, sessions[0], onResult)
+	}
+
+	tests := evaluatedTests(suite)
+	result := &EvalResult{
+		Timestamp:   time.Now(),
+		TestSuite:   suite.Name,
+		TotalTests:  len(tests),
+		TestResults: make([]TestResult, 0, len(tests)),
+	}
+
+	// Every (session, test) pair shares one worker pool
+	runs := make([][]TestResult, len(sessions))
+	for s := range runs {
+		runs[s] = make([]TestResult, len(tests))
+	}
+	forEachOrdered(len(sessions)*len(tests), func(job int) {
+		s, i := job/len(tests), job%len(tests)
+		runs[s][i] = evaluateTest(suite, tests[i], sessions[s], result.Timestamp)
+	}, nil)
+
+	for i, test := range tests {
+		merged := runs[0][i]
+		var latencies, tokens, scores []float64
+		var sampled []TestResult
+		passes, evaluated := 0, 0
+		for _, run := range runs {
+			tr := run[i]
+			if tr.Status == "skipped" || tr.Status == "error" {
+				continue
+			}
+			evaluated++
+			sampled = append(sampled, tr)
+			if tr.Status == "passed" {
+				passes++
+			} else {
+				// Report the checks from a failing run
+				merged = tr
+			}
+			if tr.Latency > 0 {
+				latencies = append(latencies, float64(tr.Latency))
+			}
+			if tr.TokensOut > 0 {
+				tokens = append(tokens, float64(tr.TokensOut))
+			}
+			if tr.Score != nil {
+				scores = append(scores, *tr.Score)
+			}
+		}
+
+		if evaluated > 0 {
+			stats := newRunStats(passes, evaluated, latencies, tokens)
+			stats.MajorityPassed, stats.MajorityShare = majorityVote(sampled)
+			if len(scores) > 0 {
+				sort.Float64s(scores)
+				var sum float64
+				for _, s := range scores {
+					sum += s
+				}
+				mean := sum / float64(len(scores))
+				stats.ScoreMean, stats.ScoreMin, stats.ScoreMax = &mean, &scores[0], &scores[len(scores)-1]
+				merged.Score = &mean
+			}
+			merged.Stats = stats
+			merged.Status = "failed"
+			if 2*passes >= evaluated {
+				merged.Status = "passed"
+			}
+		}
+		result.TestResults = append(result.TestResults, merged)
+		result.tally(test, merged)
+
+		if onResult != nil {
+			onResult(test, merged)
+		}
+	}
+
+	result.Scores = summarizeScores(result.TestResults)
+	result.PassAtK = summarizePassAtK(result.TestResults)
+	result.UpdateStatus()
+	return result
+}
+
+func newRunStats(passes, runs int, latencies, tokens []float64) *RunStats {
+	stats := &RunStats{Runs: runs, Passes: passes, PassRate: float64(passes) / float64(runs)}
+	stats.PassRateLow, stats.PassRateHigh = wilsonInterval(passes, runs)
+	stats.PassRateCV = passRateCV(passes, runs)
+	for _, k := range passAtKValues {
+		if k <= runs {
+			if stats.PassAtK == nil {
+				stats.PassAtK = make(map[string]float64)
+			}
+			stats.PassAtK[fmt.Sprintf("pass@%d", k)] = PassAtK(runs, passes, k)
+		}
+	}
+
+	if n := len(latencies); n > 0 {
+		mean, stddev, low, high := spread(latencies)
+		margin := z95 * stddev / math.Sqrt(float64(n))
+		stats.LatencyMean = mean
+		stats.LatencyLow = math.Max(0, mean-margin)
+		stats.LatencyHigh = mean + margin
+		stats.LatencyStdDev, stats.LatencyMin, stats.LatencyMax = stddev, low, high
+	}
+	if len(tokens) > 0 {
+		mean, stddev, low, high := spread(tokens)
+		stats.TokensOutMean, stats.TokensOutStdDev = mean, stddev
+		stats.TokensOutMin, stats.TokensOutMax = int(low), int(high)
+	}
+
+	return stats
+}
+
+// spread returns the mean, sample standard deviation, minimum, and maximum of values,
+// which must not be empty.
+func spread(values []float64) (mean, stddev, low, high float64) {
+	low, high = values[0], values[0]
+	var sum float64
+	for _, v := range values {
+		sum += v
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	mean = sum / float64(len(values))
+
+	if n := len(values); n > 1 {
+		var variance float64
+		for _, v := range values {
+			variance += (v - mean) * (v - mean)
+		}
+		stddev = math.Sqrt(variance / float64(n-1))
+	}
+	return mean, stddev, low, high
+}
+
+// coefficientOfVariation returns stddev / mean, or 0 when the mean is 0.
+func coefficientOfVariation(mean, stddev float64) float64 {
+	if mean == 0 {
+		return 0
+	}
+	return stddev / mean
+}
+
+// passRateCV is the coefficient of variation of the pass/fail outcomes (1 or 0) of runs,
+// passes of which passed, using the sample standard deviation; 0 when nothing passed.
+func passRateCV(passes, runs int) float64 {
+	if runs < 2 {
+		return 0
+	}
+	p := float64(passes) / float64(runs)
+	variance := p * (1 - p) * float64(runs) / float64(runs-1)
+	return coefficientOfVariation(p, math.Sqrt(variance))
+}
+
+// PassAtK returns the unbiased estimate of pass@k from n samples of which c passed:
+// the probability that at least one of k samples drawn without replacement passes.
+// k must be at most n.
+func PassAtK(n, c, k int) float64 {
+	if n-c < k {
+		return 1
+	}
+	// 1 - C(n-c, k) / C(n, k), as a product to avoid large binomials
+	fail := 1.0
+	for i := n - c + 1; i <= n; i++ {
+		fail *= 1 - float64(k)/float64(i)
+	}
+	return 1 - fail
+}
+
+// majorityVote groups runs by output, ignoring case and surrounding whitespace, and
+// returns whether the largest group passed and its share of the runs. Ties go to the
+// group seen first. Runs without output all vote for the same (empty) output.
+func majorityVote(runs []TestResult) (bool, float64) {
+	if len(runs) == 0 {
+		return false, 0
+	}
+	counts := make(map[string]int)
+	passed := make(map[string]bool)
+	best := ""
+	for i, tr := range runs {
+		key := strings.ToLower(strings.TrimSpace(tr.Output))
+		counts[key]++
+		if _, ok := passed[key]; !ok {
+			passed[key] = tr.Status == "passed"
+		}
+		if i == 0 || counts[key] > counts[best] {
+			best = key
+		}
+	}
+	return passed[best], float64(counts[best]) / float64(len(runs))
+}
+
+// summarizePassAtK averages the pass@k estimates of tests evaluated across several runs,
+// for each k every such test has, and adds "majority": the fraction of tests whose
+// majority vote passed. Drafts and skipped tests are left out; nil means no test had stats.
+func summarizePassAtK(results []TestResult) map[string]float64 {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	tests := 0
+	for _, tr := range results {
+		if tr.Stats == nil || tr.State == StateDraft || tr.Status == "skipped" {
+			continue
+		}
+		tests++
+		for key, v := range tr.Stats.PassAtK {
+			sums[key] += v
+			counts[key]++
+		}
+		if tr.Stats.MajorityPassed {
+			sums["majority"]++
+		}
+	}
+	if tests == 0 {
+		return nil
+	}
+
+	out := map[string]float64{"majority": sums["majority"] / float64(tests)}
+	for key, n := range counts {
+		if n == tests {
+			out[key] = sums[key] / float64(n)
+		}
+	}
+	return out
+}
+
+// wilsonInterval returns the 95% Wilson score interval for a binomial proportion.
+func wilsonInterval(passes, runs int) (float64, float64) {
+	if runs == 0 {
+		return 0, 1
+	}
+	n := float64(runs)
+	p := float64(passes) / n
+	z2 := z95 * z95
+
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z95 * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
+
+// passRateShift compares two pass rates with a two-proportion z-test and returns
+// -1 for a significant drop, 1 for a significant rise, and 0 otherwise.
+func passRateShift(baseline, current *RunStats) int {
+	n1, n2 := float64(baseline.Runs), float64(current.Runs)
+	pooled := float64(baseline.Passes+current.Passes) / (n1 + n2)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/n1 + 1/n2))
+	if se == 0 {
+		return 0
+	}
+
+	z := (current.PassRate - baseline.PassRate) / se
+	switch {
+	case z <= -z95:
+		return -1
+	case z >= z95:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// latencyIncrease describes a significant latency increase between two runs, or returns ""
+// when the confidence intervals overlap.
+func latencyIncrease(name string, baseline, current *RunStats) string {
+	if baseline.LatencyMean == 0 || current.LatencyMean == 0 {
+		return ""
+	}
+	if current.LatencyLow <= baseline.LatencyHigh {
+		return ""
+	}
+	return fmt.Sprintf("%s: latency increased from %.0fms to %.0fms (95%% CI %.0f-%.0fms)",
+		name, baseline.LatencyMean, current.LatencyMean, current.LatencyLow, current.LatencyHigh)
+}
+
+// statistical reports whether both results carry enough runs for significance tests.
+func statistical(baseline, current TestResult) bool {
+	return baseline.Stats != nil && current.Stats != nil &&
+		baseline.Stats.Runs >= MinStatRuns && current.Stats.Runs >= MinStatRuns
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"time"
+
+	"github.com/matias/regrada/trace"
+)
+
+// EvaluateSuite runs every test in the suite against a trace session and tallies the results.
+// Lifecycle states are honored: deprecated tests are skipped (or failed past their sunset)
+// and drafts are counted separately so they never gate CI. Tests are evaluated concurrently
+// (see UseConcurrency). If onResult is non-nil it is called after each test, in suite order.
+// Session checks are evaluated last, as a test named "session".
+func EvaluateSuite(suite *TestSuite, session *trace.TraceSession, onResult func(TestCase, TestResult)) *EvalResult {
+	tests := evaluatedTests(suite)
+	result := &EvalResult{
+		Timestamp:   time.Now(),
+		TestSuite:   suite.Name,
+		TotalTests:  len(tests),
+		TestResults: make([]TestResult, len(tests)),
+	}
+	result.PromptSHA256 = session.Metadata["system_prompt_sha256"]
+	result.PromptFiles = PromptFiles(session)
+
+	forEachOrdered(len(tests), func(i int) {
+		result.TestResults[i] = evaluateTest(suite, tests[i], session, result.Timestamp)
+	}, func(i int) {
+		result.tally(tests[i], result.TestResults[i])
+		if onResult != nil {
+			onResult(tests[i], result.TestResults[i])
+		}
+	})
+
+	result.Scores = summarizeScores(result.TestResults)
+	result.UpdateStatus()
+	return result
+}
+
+// evaluateTest runs one test against a session, honoring its lifecycle state, and
+// labels the result with the test's tags, severity, and owner.
+func evaluateTest(suite *TestSuite, test TestCase, session *trace.TraceSession, now time.Time) TestResult {
+	if activeCheckpoint != nil {
+		if testResult, ok := activeCheckpoint.lookup(session.ID, test.Name); ok {
+			return testResult
+		}
+	}
+
+	var testResult TestResult
+	if lifecycle := CheckLifecycle(test, now); lifecycle != nil {
+		testResult = *lifecycle
+	} else {
+		testResult = RunTestInSession(test, session)
+		testResult.State = test.State
+	}
+	testResult.Tags = test.Tags
+	testResult.Severity = test.Severity
+	testResult.XFail = test.XFail
+	testResult.Owner = test.Owner
+	if testResult.Owner == "" {
+		testResult.Owner = suite.Owner
+	}
+
+	// Errors and checks that couldn't reach the judge or embeddings provider may be
+	// transient, so a resumed run evaluates those tests again
+	if activeCheckpoint != nil && testResult.Status != "error" && !hasTransient(testResult) {
+		activeCheckpoint.record(session.ID, testResult)
+	}
+	return testResult
+}
+
+// hasTransient reports whether any of a test's check results depended on an outside
+// provider that was unavailable.
+func hasTransient(tr TestResult) bool {
+	for _, cr := range tr.CheckResults {
+		if cr.transient {
+			return true
+		}
+	}
+	return false
+}
+
+// tally counts a test result towards the run totals.
+func (r *EvalResult) tally(test TestCase, testResult TestResult) {
+	r.Retries += testResult.Retries
+	switch {
+	case testResult.Status == "skipped":
+		r.Skipped++
+	case test.State == StateDraft:
+		r.Drafts++
+	case test.XFail != "" && testResult.Status == "passed":
+		r.XPassed++
+	case test.XFail != "":
+		r.XFailed++
+	case testResult.Status == "passed":
+		r.Passed++
+	default:
+		r.Failed++
+		if testResult.Status == "error" {
+			r.Errored++
+		}
+	}
+}
+
+// Recount recomputes the run totals from the test results, for results assembled from
+// the test results of other runs.
+func (r *EvalResult) Recount() {
+	r.TotalTests = len(r.TestResults)
+	r.Passed, r.Failed, r.Errored, r.Skipped, r.Drafts, r.XPassed, r.XFailed, r.Retries = 0, 0, 0, 0, 0, 0, 0, 0
+	for _, tr := range r.TestResults {
+		r.tally(TestCase{State: tr.State, XFail: tr.XFail}, tr)
+	}
+	r.UpdateStatus()
+}
+
+// ApplyBaseline compares a result with the baseline at baselinePath, records the
+// comparison, and marks regressed tests. The result status is updated accordingly.
+func ApplyBaseline(result *EvalResult, baselinePath string) (*BaselineComparison, error) {
+	baseline, err := LoadResults(baselinePath)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyBaselineResult(result, baseline), nil
+}
+
+// ApplyBaselineResult is ApplyBaseline with baseline results already loaded.
+func ApplyBaselineResult(result, baseline *EvalResult) *BaselineComparison {
+	comp := CompareResults(result, baseline)
+	result.Comparison = comp
+	result.Regressions = len(comp.NewFailures)
+
+	regressed := make(map[string]bool, len(comp.NewFailures))
+	for _, name := range comp.NewFailures {
+		regressed[name] = true
+	}
+	for i := range result.TestResults {
+		if regressed[result.TestResults[i].Name] {
+			result.TestResults[i].Regression = true
+		}
+	}
+
+	result.UpdateStatus()
+	return comp
+}
+
+// OwnerOf returns the owner recorded for the named test, or "" if none.
+func (r *EvalResult) OwnerOf(name string) string {
+	for _, tr := range r.TestResults {
+		if tr.Name == name {
+			return tr.Owner
+		}
+	}
+	return ""
+}
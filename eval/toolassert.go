@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// Assertions are structured expectations on a test, expanded into checks when the
+// suite is loaded.
+type Assertions struct {
+	Tools *ToolAssertions `yaml:"tools,omitempty"`
+}
+
+// ToolAssertions describe the tool calls a response must (or must not) make.
+type ToolAssertions struct {
+	ExpectedCalled []string `yaml:"expected_called,omitempty"`
+	NotCalled      []string `yaml:"not_called,omitempty"`
+
+	// CallOrder lists tools that must be called in this relative order; other calls
+	// may come in between.
+	CallOrder []string `yaml:"call_order,omitempty"`
+
+	// MinCalls and MaxCalls bound the total number of tool calls.
+	MinCalls *int `yaml:"min_calls,omitempty"`
+	MaxCalls *int `yaml:"max_calls,omitempty"`
+
+	// Counts bounds the number of calls per tool.
+	Counts map[string]CallCount `yaml:"counts,omitempty"`
+
+	// ArgsSchema maps tool names to JSON Schema files their call arguments must satisfy.
+	ArgsSchema map[string]string `yaml:"args_schema,omitempty"`
+}
+
+// argsSchemaSpec is the parameter of a tool_args_schema check.
+type argsSchemaSpec struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+// CallCount bounds a number of tool calls. Either bound may be omitted.
+type CallCount struct {
+	Name string `yaml:"-" json:"name,omitempty"`
+	Min  *int   `yaml:"min,omitempty" json:"min,omitempty"`
+	Max  *int   `yaml:"max,omitempty" json:"max,omitempty"`
+}
+
+// checks expands the assertions into the equivalent checks, in a stable order.
+func (a *ToolAssertions) checks() ([]Check, error) {
+	var checks []Check
+	for _, name := range a.ExpectedCalled {
+		checks = append(checks, Check{Raw: "tool_called:" + name})
+	}
+	for _, name := range a.NotCalled {
+		checks = append(checks, Check{Raw: "tool_not_called:" + name})
+	}
+	if len(a.CallOrder) > 0 {
+		order, err := json.Marshal(a.CallOrder)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, Check{Raw: "tool_call_order:" + string(order)})
+	}
+
+	var counts []CallCount
+	if a.MinCalls != nil || a.MaxCalls != nil {
+		counts = append(counts, CallCount{Min: a.MinCalls, Max: a.MaxCalls})
+	}
+	names := make([]string, 0, len(a.Counts))
+	for name := range a.Counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		count := a.Counts[name]
+		count.Name = name
+		counts = append(counts, count)
+	}
+	for _, count := range counts {
+		if count.Min != nil && count.Max != nil && *count.Min > *count.Max {
+			return nil, fmt.Errorf("tool call count min %d is greater than max %d", *count.Min, *count.Max)
+		}
+		spec, err := json.Marshal(count)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, Check{Raw: "tool_call_count:" + string(spec)})
+	}
+
+	names = names[:0]
+	for name := range a.ArgsSchema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec, err := json.Marshal(argsSchemaSpec{Name: name, Schema: a.ArgsSchema[name]})
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, Check{Raw: "tool_args_schema:" + string(spec)})
+	}
+
+	return checks, nil
+}
+
+// checkToolNotCalled verifies that a specific tool was not called.
+func checkToolNotCalled(tr *trace.LLMTrace, name string) CheckResult {
+	result := CheckResult{Check: "tool_not_called: " + name, Passed: true}
+	for _, tc := range tr.ToolCalls {
+		if tc.Name == name {
+			result.Passed = false
+			result.Message = fmt.Sprintf("Tool '%s' was called", name)
+			return result
+		}
+	}
+	result.Message = fmt.Sprintf("Tool '%s' was not called", name)
+	return result
+}
+
+// checkToolCallOrder verifies that the tools in a JSON array were called in that
+// relative order. Calls to other tools may come in between.
+func checkToolCallOrder(tr *trace.LLMTrace, param string) CheckResult {
+	result := CheckResult{Check: "tool_call_order: " + param}
+
+	var order []string
+	if err := json.Unmarshal([]byte(param), &order); err != nil {
+		result.Message = fmt.Sprintf("Invalid call order (expected a JSON array of tool names): %v", err)
+		return result
+	}
+
+	called := toolNames(tr)
+	next := 0
+	for _, name := range called {
+		if next < len(order) && name == order[next] {
+			next++
+		}
+	}
+
+	result.Passed = next == len(order)
+	if result.Passed {
+		result.Message = fmt.Sprintf("Tools were called in order: %s", strings.Join(order, " → "))
+	} else {
+		result.Message = fmt.Sprintf("Expected calls in order %s, but '%s' was not called after the previous ones (called: %s)",
+			strings.Join(order, " → "), order[next], describeCalls(called))
+	}
+	return result
+}
+
+// checkToolCallCount verifies the number of calls to a tool, or of all tool calls when
+// the JSON spec has no name, is within its min and max.
+func checkToolCallCount(tr *trace.LLMTrace, param string) CheckResult {
+	result := CheckResult{Check: "tool_call_count: " + param}
+
+	var spec CallCount
+	if err := json.Unmarshal([]byte(param), &spec); err != nil {
+		result.Message = fmt.Sprintf("Invalid call count: %v", err)
+		return result
+	}
+
+	n := 0
+	for _, tc := range tr.ToolCalls {
+		if spec.Name == "" || tc.Name == spec.Name {
+			n++
+		}
+	}
+	subject := "Tools were"
+	if spec.Name != "" {
+		subject = fmt.Sprintf("Tool '%s' was", spec.Name)
+	}
+
+	switch {
+	case spec.Min != nil && n < *spec.Min:
+		result.Message = fmt.Sprintf("%s called %d times, expected at least %d", subject, n, *spec.Min)
+	case spec.Max != nil && n > *spec.Max:
+		result.Message = fmt.Sprintf("%s called %d times, expected at most %d", subject, n, *spec.Max)
+	default:
+		result.Passed = true
+		result.Message = fmt.Sprintf("%s called %d times", subject, n)
+	}
+	return result
+}
+
+// checkToolArgsSchema verifies that the tool was called and the arguments of every call
+// to it satisfy a JSON Schema, given as {"name": ..., "schema": <path>}.
+func checkToolArgsSchema(tr *trace.LLMTrace, param string) CheckResult {
+	result := CheckResult{Check: "tool_args_schema: " + param}
+
+	var spec argsSchemaSpec
+	if err := json.Unmarshal([]byte(param), &spec); err != nil || spec.Name == "" || spec.Schema == "" {
+		result.Message = `Invalid parameters (expected {"name": <tool>, "schema": <path>})`
+		return result
+	}
+	schema, err := loadJSONSchema(spec.Schema)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+
+	calls := 0
+	for _, tc := range tr.ToolCalls {
+		if tc.Name != spec.Name {
+			continue
+		}
+		calls++
+
+		var args interface{}
+		if len(tc.Args) > 0 {
+			if err := json.Unmarshal(tc.Args, &args); err != nil {
+				result.Message = fmt.Sprintf("Tool '%s' arguments are not valid JSON: %v", spec.Name, err)
+				return result
+			}
+		}
+		// Some providers send arguments as a JSON-encoded string
+		if encoded, ok := args.(string); ok {
+			if err := json.Unmarshal([]byte(encoded), &args); err != nil {
+				result.Message = fmt.Sprintf("Tool '%s' arguments are not a JSON object: %q", spec.Name, encoded)
+				return result
+			}
+		}
+		if err := validateJSONSchema(args, schema, "arguments"); err != nil {
+			result.Message = fmt.Sprintf("Tool '%s' call %d: %v", spec.Name, calls, err)
+			return result
+		}
+	}
+
+	if calls == 0 {
+		result.Message = fmt.Sprintf("Tool '%s' was not called (called: %s)", spec.Name, describeCalls(toolNames(tr)))
+		return result
+	}
+	result.Passed = true
+	result.Message = fmt.Sprintf("Arguments of %d call(s) to '%s' match %s", calls, spec.Name, spec.Schema)
+	return result
+}
+
+// toolNames returns the names of the tools called in the trace, in call order.
+func toolNames(tr *trace.LLMTrace) []string {
+	names := make([]string, len(tr.ToolCalls))
+	for i, tc := range tr.ToolCalls {
+		names[i] = tc.Name
+	}
+	return names
+}
+
+func describeCalls(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
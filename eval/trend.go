@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+// sparkBlocks are the bars of a sparkline, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Trend returns the summaries of the last n runs of result's suite recorded in dir,
+// oldest first, ending with result itself, which is not yet recorded. Filtered runs
+// are left out, since their pass rates and latencies cover only part of the suite.
+func Trend(dir string, result *EvalResult, n int) ([]RunSummary, error) {
+	runs, err := ReadHistory(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	current := Summarize(result)
+	var trend []RunSummary
+	for _, run := range runs {
+		if run.TestSuite == current.TestSuite && run.ID != current.ID && !run.Filtered {
+			trend = append(trend, run)
+		}
+	}
+	trend = append(trend, current)
+	if n > 0 && len(trend) > n {
+		trend = trend[len(trend)-n:]
+	}
+	return trend, nil
+}
+
+// Sparkline draws values as a line of bars scaled between their minimum and maximum.
+// Equal values are drawn at the lowest bar.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low, high = min(low, v), max(high, v)
+	}
+
+	line := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if high > low {
+			level = int((v - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		line[i] = sparkBlocks[level]
+	}
+	return string(line)
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package eval
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SetVars overrides the value of vars in every test, as `--var name=value` does. Values
+// in a test's dataset rows still win for those rows.
+func (s *TestSuite) SetVars(overrides map[string]string) {
+	if len(overrides) == 0 {
+		return
+	}
+	s.Vars = mergeVars(s.Vars, overrides)
+	for i := range s.Tests {
+		s.Tests[i].Vars = mergeVars(s.Tests[i].Vars, overrides)
+	}
+}
+
+// ParseVars parses name=value pairs such as those given with --var.
+func ParseVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid var %q (expected name=value)", pair)
+		}
+		vars[strings.TrimSpace(name)] = value
+	}
+	return vars, nil
+}
+
+// mergeVars returns base with every value in overrides replacing base's.
+func mergeVars(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// placeholder matches a {{name}} placeholder.
+var placeholder = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+// interpolate substitutes {{name}} placeholders in s with values from vars, in a single
+// pass, so placeholders inside substituted values are left as they are. Unknown
+// placeholders are left as they are too.
+func interpolate(s string, vars map[string]string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := vars[m[2:len(m)-2]]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// withVars returns test with its vars filled into its checks and the text fields of
+// its select.
+func withVars(test TestCase, vars map[string]string) TestCase {
+	if len(vars) == 0 {
+		return test
+	}
+	test.Checks = interpolateChecks(test.Checks, vars)
+	if test.Select != nil {
+		sel := *test.Select
+		sel.Endpoint = interpolate(sel.Endpoint, vars)
+		sel.Model = interpolate(sel.Model, vars)
+		sel.Prompt = interpolate(sel.Prompt, vars)
+		sel.Case = interpolate(sel.Case, vars)
+		test.Select = &sel
+	}
+	return test
+}
@@ -0,0 +1,225 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package notify tells Slack and webhooks about runs that regressed.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+)
+
+// severityRank orders test severities; tests without one are medium.
+var severityRank = map[string]int{
+	eval.SeverityLow:      1,
+	eval.SeverityMedium:   2,
+	eval.SeverityHigh:     3,
+	eval.SeverityCritical: 4,
+}
+
+// maxListed caps the tests named in a Slack message.
+const maxListed = 10
+
+// Event summarizes a run worth notifying about. Webhooks receive it as JSON.
+type Event struct {
+	Project        string           `json:"project,omitempty"`
+	TestSuite      string           `json:"test_suite"`
+	Status         string           `json:"status"`
+	Passed         int              `json:"passed"`
+	Failed         int              `json:"failed"`
+	Regressions    int              `json:"regressions"`
+	Regressed      []Test           `json:"regressed_tests,omitempty"`
+	SevereFailures []Test           `json:"severe_failures,omitempty"` // Failing tests at or above the configured severity
+	Provenance     *eval.Provenance `json:"provenance,omitempty"`
+	Links          []Link           `json:"links,omitempty"`
+}
+
+// Test is a failing test named in an event.
+type Test struct {
+	Name     string   `json:"name"`
+	Severity string   `json:"severity,omitempty"`
+	Owner    string   `json:"owner,omitempty"`
+	Checks   []string `json:"failed_checks,omitempty"`
+}
+
+// Link points at where the run's results can be read, such as the CI run and its
+// artifacts or the pull request comment.
+type Link struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// NewEvent returns the event for a result, and false when the run has no regressions
+// and no gated failures of tests at least minSeverity severe.
+func NewEvent(result *eval.EvalResult, project, minSeverity string) (*Event, bool) {
+	event := &Event{
+		Project:     project,
+		TestSuite:   result.TestSuite,
+		Status:      result.Status,
+		Passed:      result.Passed,
+		Failed:      result.Failed,
+		Regressions: result.Regressions,
+		Provenance:  result.Provenance,
+	}
+
+	for _, tr := range result.TestResults {
+		if tr.State == eval.StateDraft || tr.XFail != "" || (tr.Status != "failed" && tr.Status != "error") {
+			continue
+		}
+		test := Test{Name: tr.Name, Severity: tr.Severity, Owner: tr.Owner}
+		for _, cr := range tr.CheckResults {
+			if cr.Failed() {
+				test.Checks = append(test.Checks, cr.Check)
+			}
+		}
+		if tr.Regression {
+			event.Regressed = append(event.Regressed, test)
+		}
+		if atLeast(tr.Severity, minSeverity) {
+			event.SevereFailures = append(event.SevereFailures, test)
+		}
+	}
+	return event, event.Regressions > 0 || len(event.SevereFailures) > 0
+}
+
+// atLeast reports whether severity is at least min. An empty severity is medium.
+func atLeast(severity, min string) bool {
+	if severity == "" {
+		severity = eval.SeverityMedium
+	}
+	return severityRank[severity] >= severityRank[min]
+}
+
+// Send posts the event to the configured Slack webhook and every webhook. A failing
+// destination doesn't stop the others; their errors are returned together.
+func Send(cfg config.NotificationsConfig, event *Event) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	var errs []error
+
+	if cfg.Slack != "" {
+		body, err := json.Marshal(map[string]string{"text": slackText(event)})
+		if err == nil {
+			err = post(client, redactURL(cfg.Slack), os.ExpandEnv(cfg.Slack), body)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("slack: %w", err))
+		}
+	}
+
+	if len(cfg.Webhooks) > 0 {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		for _, webhook := range cfg.Webhooks {
+			if err := post(client, redactURL(webhook), os.ExpandEnv(webhook), body); err != nil {
+				errs = append(errs, fmt.Errorf("webhook: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post sends body to target. Errors name the destination by label, since a network
+// error's own text contains the full URL and its secret path.
+func post(client *http.Client, label, target string, body []byte) error {
+	if target == "" {
+		return fmt.Errorf("%s: url is empty", label)
+	}
+	resp, err := client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("%s: %w", label, urlErr.Err)
+		}
+		return fmt.Errorf("%s: request failed", label)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %d: %s", label, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// redactURL names a webhook in errors without the secret path or query, or as its
+// variable when it comes from the environment.
+func redactURL(raw string) string {
+	if strings.HasPrefix(raw, "$") {
+		return raw
+	}
+	if scheme, rest, ok := strings.Cut(raw, "://"); ok {
+		host, _, _ := strings.Cut(rest, "/")
+		return scheme + "://" + host
+	}
+	return "(invalid url)"
+}
+
+// slackText formats the event as a Slack message in mrkdwn.
+func slackText(event *Event) string {
+	var b strings.Builder
+
+	subject := event.TestSuite
+	if event.Project != "" {
+		subject = event.Project + " / " + subject
+	}
+	switch {
+	case event.Regressions > 0:
+		fmt.Fprintf(&b, ":rotating_light: *Regrada: %d regression(s) in %s*\n", event.Regressions, subject)
+	default:
+		fmt.Fprintf(&b, ":warning: *Regrada: %d severe failure(s) in %s*\n", len(event.SevereFailures), subject)
+	}
+	fmt.Fprintf(&b, "%d passed, %d failed", event.Passed, event.Failed)
+	if p := event.Provenance.String(); p != "" {
+		fmt.Fprintf(&b, " · %s", p)
+	}
+	b.WriteString("\n")
+
+	writeTests(&b, "Regressed", event.Regressed)
+	writeTests(&b, "Severe failures", event.SevereFailures)
+
+	if len(event.Links) > 0 {
+		links := make([]string, len(event.Links))
+		for i, link := range event.Links {
+			links[i] = fmt.Sprintf("<%s|%s>", link.URL, link.Title)
+		}
+		fmt.Fprintf(&b, "\n%s\n", strings.Join(links, " · "))
+	}
+	return b.String()
+}
+
+// writeTests lists up to maxListed tests under a heading.
+func writeTests(b *strings.Builder, heading string, tests []Test) {
+	if len(tests) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n*%s:*\n", heading)
+	for i, t := range tests {
+		if i == maxListed {
+			fmt.Fprintf(b, "• …and %d more\n", len(tests)-maxListed)
+			break
+		}
+		fmt.Fprintf(b, "• `%s`", t.Name)
+		if t.Severity != "" {
+			fmt.Fprintf(b, " (%s)", t.Severity)
+		}
+		if len(t.Checks) > 0 {
+			fmt.Fprintf(b, ": %s", strings.Join(t.Checks, ", "))
+		}
+		if t.Owner != "" {
+			fmt.Fprintf(b, " — owner: %s", t.Owner)
+		}
+		b.WriteString("\n")
+	}
+}
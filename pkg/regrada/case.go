@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package regrada
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/matias/regrada/trace"
+)
+
+// Correlation names, re-exported for apps tagging their LLM calls with test cases.
+const (
+	CaseHeader = trace.CaseHeader // Request header the proxy records and strips
+	CaseEnv    = trace.CaseEnv    // Environment variable CaseTransport reads by default
+)
+
+type caseKey struct{}
+
+// WithCase returns a context whose requests CaseTransport tags with the test case name.
+func WithCase(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, caseKey{}, name)
+}
+
+// SetCase tags a single request with a test case name.
+func SetCase(req *http.Request, name string) {
+	if name != "" {
+		req.Header.Set(CaseHeader, name)
+	}
+}
+
+// CaseTransport wraps base (http.DefaultTransport if nil) so every request carries the
+// X-Regrada-Case header: the name from WithCase on the request's context, or else the
+// REGRADA_CASE environment variable. Requests that already set the header, or with no
+// case to set, are sent unchanged. Outside `regrada trace` the header goes to the
+// provider as-is, where it is ignored.
+//
+//	client := &http.Client{Transport: regrada.CaseTransport(nil)}
+func CaseTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return caseTransport{base: base}
+}
+
+type caseTransport struct {
+	base http.RoundTripper
+}
+
+func (t caseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(CaseHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	name, _ := req.Context().Value(caseKey{}).(string)
+	if name == "" {
+		name = os.Getenv(CaseEnv)
+	}
+	if name == "" {
+		return t.base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	tagged := req.Clone(req.Context())
+	SetCase(tagged, name)
+	return t.base.RoundTrip(tagged)
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+// Package regrada is the stable Go API for running Regrada evaluations from Go code,
+// for example from a TestMain, without shelling out to the CLI and parsing JSON.
+//
+//	func TestMain(m *testing.M) {
+//		result, err := regrada.RunSuite(regrada.Options{})
+//		if err != nil || result.Regressions > 0 {
+//			os.Exit(1)
+//		}
+//		os.Exit(m.Run())
+//	}
+package regrada
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+)
+
+// Re-exported result types so callers only need to import this package.
+type (
+	Config      = config.RegradaConfig
+	Suite       = eval.TestSuite
+	Case        = eval.TestCase
+	Result      = eval.EvalResult
+	CaseResult  = eval.TestResult
+	CheckResult = eval.CheckResult
+	Session     = trace.TraceSession
+)
+
+// Options control a suite run. Zero values use the same defaults as `regrada run`.
+type Options struct {
+	// ConfigPath is the project config (default: .regrada.yaml). A missing file falls back to defaults.
+	ConfigPath string
+	// TestsPath is the test suite (default: <evals.path>/tests.yaml).
+	TestsPath string
+	// BaselinePath is the baseline to compare against (default: .regrada/baseline.json,
+	// or the rolling baseline over the run history with baseline.mode rolling).
+	// A missing baseline is not an error; the result simply has no comparison.
+	BaselinePath string
+	// Baseline is compared against instead of BaselinePath when set, e.g. one fetched
+	// from a remote store (baseline.mode remote or backend).
+	Baseline *Result
+	// Session is the trace session to evaluate (default: the latest in .regrada/traces).
+	Session *Session
+	// Offline skips rubric and similar_to checks instead of calling the judge and the
+	// embeddings provider, like `regrada run --offline`.
+	Offline bool
+	// NoCheckCache evaluates every check again instead of reusing the results cached in
+	// .regrada/cache/checks.json.
+	NoCheckCache bool
+}
+
+// LoadConfig reads a project config, falling back to defaults when the file does not exist.
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		path = config.DefaultPath
+	}
+	cfg, err := config.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.Defaults("."), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadSuite reads a test suite from a YAML file.
+func LoadSuite(path string) (*Suite, error) {
+	return eval.LoadSuite(path)
+}
+
+// RunSuite evaluates the test suite against a trace session, applies the configured
+// policies, and compares the result with the baseline, the same way `regrada run`
+// does. Problems that leave a policy unapplied (such as semantic_drift without an
+// embeddings provider) are returned as an error together with the result.
+func RunSuite(opts Options) (*Result, error) {
+	cfg, err := LoadConfig(opts.ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	testsPath := opts.TestsPath
+	if testsPath == "" {
+		testsPath = filepath.Join(cfg.Evals.Path, "tests.yaml")
+	}
+	suite, err := eval.LoadSuite(testsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	session := opts.Session
+	if session == nil {
+		session, err = eval.LoadLatestSession()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	baseline, err := loadBaseline(cfg, suite.Name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	env := eval.Env{Concurrency: cfg.Evals.Concurrent}
+	if !opts.Offline {
+		if env, err = eval.NewEnv(cfg); err != nil {
+			return nil, err
+		}
+	}
+	var cache *eval.CheckCache
+	if !opts.NoCheckCache {
+		cache = eval.LoadCheckCache(filepath.Join(".regrada", "cache", "checks.json"))
+		env.CheckCache = cache
+	}
+
+	result, report := eval.Evaluate(cfg, suite, []*Session{session}, baseline, env)
+	errs := report.Warnings
+	if cache != nil {
+		if err := cache.Save(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save check cache: %w", err))
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// loadBaseline returns the baseline RunSuite compares the named suite with, or nil when
+// there is none.
+func loadBaseline(cfg *Config, suite string, opts Options) (*Result, error) {
+	if opts.Baseline != nil {
+		return opts.Baseline, nil
+	}
+	if opts.BaselinePath == "" && cfg.Baseline.Mode == "rolling" {
+		history, err := eval.LoadHistory(filepath.Join(".regrada", "history"), suite, cfg.Baseline.RollingRuns())
+		if err != nil {
+			return nil, err
+		}
+		if len(history) > 0 {
+			return eval.RollingBaseline(history), nil
+		}
+	}
+
+	path := opts.BaselinePath
+	if path == "" {
+		path = filepath.Join(".regrada", "baseline.json")
+	}
+	baseline, err := eval.LoadResults(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// RunCase evaluates a single test case against a trace session.
+func RunCase(test Case, session *Session) CaseResult {
+	return eval.RunTestInSession(test, session)
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// extractAttachments finds file inputs in a request body. It recognizes Anthropic
+// document/image blocks, OpenAI chat "file" and "image_url" parts, and Responses API
+// "input_file"/"input_image" parts, and Gemini inlineData/fileData parts.
+func extractAttachments(reqBody []byte) []trace.Attachment {
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(reqBody, &reqData); err != nil {
+		return nil
+	}
+
+	var messages []interface{}
+	for _, key := range []string{"messages", "input", "contents"} {
+		if list, ok := reqData[key].([]interface{}); ok {
+			messages = append(messages, list...)
+		}
+	}
+
+	var attachments []trace.Attachment
+	for _, m := range messages {
+		msg, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		parts, ok := msg["content"].([]interface{})
+		if !ok {
+			if parts, ok = msg["parts"].([]interface{}); !ok {
+				continue
+			}
+		}
+		for _, p := range parts {
+			if part, ok := p.(map[string]interface{}); ok {
+				if att, ok := parseAttachment(part); ok {
+					attachments = append(attachments, att)
+				}
+			}
+		}
+	}
+	return attachments
+}
+
+// parseAttachment converts a single content part into an attachment, if it is one.
+func parseAttachment(part map[string]interface{}) (trace.Attachment, bool) {
+	// Gemini parts are keyed by content type rather than tagged with "type"
+	if inline, ok := part["inlineData"].(map[string]interface{}); ok {
+		att := trace.Attachment{Kind: geminiAttachmentKind(getString(inline, "mimeType")), MediaType: getString(inline, "mimeType")}
+		setInlineData(&att, getString(inline, "data"))
+		return att, true
+	}
+	if file, ok := part["fileData"].(map[string]interface{}); ok {
+		mediaType := getString(file, "mimeType")
+		return trace.Attachment{Kind: geminiAttachmentKind(mediaType), MediaType: mediaType, URL: getString(file, "fileUri")}, true
+	}
+
+	switch getString(part, "type") {
+	case "document", "image":
+		// Anthropic: {"type": "document", "source": {"type": "base64", "media_type": ..., "data": ...}}
+		att := trace.Attachment{Kind: getString(part, "type")}
+		att.Filename = getString(part, "title")
+		if source, ok := part["source"].(map[string]interface{}); ok {
+			att.MediaType = getString(source, "media_type")
+			att.URL = getString(source, "url")
+			if source["type"] == "base64" {
+				setInlineData(&att, getString(source, "data"))
+			}
+		}
+		return att, true
+
+	case "file":
+		// OpenAI chat: {"type": "file", "file": {"filename": ..., "file_data": "data:...;base64,..."}}
+		att := trace.Attachment{Kind: "file"}
+		if file, ok := part["file"].(map[string]interface{}); ok {
+			att.Filename = getString(file, "filename")
+			if id := getString(file, "file_id"); id != "" {
+				att.URL = "file_id:" + id
+			}
+			setDataURL(&att, getString(file, "file_data"))
+		}
+		return att, true
+
+	case "input_file":
+		// OpenAI Responses: {"type": "input_file", "filename": ..., "file_data": ...}
+		att := trace.Attachment{Kind: "file", Filename: getString(part, "filename")}
+		if id := getString(part, "file_id"); id != "" {
+			att.URL = "file_id:" + id
+		}
+		setDataURL(&att, getString(part, "file_data"))
+		return att, true
+
+	case "image_url", "input_image":
+		att := trace.Attachment{Kind: "image"}
+		url := getString(part, "image_url")
+		if nested, ok := part["image_url"].(map[string]interface{}); ok {
+			url = getString(nested, "url")
+		}
+		if strings.HasPrefix(url, "data:") {
+			setDataURL(&att, url)
+		} else {
+			att.URL = url
+		}
+		return att, true
+	}
+
+	return trace.Attachment{}, false
+}
+
+// setDataURL fills media type, size, and digest from a "data:<type>;base64,<data>" URL.
+// Plain base64 strings without the data: prefix are accepted too.
+func setDataURL(att *trace.Attachment, value string) {
+	if value == "" {
+		return
+	}
+	if strings.HasPrefix(value, "data:") {
+		header, data, found := strings.Cut(value[len("data:"):], ",")
+		if !found {
+			return
+		}
+		att.MediaType = strings.TrimSuffix(header, ";base64")
+		value = data
+	}
+	setInlineData(att, value)
+}
+
+// setInlineData decodes base64 data and records its size and SHA-256 digest.
+func setInlineData(att *trace.Attachment, data string) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(decoded)
+	att.Size = len(decoded)
+	att.SHA256 = hex.EncodeToString(sum[:])
+}
+
+// geminiAttachmentKind classifies a Gemini part by media type.
+func geminiAttachmentKind(mediaType string) string {
+	switch {
+	case strings.HasPrefix(mediaType, "image/"):
+		return "image"
+	case mediaType == "application/pdf":
+		return "document"
+	default:
+		return "file"
+	}
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/matias/regrada/config"
+)
+
+// defaultAzureAPIVersion is the GA Azure OpenAI data-plane version used when none is configured.
+const defaultAzureAPIVersion = "2024-10-21"
+
+// azureDeployment routes OpenAI-style requests to an Azure OpenAI deployment.
+type azureDeployment struct {
+	deployment string
+	apiVersion string
+	apiKey     string
+}
+
+// newAzureDeployment resolves the deployment, API version, and key from the provider config.
+// The deployment defaults to the configured model; a missing key leaves client auth untouched.
+func newAzureDeployment(cfg config.ProviderConfig) *azureDeployment {
+	az := &azureDeployment{
+		deployment: cfg.Model,
+		apiVersion: defaultAzureAPIVersion,
+	}
+	keyEnv := "AZURE_OPENAI_API_KEY"
+	if c := cfg.Azure; c != nil {
+		if c.Deployment != "" {
+			az.deployment = c.Deployment
+		}
+		if c.APIVersion != "" {
+			az.apiVersion = c.APIVersion
+		}
+		if c.APIKeyEnv != "" {
+			keyEnv = c.APIKeyEnv
+		}
+	}
+	az.apiKey = os.Getenv(keyEnv)
+	return az
+}
+
+// rewrite points a forwarded request at the deployment. Paths that are already
+// Azure-shaped (/openai/...) are kept; OpenAI paths such as /v1/chat/completions
+// are mapped to /openai/deployments/<deployment>/chat/completions. The api-version
+// query parameter is added when missing, and a configured key replaces the client's
+// Authorization header with api-key.
+func (az *azureDeployment) rewrite(req *http.Request) {
+	path := req.URL.Path
+	if !strings.HasPrefix(path, "/openai/") && az.deployment != "" {
+		op := strings.TrimPrefix(path, "/v1")
+		if op == "/responses" || strings.HasPrefix(op, "/responses/") {
+			// The Responses API is addressed per resource; the model field picks the deployment
+			path = "/openai" + op
+		} else {
+			path = "/openai/deployments/" + url.PathEscape(az.deployment) + op
+		}
+		req.URL.Path = path
+		req.URL.RawPath = ""
+	}
+
+	query := req.URL.Query()
+	if query.Get("api-version") == "" {
+		query.Set("api-version", az.apiVersion)
+		req.URL.RawQuery = query.Encode()
+	}
+
+	if az.apiKey != "" {
+		req.Header.Del("Authorization")
+		req.Header.Set("api-key", az.apiKey)
+	}
+}
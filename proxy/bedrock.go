@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// bedrockModelFromPath extracts the model ID from a Bedrock runtime path such as
+// /model/anthropic.claude-3-5-sonnet-20240620-v1:0/invoke.
+func bedrockModelFromPath(path string) string {
+	rest, ok := strings.CutPrefix(path, "/model/")
+	if !ok {
+		return ""
+	}
+	if idx := strings.LastIndex(rest, "/"); idx >= 0 {
+		rest = rest[:idx]
+	}
+	return rest
+}
+
+// parseBedrockDetails extracts the model, token usage, and tool calls from a Bedrock call.
+// It understands the Converse API and the InvokeModel bodies of Anthropic Claude,
+// Amazon Titan, and Meta Llama models.
+func parseBedrockDetails(path string, reqBody, respBody []byte) (model string, tokensIn, tokensOut int, toolCalls []trace.ToolCall) {
+	model = bedrockModelFromPath(path)
+
+	var respData map[string]interface{}
+	if err := json.Unmarshal(respBody, &respData); err != nil {
+		return
+	}
+
+	switch {
+	case respData["output"] != nil:
+		// Converse API
+		if usage, ok := respData["usage"].(map[string]interface{}); ok {
+			if it, ok := usage["inputTokens"].(float64); ok {
+				tokensIn = int(it)
+			}
+			if ot, ok := usage["outputTokens"].(float64); ok {
+				tokensOut = int(ot)
+			}
+		}
+		for _, block := range converseContent(respData) {
+			if tu, ok := block["toolUse"].(map[string]interface{}); ok {
+				toolCall := trace.ToolCall{
+					ID:   getString(tu, "toolUseId"),
+					Name: getString(tu, "name"),
+				}
+				if input, ok := tu["input"]; ok {
+					if inputBytes, err := json.Marshal(input); err == nil {
+						toolCall.Args = json.RawMessage(inputBytes)
+					}
+				}
+				toolCalls = append(toolCalls, toolCall)
+			}
+		}
+
+	case respData["content"] != nil:
+		// Anthropic Claude messages format
+		_, tokensIn, tokensOut, toolCalls = parseAPIDetails("anthropic", reqBody, respBody)
+
+	case respData["results"] != nil:
+		// Amazon Titan text
+		if it, ok := respData["inputTextTokenCount"].(float64); ok {
+			tokensIn = int(it)
+		}
+		if results, ok := respData["results"].([]interface{}); ok && len(results) > 0 {
+			if result, ok := results[0].(map[string]interface{}); ok {
+				if tc, ok := result["tokenCount"].(float64); ok {
+					tokensOut = int(tc)
+				}
+			}
+		}
+
+	default:
+		// Meta Llama
+		if pt, ok := respData["prompt_token_count"].(float64); ok {
+			tokensIn = int(pt)
+		}
+		if gt, ok := respData["generation_token_count"].(float64); ok {
+			tokensOut = int(gt)
+		}
+	}
+
+	return
+}
+
+// converseContent returns the content blocks of a Converse API response message.
+func converseContent(respData map[string]interface{}) []map[string]interface{} {
+	output, ok := respData["output"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	message, ok := output["message"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	rawContent, _ := message["content"].([]interface{})
+
+	blocks := make([]map[string]interface{}, 0, len(rawContent))
+	for _, c := range rawContent {
+		if block, ok := c.(map[string]interface{}); ok {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
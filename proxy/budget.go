@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/matias/regrada/config"
+)
+
+// contextWindows are the input context sizes of common models, matched by model name prefix.
+// The longest matching prefix wins; provider.prompt_budget.context_windows overrides these.
+var contextWindows = map[string]int{
+	"gpt-3.5-turbo":     16385,
+	"gpt-4":             8192,
+	"gpt-4-turbo":       128000,
+	"gpt-4o":            128000,
+	"gpt-4.1":           1047576,
+	"o1":                200000,
+	"o3":                200000,
+	"o4-mini":           200000,
+	"claude-":           200000,
+	"gemini-1.5-pro":    2097152,
+	"gemini-1.5-flash":  1048576,
+	"gemini-2.0-flash":  1048576,
+	"gemini-2.5":        1048576,
+	"llama3":            8192,
+	"llama3.1":          131072,
+	"mistral":           32768,
+	"mixtral":           32768,
+	"text-embedding-3-": 8191,
+}
+
+// estimateTokens approximates the token count of a request's prompt: roughly four
+// characters per token over every string in the body, plus a small per-message overhead.
+// It is a heuristic rather than the model's tokenizer. Attachments (images, documents,
+// audio) are skipped, since their base64 data isn't tokenized as text.
+func estimateTokens(reqBody []byte) int {
+	var body interface{}
+	if err := json.Unmarshal(reqBody, &body); err != nil {
+		return (len(reqBody) + 3) / 4
+	}
+
+	chars, strs := 0, 0
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch val := v.(type) {
+		case string:
+			chars += len(val)
+			strs++
+		case map[string]interface{}:
+			if isBinaryPart(val) {
+				return
+			}
+			for _, child := range val {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range val {
+				walk(child)
+			}
+		}
+	}
+	walk(body)
+
+	return (chars+3)/4 + strs
+}
+
+// isBinaryPart reports whether a content part carries file data: the attachment parts
+// extractAttachments recognizes, plus OpenAI audio input.
+func isBinaryPart(part map[string]interface{}) bool {
+	for _, key := range []string{"inlineData", "inline_data", "fileData", "file_data", "input_audio"} {
+		if _, ok := part[key].(map[string]interface{}); ok {
+			return true
+		}
+	}
+	switch getString(part, "type") {
+	case "document":
+		// Anthropic plain-text documents are tokenized like any other text
+		source, _ := part["source"].(map[string]interface{})
+		return getString(source, "type") != "text"
+	case "image", "file", "input_file", "image_url", "input_image", "input_audio":
+		return true
+	}
+	return false
+}
+
+// contextWindow returns the context size for a model, or 0 if unknown.
+func contextWindow(model string, overrides map[string]int) int {
+	if size, ok := overrides[model]; ok {
+		return size
+	}
+
+	prefixes := make([]string, 0, len(contextWindows))
+	for prefix := range contextWindows {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(model, prefix) {
+			return contextWindows[prefix]
+		}
+	}
+	return 0
+}
+
+// checkPromptBudget returns a description of the budget violation for a request, or ""
+// when the estimated prompt fits both the configured budget and the model's context window.
+func checkPromptBudget(budget *config.PromptBudgetConfig, model string, reqBody []byte) string {
+	estimate := estimateTokens(reqBody)
+
+	if budget.MaxTokens > 0 && estimate > budget.MaxTokens {
+		return fmt.Sprintf("prompt is ~%d tokens, over the configured budget of %d", estimate, budget.MaxTokens)
+	}
+	if window := contextWindow(model, budget.ContextWindows); window > 0 && estimate > window {
+		return fmt.Sprintf("prompt is ~%d tokens, over the %d-token context window of %s", estimate, window, model)
+	}
+	return ""
+}
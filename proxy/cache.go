@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// responseCache reuses successful provider responses for identical requests during
+// development. Entries use the cassette format and are keyed by the upstream URL, method,
+// and normalized body, so any change to the prompt, parameters, or model is a miss.
+type responseCache struct {
+	store *cassetteStore
+	ttl   time.Duration // Zero keeps entries until the cache is cleared
+}
+
+// newResponseCache returns nil when the cache is disabled.
+func newResponseCache(cfg config.CacheConfig) *responseCache {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(".regrada", "cache")
+	}
+	cache := &responseCache{store: &cassetteStore{mode: CassetteAuto, dir: dir}}
+	if d, err := time.ParseDuration(cfg.TTL); err == nil && d > 0 {
+		cache.ttl = d
+	}
+	return cache
+}
+
+// get returns the cached response under key unless it has expired.
+func (c *responseCache) get(key string) (*cassette, bool) {
+	entry, ok := c.store.load(key)
+	if !ok {
+		return nil, false
+	}
+	if c.ttl > 0 && time.Since(entry.RecordedAt) > c.ttl {
+		return nil, false
+	}
+	return entry, true
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// Cassette modes. In record mode every request goes upstream and its response is
+// stored; in replay mode only stored responses are served; auto replays what is
+// stored and records the rest.
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+	CassetteAuto   = "auto"
+)
+
+// cassette is one stored request/response pair. Response bodies that are not JSON,
+// such as server-sent event streams, are kept verbatim in Text.
+type cassette struct {
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Request    json.RawMessage   `json:"request,omitempty"`
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	Text       string            `json:"text,omitempty"`
+	LatencyMs  int64             `json:"latency_ms"`
+	RecordedAt time.Time         `json:"recorded_at"`
+}
+
+// cassetteStore reads and writes cassettes in a directory, one file per request hash.
+type cassetteStore struct {
+	mode string
+	dir  string
+}
+
+// newCassetteStore returns nil when cassettes are disabled.
+func newCassetteStore(cfg config.CassetteConfig) (*cassetteStore, error) {
+	switch cfg.Mode {
+	case "", "off":
+		return nil, nil
+	case CassetteRecord, CassetteReplay, CassetteAuto:
+	default:
+		return nil, fmt.Errorf("invalid cassettes.mode %q (must be one of: off, record, replay, auto)", cfg.Mode)
+	}
+
+	dir := cfg.Dir
+	if dir == "" {
+		dir = filepath.Join(".regrada", "cassettes")
+	}
+	return &cassetteStore{mode: cfg.Mode, dir: dir}, nil
+}
+
+// cassetteKey hashes the method, path, and normalized body of a request. JSON bodies
+// are re-encoded with sorted keys and no insignificant whitespace, so formatting and
+// field order don't affect matching.
+func cassetteKey(method, path string, body []byte) string {
+	normalized := body
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err == nil {
+		normalized, _ = json.Marshal(v)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, path)
+	h.Write(normalized)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// load returns the cassette stored under key, if any.
+func (s *cassetteStore) load(key string) (*cassette, bool) {
+	data, err := os.ReadFile(filepath.Join(s.dir, key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, false
+	}
+	return &c, true
+}
+
+// save stores a request and its response under key.
+func (s *cassetteStore) save(key string, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, latency time.Duration) error {
+	c := cassette{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Request:    sanitizeBody(reqBody),
+		StatusCode: resp.StatusCode,
+		Headers:    flattenHeaders(resp.Header),
+		LatencyMs:  latency.Milliseconds(),
+		RecordedAt: time.Now().UTC(),
+	}
+	if json.Valid(respBody) {
+		c.Body = respBody
+	} else {
+		c.Text = string(respBody)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.dir, key+".json"), data, 0644)
+}
+
+// response rebuilds the stored response and its body.
+func (c *cassette) response() (*http.Response, []byte) {
+	body := []byte(c.Body)
+	if c.Text != "" {
+		body = []byte(c.Text)
+	}
+
+	header := make(http.Header)
+	for key, value := range c.Headers {
+		header.Set(key, value)
+	}
+	return &http.Response{
+		StatusCode: c.StatusCode,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(body)),
+	}, body
+}
+
+// serveCassette answers a request with a stored response and records it as a trace
+// with the latency measured when the cassette was recorded. The trace's metadata maps
+// source (cassette or cached) to the short key.
+func (p *LLMProxy) serveCassette(w http.ResponseWriter, r *http.Request, provider string, reqBody []byte, c *cassette, source, key string) {
+	resp, respBody := c.response()
+
+	tr := p.createTrace(provider, r, reqBody, resp, respBody, time.Duration(c.LatencyMs)*time.Millisecond)
+	setMetadata(&tr, source, key[:12])
+	p.mu.Lock()
+	p.traces = append(p.traces, tr)
+	p.mu.Unlock()
+
+	if p.OnTrace != nil {
+		p.OnTrace(tr)
+	}
+
+	p.writeResponse(w, resp, respBody)
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/matias/regrada/config"
+)
+
+// compatiblePath joins an incoming OpenAI-style path onto the base path of an
+// OpenAI-compatible endpoint. The base_url carries the full API prefix (e.g.
+// https://api.groq.com/openai/v1 or http://localhost:8000/v1), so a leading /v1
+// sent by the client is dropped rather than doubled.
+func compatiblePath(basePath, reqPath string) string {
+	op := reqPath
+	if rest, ok := strings.CutPrefix(reqPath, "/v1"); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		op = rest
+	}
+	return strings.TrimSuffix(basePath, "/") + op
+}
+
+// applyCompatibleAuth sets the configured auth header, expanding $VARS in its template.
+// Without a template the client's own credentials are forwarded unchanged.
+func applyCompatibleAuth(req *http.Request, cfg *config.OpenAICompatibleConfig) {
+	if cfg == nil || cfg.AuthTemplate == "" {
+		return
+	}
+	header := cfg.AuthHeader
+	if header == "" {
+		header = "Authorization"
+	}
+	req.Header.Set(header, os.ExpandEnv(cfg.AuthTemplate))
+}
+
+// applyDefaultModel sets the request's model to model when the body doesn't name one.
+// Bodies that are not JSON objects are returned unchanged.
+func applyDefaultModel(body []byte, model string) []byte {
+	return setModel(body, model, false)
+}
+
+// overrideModel sets the request's model to model, replacing the one the client chose.
+// Bodies that are not JSON objects are returned unchanged.
+func overrideModel(body []byte, model string) []byte {
+	return setModel(body, model, true)
+}
+
+func setModel(body []byte, model string, replace bool) []byte {
+	if model == "" {
+		return body
+	}
+
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(body, &reqData); err != nil {
+		return body
+	}
+	if m, ok := reqData["model"].(string); ok && (m == model || (m != "" && !replace)) {
+		return body
+	}
+	reqData["model"] = model
+
+	rewritten, err := json.Marshal(reqData)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
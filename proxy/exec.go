@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// Exec providers run the configured command once per request, for gateways that are
+// easiest to wrap in a short script. The command receives a single ExternalRequest
+// JSON object on stdin and must write a single ExternalResponse object to stdout
+// before exiting; the fields mean the same as for external providers. A non-zero exit
+// status fails the request with 502. stderr is passed through.
+
+// execProvider runs a command per request.
+type execProvider struct {
+	command []string
+	env     []string
+	timeout time.Duration
+}
+
+// newExecProvider validates the exec provider config.
+func newExecProvider(cfg *config.ExternalProviderConfig) (*execProvider, error) {
+	timeout, err := providerTimeout(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := exec.LookPath(cfg.Command[0]); err != nil {
+		return nil, fmt.Errorf("exec provider command not found: %w", err)
+	}
+	return &execProvider{command: cfg.Command, env: providerEnv(cfg), timeout: timeout}, nil
+}
+
+// Execute runs the command with the request on stdin and parses its stdout.
+func (e *execProvider) Execute(req ExternalRequest) (*ExternalResponse, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.command[0], e.command[1:]...)
+	cmd.Env = e.env
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("exec provider timed out after %s", e.timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("exec provider failed: %w", err)
+	}
+	return decodeExternalResponse(output, req.ID)
+}
+
+// Close is a no-op; every request runs in its own process.
+func (e *execProvider) Close() {}
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// External providers let teams wrap any inference stack without modifying regrada.
+// The proxy starts the configured command once and exchanges one JSON object per
+// line over its stdin/stdout, one request at a time:
+//
+//	→ {"id": "...", "method": "POST", "path": "/v1/chat/completions", "headers": {...}, "body": {...}}
+//	← {"id": "...", "status": 200, "headers": {...}, "body": {...}, "timings": {"latency_ms": 812}}
+//
+// "body" is the raw request or response JSON. "status" defaults to 200, and when
+// timings.latency_ms is set it is recorded instead of the wall-clock latency.
+// A response with a non-empty "error" fails the request with 502. The subprocess's
+// stderr is passed through, so it can log freely.
+
+// ExternalRequest is a request sent to an external provider.
+type ExternalRequest struct {
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// ExternalResponse is an external provider's reply to an ExternalRequest.
+type ExternalResponse struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	Timings struct {
+		LatencyMs int64 `json:"latency_ms,omitempty"`
+	} `json:"timings"`
+}
+
+// subprocessProvider serves proxied requests from a user-supplied command instead of an HTTP endpoint.
+type subprocessProvider interface {
+	Execute(req ExternalRequest) (*ExternalResponse, error)
+	Close()
+}
+
+// externalProvider runs the external provider subprocess, starting it again when the
+// previous one timed out or exited.
+type externalProvider struct {
+	cfg     *config.ExternalProviderConfig
+	timeout time.Duration
+	mu      sync.Mutex
+	proc    *externalProcess // nil until the next request starts one
+}
+
+// externalProcess is one running provider command. A single goroutine reads its
+// stdout for the life of the process and exits once the process is stopped.
+type externalProcess struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan readResult
+	stop  chan struct{}
+}
+
+type readResult struct {
+	line []byte
+	err  error
+}
+
+// providerTimeout parses the configured per-request timeout (default: 120s).
+func providerTimeout(cfg *config.ExternalProviderConfig) (time.Duration, error) {
+	if cfg.Timeout == "" {
+		return 120 * time.Second, nil
+	}
+	d, err := time.ParseDuration(cfg.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid external provider timeout: %w", err)
+	}
+	return d, nil
+}
+
+// providerEnv is the environment of a provider command: ours plus the configured variables.
+func providerEnv(cfg *config.ExternalProviderConfig) []string {
+	env := os.Environ()
+	for key, value := range cfg.Env {
+		env = append(env, key+"="+os.ExpandEnv(value))
+	}
+	return env
+}
+
+// startExternalProvider launches the configured provider command.
+func startExternalProvider(cfg *config.ExternalProviderConfig) (*externalProvider, error) {
+	timeout, err := providerTimeout(cfg)
+	if err != nil {
+		return nil, err
+	}
+	proc, err := startExternalProcess(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &externalProvider{cfg: cfg, timeout: timeout, proc: proc}, nil
+}
+
+// startExternalProcess starts the provider command and the reader of its stdout.
+func startExternalProcess(cfg *config.ExternalProviderConfig) (*externalProcess, error) {
+	cmd := exec.Command(cfg.Command[0], cfg.Command[1:]...)
+	cmd.Env = providerEnv(cfg)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start external provider: %w", err)
+	}
+
+	proc := &externalProcess{
+		cmd:   cmd,
+		stdin: stdin,
+		lines: make(chan readResult),
+		stop:  make(chan struct{}),
+	}
+	go func() {
+		reader := bufio.NewReaderSize(stdout, 1<<20)
+		for {
+			line, err := reader.ReadBytes('\n')
+			select {
+			case proc.lines <- readResult{line, err}:
+			case <-proc.stop:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return proc, nil
+}
+
+// kill stops the process and its reader without waiting for pending work.
+func (p *externalProcess) kill() {
+	close(p.stop)
+	p.cmd.Process.Kill()
+	go p.cmd.Wait()
+}
+
+// Execute sends a request to the subprocess and waits for its response. When the
+// previous subprocess was stopped, a new one is started first.
+func (e *externalProvider) Execute(req ExternalRequest) (*ExternalResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if e.proc == nil {
+		if e.proc, err = startExternalProcess(e.cfg); err != nil {
+			return nil, err
+		}
+	}
+	proc := e.proc
+
+	if _, err := proc.stdin.Write(append(line, '\n')); err != nil {
+		proc.kill()
+		e.proc = nil
+		return nil, fmt.Errorf("external provider is not accepting requests: %w", err)
+	}
+
+	var read readResult
+	select {
+	case read = <-proc.lines:
+	case <-time.After(e.timeout):
+		// The pending reply would desynchronize later requests, so the process is
+		// replaced on the next one
+		proc.kill()
+		e.proc = nil
+		return nil, fmt.Errorf("external provider timed out after %s", e.timeout)
+	}
+	if read.err != nil {
+		// The process exited, so the next request starts a new one
+		proc.kill()
+		e.proc = nil
+		if len(read.line) == 0 {
+			return nil, fmt.Errorf("failed to read external provider response: %w", read.err)
+		}
+	}
+
+	return decodeExternalResponse(read.line, req.ID)
+}
+
+// decodeExternalResponse parses a provider's reply to the request with the given ID.
+func decodeExternalResponse(data []byte, id string) (*ExternalResponse, error) {
+	var resp ExternalResponse
+	if err := json.Unmarshal(bytes.TrimSpace(data), &resp); err != nil {
+		return nil, fmt.Errorf("invalid external provider response: %w", err)
+	}
+	if resp.ID != "" && resp.ID != id {
+		return nil, fmt.Errorf("external provider answered %s, expected %s", resp.ID, id)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("external provider error: %s", resp.Error)
+	}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	return &resp, nil
+}
+
+// Close stops the subprocess after letting it finish in-flight work.
+func (e *externalProvider) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	proc := e.proc
+	if proc == nil {
+		return
+	}
+	e.proc = nil
+	proc.stdin.Close()
+	close(proc.stop)
+
+	done := make(chan struct{})
+	go func() {
+		proc.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		proc.cmd.Process.Kill()
+	}
+}
+
+// httpResponse converts an external response into an *http.Response for tracing and forwarding.
+func (r *ExternalResponse) httpResponse() *http.Response {
+	header := make(http.Header)
+	for key, value := range r.Headers {
+		header.Set(key, value)
+	}
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		StatusCode: r.Status,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(r.Body)),
+	}
+}
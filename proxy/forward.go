@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Traffic arrives in forward-proxy form when applications honor HTTP_PROXY/HTTPS_PROXY
+// instead of a provider base URL: plain HTTP requests carry an absolute URL, and HTTPS
+// requests open a CONNECT tunnel. Only the provider's own host and provider.allow_hosts
+// are accepted; everything else is refused so unexpected egress is visible.
+
+// isForwardRequest reports whether r was sent to the proxy as a forward proxy.
+func isForwardRequest(r *http.Request) bool {
+	return r.Method == http.MethodConnect || r.URL.IsAbs()
+}
+
+// providerHost returns the host of the configured provider endpoint, or "" if there is none.
+func (p *LLMProxy) providerHost() string {
+	if target, ok := p.providers[p.config.Provider.Type]; ok {
+		return target.Hostname()
+	}
+	return ""
+}
+
+// hostAllowed reports whether forward-proxy traffic to host is accepted. Entries in
+// provider.allow_hosts match exactly, or as a suffix when written as "*.example.com".
+func (p *LLMProxy) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	if host == strings.ToLower(p.providerHost()) {
+		return true
+	}
+	for _, allowed := range p.config.Provider.AllowHosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// handleForwardRequest serves forward-proxy traffic. Plain HTTP requests to the provider's
+// host are recorded like any other call; other allowed hosts are passed through unrecorded,
+// and CONNECT tunnels are spliced without inspecting the encrypted traffic.
+func (p *LLMProxy) handleForwardRequest(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Hostname()
+	if r.Method == http.MethodConnect {
+		host, _, _ = net.SplitHostPort(r.Host)
+	}
+	if !p.hostAllowed(host) {
+		http.Error(w, fmt.Sprintf("regrada: host %s is not allowed (add it to provider.allow_hosts)", host), http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodConnect {
+		if _, seen := p.tunneled.LoadOrStore(host, true); !seen && p.OnWarning != nil {
+			p.OnWarning(fmt.Sprintf("HTTPS traffic to %s is tunneled without capture; point the SDK base URL at the proxy to record it", host))
+		}
+		p.tunnel(w, r)
+		return
+	}
+
+	if strings.EqualFold(host, p.providerHost()) {
+		// Recorded through the regular path, which rewrites the target itself
+		r.URL.Scheme, r.URL.Host = "", ""
+		p.handleRequest(w, r)
+		return
+	}
+
+	outReq, err := http.NewRequest(r.Method, r.URL.String(), r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	outReq.Header = r.Header.Clone()
+	outReq.Header.Del("Proxy-Connection")
+	outReq.Header.Del("Proxy-Authorization")
+
+	resp, respBody, _, err := p.executeProxyRequest(outReq)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	p.writeResponse(w, resp, respBody)
+}
+
+// tunnel opens a TCP connection to the CONNECT target and copies bytes in both directions.
+func (p *LLMProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := net.DialTimeout("tcp", r.Host, 30*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+
+	go func() {
+		io.Copy(upstream, buffered)
+		upstream.Close()
+	}()
+	io.Copy(client, upstream)
+	client.Close()
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/matias/regrada/trace"
+)
+
+// geminiBlockReasons are Gemini finish reasons that mean the response was blocked.
+var geminiBlockReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+// geminiModelFromPath extracts the model from a generateContent path such as
+// /v1beta/models/gemini-2.0-flash:generateContent.
+func geminiModelFromPath(path string) string {
+	idx := strings.Index(path, "/models/")
+	if idx < 0 {
+		return ""
+	}
+	model := path[idx+len("/models/"):]
+	if colon := strings.Index(model, ":"); colon >= 0 {
+		model = model[:colon]
+	}
+	return model
+}
+
+// parseGeminiDetails extracts token usage and function calls from a generateContent response.
+func parseGeminiDetails(respData map[string]interface{}) (tokensIn, tokensOut int, toolCalls []trace.ToolCall) {
+	if usage, ok := respData["usageMetadata"].(map[string]interface{}); ok {
+		if pt, ok := usage["promptTokenCount"].(float64); ok {
+			tokensIn = int(pt)
+		}
+		if ct, ok := usage["candidatesTokenCount"].(float64); ok {
+			tokensOut = int(ct)
+		}
+	}
+
+	for _, part := range geminiParts(respData) {
+		if fc, ok := part["functionCall"].(map[string]interface{}); ok {
+			toolCall := trace.ToolCall{
+				ID:   getString(fc, "id"),
+				Name: getString(fc, "name"),
+			}
+			if args, ok := fc["args"]; ok {
+				if argsBytes, err := json.Marshal(args); err == nil {
+					toolCall.Args = json.RawMessage(argsBytes)
+				}
+			}
+			toolCalls = append(toolCalls, toolCall)
+		}
+	}
+	return
+}
+
+// geminiParts returns the content parts of the first candidate.
+func geminiParts(respData map[string]interface{}) []map[string]interface{} {
+	candidates, ok := respData["candidates"].([]interface{})
+	if !ok || len(candidates) == 0 {
+		return nil
+	}
+	candidate, ok := candidates[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	content, ok := candidate["content"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	rawParts, _ := content["parts"].([]interface{})
+
+	parts := make([]map[string]interface{}, 0, len(rawParts))
+	for _, p := range rawParts {
+		if part, ok := p.(map[string]interface{}); ok {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// geminiFinishReason returns the raw finish reason of the first candidate.
+func geminiFinishReason(respData map[string]interface{}) string {
+	candidates, ok := respData["candidates"].([]interface{})
+	if !ok || len(candidates) == 0 {
+		return ""
+	}
+	if candidate, ok := candidates[0].(map[string]interface{}); ok {
+		return getString(candidate, "finishReason")
+	}
+	return ""
+}
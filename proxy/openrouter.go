@@ -0,0 +1,62 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/matias/regrada/config"
+)
+
+// openRouterBaseURL is the OpenRouter host; the OpenAI-compatible API lives under /api/v1.
+const openRouterBaseURL = "https://openrouter.ai"
+
+// applyOpenRouterHeaders sets the app attribution headers OpenRouter uses for its rankings
+// and, when the key variable is set, the bearer token, so the application under test can
+// keep a placeholder key.
+func applyOpenRouterHeaders(req *http.Request, cfg *config.OpenRouterConfig) {
+	keyEnv := "OPENROUTER_API_KEY"
+	if cfg != nil {
+		if cfg.SiteURL != "" {
+			req.Header.Set("HTTP-Referer", cfg.SiteURL)
+		}
+		if cfg.AppName != "" {
+			req.Header.Set("X-Title", cfg.AppName)
+		}
+		if cfg.APIKeyEnv != "" {
+			keyEnv = cfg.APIKeyEnv
+		}
+	}
+	if key := os.Getenv(keyEnv); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+}
+
+// applyOpenRouterRouting adds the configured fallback models ("models") and provider
+// preferences ("provider.order") to a request body that doesn't set them already.
+// Bodies that are not JSON objects are returned unchanged.
+func applyOpenRouterRouting(body []byte, cfg *config.OpenRouterConfig) []byte {
+	if cfg == nil || (len(cfg.Fallbacks) == 0 && len(cfg.ProviderOrder) == 0) {
+		return body
+	}
+
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(body, &reqData); err != nil {
+		return body
+	}
+
+	if _, ok := reqData["models"]; !ok && len(cfg.Fallbacks) > 0 {
+		reqData["models"] = cfg.Fallbacks
+	}
+	if _, ok := reqData["provider"]; !ok && len(cfg.ProviderOrder) > 0 {
+		reqData["provider"] = map[string]interface{}{"order": cfg.ProviderOrder}
+	}
+
+	rewritten, err := json.Marshal(reqData)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import "encoding/json"
+
+// overrideSystemPrompt replaces the system prompt in a request body with the given prompt.
+// Anthropic requests carry the system prompt in a top-level "system" field and Gemini
+// requests in "systemInstruction" (Bedrock uses the Anthropic field for Claude bodies and
+// a list of system blocks for Converse); every other provider uses a "system" role
+// message, which is prepended when the request has none. Bodies that are not JSON
+// objects are returned unchanged.
+func overrideSystemPrompt(provider string, body []byte, prompt string) []byte {
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(body, &reqData); err != nil {
+		return body
+	}
+
+	if provider == "bedrock" && reqData["anthropic_version"] == nil {
+		// Bedrock Converse takes a list of system content blocks
+		reqData["system"] = []interface{}{map[string]interface{}{"text": prompt}}
+	} else if provider == "anthropic" || provider == "bedrock" {
+		reqData["system"] = prompt
+	} else if provider == "gemini" {
+		reqData["systemInstruction"] = map[string]interface{}{
+			"parts": []interface{}{map[string]interface{}{"text": prompt}},
+		}
+	} else {
+		messages, ok := reqData["messages"].([]interface{})
+		if !ok {
+			return body
+		}
+
+		replaced := false
+		for _, m := range messages {
+			if msg, ok := m.(map[string]interface{}); ok && msg["role"] == "system" {
+				msg["content"] = prompt
+				replaced = true
+			}
+		}
+		if !replaced {
+			systemMsg := map[string]interface{}{"role": "system", "content": prompt}
+			messages = append([]interface{}{systemMsg}, messages...)
+		}
+		reqData["messages"] = messages
+	}
+
+	rewritten, err := json.Marshal(reqData)
+	if err != nil {
+		return body
+	}
+	return rewritten
+}
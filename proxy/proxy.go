@@ -13,11 +13,13 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/matias/regrada/config"
+	"github.com/matias/regrada/sigv4"
 	"github.com/matias/regrada/trace"
 )
 
@@ -30,6 +32,42 @@ type LLMProxy struct {
 	config     *config.RegradaConfig
 	providers  map[string]*url.URL
 	httpClient *http.Client
+
+	// external serves requests when the provider type is "external" or "exec".
+	external subprocessProvider
+
+	// replay serves recorded responses when the provider type is "replay".
+	replay *replayStore
+
+	// cassettes records and replays forwarded calls when cassettes.mode is set.
+	cassettes *cassetteStore
+
+	// cache reuses responses to identical requests when cache.enabled is set.
+	cache *responseCache
+
+	// awsRegion is the region Bedrock requests are signed for.
+	awsRegion string
+
+	// azure maps requests onto an Azure OpenAI deployment when the provider is azure-openai.
+	azure *azureDeployment
+
+	// tunneled remembers hosts already reported as tunneled without capture.
+	tunneled sync.Map
+
+	// systemPrompt replaces the system prompt of every forwarded request when non-empty.
+	systemPrompt string
+
+	// retry retries transient upstream failures when provider.retry is set.
+	retry *retryPolicy
+
+	// defaultCase tags calls that don't send an X-Regrada-Case header, from REGRADA_CASE.
+	defaultCase string
+
+	// OnTrace, if set, is called with each trace as soon as it is recorded.
+	OnTrace func(trace.LLMTrace)
+
+	// OnWarning, if set, is called with problems found in requests before they are forwarded.
+	OnWarning func(string)
 }
 
 // New creates a new LLM proxy server.
@@ -45,6 +83,9 @@ func New(cfg *config.RegradaConfig) (*LLMProxy, error) {
 		traces:    []trace.LLMTrace{},
 		config:    cfg,
 		providers: make(map[string]*url.URL),
+
+		retry:       newRetryPolicy(cfg.Provider.Retry),
+		defaultCase: os.Getenv(trace.CaseEnv),
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second,
 			Transport: &http.Transport{
@@ -62,6 +103,8 @@ func New(cfg *config.RegradaConfig) (*LLMProxy, error) {
 		targetURL, _ = url.Parse("https://api.openai.com")
 	case "anthropic":
 		targetURL, _ = url.Parse("https://api.anthropic.com")
+	case "gemini":
+		targetURL, _ = url.Parse("https://generativelanguage.googleapis.com")
 	case "azure", "azure-openai":
 		if cfg.Provider.BaseURL == "" {
 			return nil, fmt.Errorf("Azure provider requires base_url in config")
@@ -70,6 +113,33 @@ func New(cfg *config.RegradaConfig) (*LLMProxy, error) {
 		if err != nil {
 			return nil, fmt.Errorf("invalid Azure base_url: %w", err)
 		}
+		proxy.azure = newAzureDeployment(cfg.Provider)
+	case "openrouter":
+		base := cfg.Provider.BaseURL
+		if base == "" {
+			base = openRouterBaseURL
+		}
+		targetURL, err = url.Parse(base)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OpenRouter base_url: %w", err)
+		}
+	case "ollama":
+		base := cfg.Provider.BaseURL
+		if base == "" {
+			base = "http://localhost:11434"
+		}
+		targetURL, err = url.Parse(base)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Ollama base_url: %w", err)
+		}
+	case "openai-compatible":
+		if cfg.Provider.BaseURL == "" {
+			return nil, fmt.Errorf("OpenAI-compatible provider requires base_url in config")
+		}
+		targetURL, err = url.Parse(cfg.Provider.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OpenAI-compatible base_url: %w", err)
+		}
 	case "custom":
 		if cfg.Provider.BaseURL == "" {
 			return nil, fmt.Errorf("Custom provider requires base_url in config")
@@ -78,17 +148,81 @@ func New(cfg *config.RegradaConfig) (*LLMProxy, error) {
 		if err != nil {
 			return nil, fmt.Errorf("invalid custom base_url: %w", err)
 		}
+	case "bedrock":
+		region := cfg.Provider.Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		if region == "" {
+			return nil, fmt.Errorf("Bedrock provider requires region in config or AWS_REGION")
+		}
+		if _, err := sigv4.CredentialsFromEnv(); err != nil {
+			return nil, fmt.Errorf("Bedrock provider: %w", err)
+		}
+		proxy.awsRegion = region
+		targetURL = &url.URL{Scheme: "https", Host: "bedrock-runtime." + region + ".amazonaws.com"}
+		if cfg.Provider.BaseURL != "" {
+			if targetURL, err = url.Parse(cfg.Provider.BaseURL); err != nil {
+				return nil, fmt.Errorf("invalid Bedrock base_url: %w", err)
+			}
+		}
+	case "external":
+		if cfg.Provider.External == nil || len(cfg.Provider.External.Command) == 0 {
+			return nil, fmt.Errorf("External provider requires external.command in config")
+		}
+		external, err := startExternalProvider(cfg.Provider.External)
+		if err != nil {
+			return nil, err
+		}
+		proxy.external = external
+	case "replay":
+		proxy.replay, err = loadReplayStore()
+		if err != nil {
+			return nil, fmt.Errorf("Replay provider: %w", err)
+		}
+	case "exec":
+		if cfg.Provider.Exec == nil || len(cfg.Provider.Exec.Command) == 0 {
+			return nil, fmt.Errorf("Exec provider requires exec.command in config")
+		}
+		execProv, err := newExecProvider(cfg.Provider.Exec)
+		if err != nil {
+			return nil, err
+		}
+		proxy.external = execProv
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", cfg.Provider.Type)
 	}
 
-	proxy.providers[cfg.Provider.Type] = targetURL
+	if targetURL != nil {
+		proxy.providers[cfg.Provider.Type] = targetURL
+	}
+
+	proxy.cassettes, err = newCassetteStore(cfg.Cassettes)
+	if err != nil {
+		return nil, err
+	}
+	proxy.cache = newResponseCache(cfg.Cache)
+
+	if cfg.Provider.SystemPromptFile != "" {
+		prompt, _, err := config.LoadPrompt(cfg.Provider.SystemPromptFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read system prompt file: %w", err)
+		}
+		proxy.systemPrompt = prompt
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", proxy.handleRequest)
 
 	proxy.server = &http.Server{
-		Handler: mux,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// CONNECT requests carry no path, so they never reach the mux patterns
+			if isForwardRequest(r) {
+				proxy.handleForwardRequest(w, r)
+				return
+			}
+			mux.ServeHTTP(w, r)
+		}),
 	}
 
 	go proxy.server.Serve(listener)
@@ -113,16 +247,25 @@ func (p *LLMProxy) Shutdown() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	p.server.Shutdown(ctx)
+	if p.external != nil {
+		p.external.Close()
+	}
 }
 
 // handleRequest is the main proxy handler that intercepts, forwards, and records LLM API calls.
 func (p *LLMProxy) handleRequest(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 
-	// Use the configured provider type
+	if p.replay != nil {
+		p.handleReplayRequest(w, r)
+		return
+	}
+
+	// Use the configured provider type. External and exec providers are commands
+	// rather than URLs, but are otherwise served like any other provider
 	targetProvider := p.config.Provider.Type
 	targetURL, ok := p.providers[targetProvider]
-	if !ok {
+	if !ok && p.external == nil {
 		http.Error(w, fmt.Sprintf("Provider %s not configured", targetProvider), http.StatusBadGateway)
 		return
 	}
@@ -134,32 +277,185 @@ func (p *LLMProxy) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create and execute proxy request
-	proxyReq, err := p.createProxyRequest(r, targetURL, requestBody)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if p.systemPrompt != "" {
+		requestBody = overrideSystemPrompt(targetProvider, requestBody, p.systemPrompt)
 	}
 
-	resp, responseBody, err := p.executeProxyRequest(proxyReq)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadGateway)
+	if targetProvider == "openrouter" {
+		requestBody = applyOpenRouterRouting(requestBody, p.config.Provider.OpenRouter)
+	}
+	if targetProvider == "openai-compatible" {
+		requestBody = applyDefaultModel(requestBody, p.config.Provider.Model)
+	}
+	if p.config.Provider.ForceModel != "" {
+		requestBody = overrideModel(requestBody, p.config.Provider.ForceModel)
+	}
+
+	budgetViolation, blocked := p.enforcePromptBudget(w, r, requestBody)
+	if blocked {
 		return
 	}
+
+	var cassetteKeyHash string
+	if p.cassettes != nil {
+		cassetteKeyHash = cassetteKey(r.Method, r.URL.Path, requestBody)
+		if p.cassettes.mode != CassetteRecord {
+			if c, ok := p.cassettes.load(cassetteKeyHash); ok {
+				p.serveCassette(w, r, targetProvider, requestBody, c, "cassette", cassetteKeyHash)
+				return
+			}
+			if p.cassettes.mode == CassetteReplay {
+				http.Error(w, fmt.Sprintf("regrada: no cassette for %s %s (key %s)", r.Method, r.URL.Path, cassetteKeyHash[:12]), http.StatusNotFound)
+				return
+			}
+		}
+	}
+
+	var cacheKeyHash string
+	if p.cache != nil {
+		upstream := targetProvider + ":"
+		if targetURL != nil {
+			upstream = targetURL.String()
+		}
+		cacheKeyHash = cassetteKey(r.Method, upstream+r.URL.Path, requestBody)
+		if c, ok := p.cache.get(cacheKeyHash); ok {
+			p.serveCassette(w, r, targetProvider, requestBody, c, "cached", cacheKeyHash)
+			return
+		}
+	}
+
+	var (
+		resp         *http.Response
+		responseBody []byte
+		firstByte    time.Time
+		retries      int
+		latency      time.Duration
+	)
+	if p.external != nil {
+		resp, responseBody, latency, err = p.executeExternal(r, requestBody, startTime)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	} else {
+		// Create and execute proxy request
+		proxyReq, err := p.createProxyRequest(r, targetURL, requestBody)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp, responseBody, firstByte, startTime, retries, err = p.forwardWithRetry(proxyReq, r, targetURL, requestBody)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		latency = time.Since(startTime)
+	}
 	defer resp.Body.Close()
+	endTime := startTime.Add(latency)
 
-	latency := time.Since(startTime)
+	// Server errors are usually transient, so they are never stored
+	if p.cassettes != nil && resp.StatusCode < 500 {
+		if err := p.cassettes.save(cassetteKeyHash, r, requestBody, resp, responseBody, latency); err != nil && p.OnWarning != nil {
+			p.OnWarning(fmt.Sprintf("failed to record cassette: %v", err))
+		}
+	}
+	if p.cache != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if err := p.cache.store.save(cacheKeyHash, r, requestBody, resp, responseBody, latency); err != nil && p.OnWarning != nil {
+			p.OnWarning(fmt.Sprintf("failed to cache response: %v", err))
+		}
+	}
 
 	// Record trace
 	tr := p.createTrace(targetProvider, r, requestBody, resp, responseBody, latency)
+	tr.Retries = retries
+	if p.external == nil && isStreamingResponse(requestBody, resp) {
+		tr.Stream = streamMetrics(startTime, firstByte, endTime, tr.TokensOut)
+	}
+	if budgetViolation != "" {
+		setMetadata(&tr, "prompt_budget", budgetViolation)
+	}
 	p.mu.Lock()
 	p.traces = append(p.traces, tr)
 	p.mu.Unlock()
 
+	if p.OnTrace != nil {
+		p.OnTrace(tr)
+	}
+
 	// Write response to client
 	p.writeResponse(w, resp, responseBody)
 }
 
+// executeExternal sends a request to the external or exec provider command. The
+// latency is the one the command reports, or the wall-clock time when it reports none.
+func (p *LLMProxy) executeExternal(r *http.Request, requestBody []byte, startTime time.Time) (*http.Response, []byte, time.Duration, error) {
+	headers := flattenHeaders(r.Header)
+	for key := range headers {
+		if strings.HasPrefix(key, "X-Regrada-") {
+			delete(headers, key)
+		}
+	}
+	// Stamp configured attribution headers
+	for key, value := range p.config.Provider.Headers {
+		headers[http.CanonicalHeaderKey(key)] = os.ExpandEnv(value)
+	}
+
+	extResp, err := p.external.Execute(ExternalRequest{
+		ID:      generateTraceID(),
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Headers: headers,
+		Body:    sanitizeBody(requestBody),
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	latency := time.Since(startTime)
+	if extResp.Timings.LatencyMs > 0 {
+		latency = time.Duration(extResp.Timings.LatencyMs) * time.Millisecond
+	}
+	return extResp.httpResponse(), extResp.Body, latency, nil
+}
+
+// enforcePromptBudget checks a request against the configured prompt budget. Violations are
+// reported through OnWarning; in block mode the request is answered with 400 and not forwarded.
+func (p *LLMProxy) enforcePromptBudget(w http.ResponseWriter, r *http.Request, requestBody []byte) (string, bool) {
+	budget := p.config.Provider.PromptBudget
+	if budget == nil {
+		return "", false
+	}
+
+	model, _, _, _ := parseAPIDetails(p.config.Provider.Type, requestBody, nil)
+	if model == "" {
+		model = geminiModelFromPath(r.URL.Path)
+	}
+
+	violation := checkPromptBudget(budget, model, requestBody)
+	if violation == "" {
+		return "", false
+	}
+
+	if p.OnWarning != nil {
+		p.OnWarning(fmt.Sprintf("%s %s", r.URL.Path, violation))
+	}
+
+	if budget.Action == "block" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{
+				"type":    "regrada_prompt_budget_exceeded",
+				"message": "regrada: " + violation,
+			},
+		})
+		return violation, true
+	}
+	return violation, false
+}
+
 // readRequestBody reads and buffers the request body.
 func (p *LLMProxy) readRequestBody(r *http.Request) ([]byte, error) {
 	if r.Body == nil {
@@ -180,7 +476,12 @@ func (p *LLMProxy) readRequestBody(r *http.Request) ([]byte, error) {
 func (p *LLMProxy) createProxyRequest(r *http.Request, targetURL *url.URL, requestBody []byte) (*http.Request, error) {
 	proxyURL := *targetURL
 	proxyURL.Path = r.URL.Path
+	proxyURL.RawPath = r.URL.RawPath
 	proxyURL.RawQuery = r.URL.RawQuery
+	if p.config.Provider.Type == "openai-compatible" {
+		proxyURL.Path = compatiblePath(targetURL.Path, r.URL.Path)
+		proxyURL.RawPath = ""
+	}
 
 	proxyReq, err := http.NewRequest(r.Method, proxyURL.String(), bytes.NewBuffer(requestBody))
 	if err != nil {
@@ -197,29 +498,63 @@ func (p *LLMProxy) createProxyRequest(r *http.Request, targetURL *url.URL, reque
 		}
 	}
 
+	// Stamp configured attribution headers
+	for key, value := range p.config.Provider.Headers {
+		proxyReq.Header.Set(key, os.ExpandEnv(value))
+	}
+
+	if p.azure != nil {
+		p.azure.rewrite(proxyReq)
+	}
+
+	if p.config.Provider.Type == "openrouter" {
+		applyOpenRouterHeaders(proxyReq, p.config.Provider.OpenRouter)
+	}
+
+	if p.config.Provider.Type == "openai-compatible" {
+		applyCompatibleAuth(proxyReq, p.config.Provider.OpenAICompatible)
+	}
+
+	if p.awsRegion != "" {
+		// The client signed for the proxy's address, so re-sign for the real endpoint
+		creds, err := sigv4.CredentialsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		sigv4.Sign(proxyReq, requestBody, "bedrock", p.awsRegion, creds, time.Now())
+	}
+
+	if p.config.Provider.Signing != nil {
+		if err := signRequest(proxyReq, requestBody, p.config.Provider.Signing); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
 	return proxyReq, nil
 }
 
-// executeProxyRequest executes the proxy request and reads the response.
-func (p *LLMProxy) executeProxyRequest(proxyReq *http.Request) (*http.Response, []byte, error) {
+// executeProxyRequest executes the proxy request and reads the response. It also returns
+// when the first bytes of the response body arrived, for time-to-first-token.
+func (p *LLMProxy) executeProxyRequest(proxyReq *http.Request) (*http.Response, []byte, time.Time, error) {
 	resp, err := p.httpClient.Do(proxyReq)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, time.Time{}, err
 	}
 
 	// Read response body, handling gzip encoding
+	body := &firstByteReader{r: resp.Body}
 	var responseBody []byte
 	if resp.Header.Get("Content-Encoding") == "gzip" {
-		gzReader, err := gzip.NewReader(resp.Body)
+		gzReader, err := gzip.NewReader(body)
 		if err == nil {
 			responseBody, _ = io.ReadAll(gzReader)
 			gzReader.Close()
 		}
 	} else {
-		responseBody, _ = io.ReadAll(resp.Body)
+		responseBody, _ = io.ReadAll(body)
 	}
 
-	return resp, responseBody, nil
+	return resp, responseBody, body.firstByte, nil
 }
 
 // writeResponse writes the proxied response back to the client.
@@ -261,7 +596,35 @@ func (p *LLMProxy) createTrace(provider string, req *http.Request, reqBody []byt
 	}
 
 	// Extract model and tokens from request/response
-	tr.Model, tr.TokensIn, tr.TokensOut, tr.ToolCalls = parseAPIDetails(provider, reqBody, respBody)
+	if provider == "bedrock" {
+		tr.Model, tr.TokensIn, tr.TokensOut, tr.ToolCalls = parseBedrockDetails(req.URL.Path, reqBody, respBody)
+	} else {
+		tr.Model, tr.TokensIn, tr.TokensOut, tr.ToolCalls = parseAPIDetails(provider, reqBody, respBody)
+	}
+	if tr.Model == "" && provider == "gemini" {
+		tr.Model = geminiModelFromPath(req.URL.Path)
+	}
+	tr.ContentFiltered = isContentFiltered(respBody)
+	tr.FinishReason = parseFinishReason(respBody)
+	tr.Attachments = extractAttachments(reqBody)
+	tr.ToolsAvailable = extractToolDefinitions(reqBody)
+
+	if c := req.Header.Get(trace.CaseHeader); c != "" {
+		setMetadata(&tr, trace.CaseMetadataKey, c)
+	} else if p.defaultCase != "" {
+		setMetadata(&tr, trace.CaseMetadataKey, p.defaultCase)
+	}
+	if traceID, spanID, ok := trace.ParseTraceparent(req.Header.Get(trace.TraceparentHeader)); ok {
+		setMetadata(&tr, trace.TraceIDMetadataKey, traceID)
+		setMetadata(&tr, trace.SpanIDMetadataKey, spanID)
+	}
+
+	if provider == "anthropic" {
+		tr.Thinking, tr.RedactedThinking = extractThinking(respBody)
+		if p.config.Storage.StripThinking && (tr.Thinking != "" || tr.RedactedThinking > 0) {
+			tr.Response.Body = sanitizeBody(stripThinking(respBody))
+		}
+	}
 
 	return tr
 }
@@ -281,15 +644,8 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 
 	// Provider-specific parsing
 	switch provider {
-	case "openai":
-		if usage, ok := respData["usage"].(map[string]interface{}); ok {
-			if pt, ok := usage["prompt_tokens"].(float64); ok {
-				tokensIn = int(pt)
-			}
-			if ct, ok := usage["completion_tokens"].(float64); ok {
-				tokensOut = int(ct)
-			}
-		}
+	case "openai", "azure", "azure-openai", "openrouter", "openai-compatible":
+		tokensIn, tokensOut = parseUsage(respData)
 		// Extract tool calls
 		if choices, ok := respData["choices"].([]interface{}); ok && len(choices) > 0 {
 			if choice, ok := choices[0].(map[string]interface{}); ok {
@@ -302,9 +658,7 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 								}
 								if fn, ok := tcMap["function"].(map[string]interface{}); ok {
 									toolCall.Name = getString(fn, "name")
-									if args, ok := fn["arguments"].(string); ok {
-										toolCall.Args = json.RawMessage(args)
-									}
+									toolCall.Args = toolArgs(fn["arguments"])
 								}
 								toolCalls = append(toolCalls, toolCall)
 							}
@@ -313,16 +667,12 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 				}
 			}
 		}
+		if len(toolCalls) == 0 {
+			toolCalls = parseResponsesToolCalls(respData)
+		}
 
 	case "anthropic":
-		if usage, ok := respData["usage"].(map[string]interface{}); ok {
-			if it, ok := usage["input_tokens"].(float64); ok {
-				tokensIn = int(it)
-			}
-			if ot, ok := usage["output_tokens"].(float64); ok {
-				tokensOut = int(ot)
-			}
-		}
+		tokensIn, tokensOut = parseUsage(respData)
 		// Extract tool use from Anthropic format
 		if content, ok := respData["content"].([]interface{}); ok {
 			for _, c := range content {
@@ -332,18 +682,17 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 							ID:   getString(cMap, "id"),
 							Name: getString(cMap, "name"),
 						}
-						if input, ok := cMap["input"]; ok {
-							if inputBytes, err := json.Marshal(input); err == nil {
-								toolCall.Args = json.RawMessage(inputBytes)
-							}
-						}
+						toolCall.Args = toolArgs(cMap["input"])
 						toolCalls = append(toolCalls, toolCall)
 					}
 				}
 			}
 		}
 
-	case "custom":
+	case "gemini":
+		tokensIn, tokensOut, toolCalls = parseGeminiDetails(respData)
+
+	case "custom", "ollama":
 		// Handle Ollama and other custom providers
 		// Try Ollama format first
 		if msg, ok := respData["message"].(map[string]interface{}); ok {
@@ -356,11 +705,7 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 						}
 						if fn, ok := tcMap["function"].(map[string]interface{}); ok {
 							toolCall.Name = getString(fn, "name")
-							if args, ok := fn["arguments"]; ok {
-								if argsBytes, err := json.Marshal(args); err == nil {
-									toolCall.Args = json.RawMessage(argsBytes)
-								}
-							}
+							toolCall.Args = toolArgs(fn["arguments"])
 						}
 						toolCalls = append(toolCalls, toolCall)
 					}
@@ -368,13 +713,8 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 			}
 		}
 
-		// Extract token counts for Ollama
-		if pc, ok := respData["prompt_eval_count"].(float64); ok {
-			tokensIn = int(pc)
-		}
-		if ec, ok := respData["eval_count"].(float64); ok {
-			tokensOut = int(ec)
-		}
+		// Ollama eval counts, or an OpenAI-style usage block from other custom providers
+		tokensIn, tokensOut = parseUsage(respData)
 
 		// Fallback: try OpenAI-compatible format for custom providers
 		if len(toolCalls) == 0 {
@@ -389,13 +729,7 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 									}
 									if fn, ok := tcMap["function"].(map[string]interface{}); ok {
 										toolCall.Name = getString(fn, "name")
-										if args, ok := fn["arguments"]; ok {
-											if argsStr, ok := args.(string); ok {
-												toolCall.Args = json.RawMessage(argsStr)
-											} else if argsBytes, err := json.Marshal(args); err == nil {
-												toolCall.Args = json.RawMessage(argsBytes)
-											}
-										}
+										toolCall.Args = toolArgs(fn["arguments"])
 									}
 									toolCalls = append(toolCalls, toolCall)
 								}
@@ -407,21 +741,196 @@ func parseAPIDetails(provider string, reqBody, respBody []byte) (model string, t
 		}
 	}
 
+	// Streamed responses are not a single JSON document
+	if respData == nil && tokensIn == 0 && tokensOut == 0 {
+		tokensIn, tokensOut = parseStreamUsage(respBody)
+	}
+
 	return
 }
 
+// isContentFiltered reports whether a response was blocked or refused by the provider's safety system.
+// It recognizes OpenAI/Azure finish_reason "content_filter", Azure content_filter error codes,
+// Gemini prompt blocks and safety finish reasons, and any finish reason that normalizes to
+// content_filter (Anthropic refusals, Bedrock guardrails and content filters).
+func isContentFiltered(respBody []byte) bool {
+	var respData map[string]interface{}
+	if err := json.Unmarshal(respBody, &respData); err != nil {
+		return false
+	}
+
+	if errData, ok := respData["error"].(map[string]interface{}); ok {
+		if getString(errData, "code") == "content_filter" {
+			return true
+		}
+	}
+
+	if choices, ok := respData["choices"].([]interface{}); ok {
+		for _, c := range choices {
+			if choice, ok := c.(map[string]interface{}); ok && getString(choice, "finish_reason") == "content_filter" {
+				return true
+			}
+		}
+	}
+
+	if feedback, ok := respData["promptFeedback"].(map[string]interface{}); ok && getString(feedback, "blockReason") != "" {
+		return true
+	}
+	if geminiBlockReasons[geminiFinishReason(respData)] {
+		return true
+	}
+
+	return parseFinishReason(respBody) == "content_filter"
+}
+
+// finishReasons maps provider-specific stop reasons onto OpenAI-style finish reasons.
+var finishReasons = map[string]string{
+	"end_turn":      "stop",
+	"stop_sequence": "stop",
+	"max_tokens":    "length",
+	"tool_use":      "tool_calls",
+	"refusal":       "content_filter",
+	"function_call": "tool_calls",
+	"STOP":          "stop",
+	"MAX_TOKENS":    "length",
+
+	"content_filtered":     "content_filter",
+	"guardrail_intervened": "content_filter",
+	"FINISH":               "stop",
+	"LENGTH":               "length",
+	"CONTENT_FILTERED":     "content_filter",
+}
+
+// parseFinishReason extracts and normalizes why generation stopped, from OpenAI
+// choices[0].finish_reason, Anthropic stop_reason, Ollama done_reason, Bedrock Converse
+// stopReason, Titan completionReason, or Gemini candidates[0].finishReason.
+func parseFinishReason(respBody []byte) string {
+	var respData map[string]interface{}
+	if err := json.Unmarshal(respBody, &respData); err != nil {
+		return ""
+	}
+
+	reason := ""
+	if choices, ok := respData["choices"].([]interface{}); ok && len(choices) > 0 {
+		if choice, ok := choices[0].(map[string]interface{}); ok {
+			reason = getString(choice, "finish_reason")
+		}
+	}
+	if reason == "" {
+		reason = getString(respData, "stop_reason")
+	}
+	if reason == "" {
+		reason = getString(respData, "done_reason")
+	}
+	if reason == "" {
+		// Bedrock Converse
+		reason = getString(respData, "stopReason")
+	}
+	if reason == "" {
+		// Amazon Titan
+		if results, ok := respData["results"].([]interface{}); ok && len(results) > 0 {
+			if result, ok := results[0].(map[string]interface{}); ok {
+				reason = getString(result, "completionReason")
+			}
+		}
+	}
+	if reason == "" {
+		reason = geminiFinishReason(respData)
+		if geminiBlockReasons[reason] {
+			return "content_filter"
+		}
+		// Gemini reports STOP for function calls too
+		if reason == "STOP" {
+			for _, part := range geminiParts(respData) {
+				if _, ok := part["functionCall"]; ok {
+					return "tool_calls"
+				}
+			}
+		}
+	}
+
+	if normalized, ok := finishReasons[reason]; ok {
+		return normalized
+	}
+	return reason
+}
+
+// extractThinking collects the text of Anthropic thinking blocks and counts redacted_thinking blocks.
+func extractThinking(respBody []byte) (string, int) {
+	var respData map[string]interface{}
+	if err := json.Unmarshal(respBody, &respData); err != nil {
+		return "", 0
+	}
+
+	var thoughts []string
+	redacted := 0
+	if content, ok := respData["content"].([]interface{}); ok {
+		for _, c := range content {
+			cMap, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			switch cMap["type"] {
+			case "thinking":
+				thoughts = append(thoughts, getString(cMap, "thinking"))
+			case "redacted_thinking":
+				redacted++
+			}
+		}
+	}
+
+	return strings.Join(thoughts, "\n"), redacted
+}
+
+// stripThinking removes thinking and redacted_thinking blocks from an Anthropic response body.
+func stripThinking(respBody []byte) []byte {
+	var respData map[string]interface{}
+	if err := json.Unmarshal(respBody, &respData); err != nil {
+		return respBody
+	}
+
+	content, ok := respData["content"].([]interface{})
+	if !ok {
+		return respBody
+	}
+
+	kept := make([]interface{}, 0, len(content))
+	for _, c := range content {
+		if cMap, ok := c.(map[string]interface{}); ok && (cMap["type"] == "thinking" || cMap["type"] == "redacted_thinking") {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	respData["content"] = kept
+
+	stripped, err := json.Marshal(respData)
+	if err != nil {
+		return respBody
+	}
+	return stripped
+}
+
 // Helper functions
 
 func generateTraceID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
 
+// setMetadata sets a trace metadata entry, creating the map if needed.
+func setMetadata(tr *trace.LLMTrace, key, value string) {
+	if tr.Metadata == nil {
+		tr.Metadata = make(map[string]string)
+	}
+	tr.Metadata[key] = value
+}
+
 func flattenHeaders(h http.Header) map[string]string {
 	result := make(map[string]string)
 	for key, values := range h {
-		// Skip sensitive headers
-		lowerKey := strings.ToLower(key)
-		if lowerKey == "authorization" || lowerKey == "x-api-key" || lowerKey == "api-key" {
+		if signingHeader(key) {
+			continue
+		}
+		if sensitiveHeader(key) {
 			result[key] = "[REDACTED]"
 			continue
 		}
@@ -430,6 +939,34 @@ func flattenHeaders(h http.Header) map[string]string {
 	return result
 }
 
+// signingHeader reports whether a header is part of an AWS client's SigV4 signature.
+// The proxy signs Bedrock requests again, so the client's values are never sent and
+// aren't recorded.
+func signingHeader(name string) bool {
+	name = strings.ToLower(name)
+	return name == "x-amz-date" || name == "x-amz-content-sha256"
+}
+
+// credentialHeaders are the headers that carry credentials and must not be recorded.
+var credentialHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"x-api-key":           true,
+	"api-key":             true,
+	"x-goog-api-key":      true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// sensitiveHeader reports whether a header carries credentials that must not be
+// recorded: the credentialHeaders and *-token auth headers such as
+// x-amz-security-token. Usage headers that only mention tokens, such as
+// x-amzn-bedrock-input-token-count, are kept.
+func sensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	return credentialHeaders[name] || strings.HasSuffix(name, "-token")
+}
+
 func sanitizeBody(body []byte) json.RawMessage {
 	if len(body) == 0 {
 		return nil
@@ -446,6 +983,16 @@ func sanitizeBody(body []byte) json.RawMessage {
 	return json.RawMessage(body)
 }
 
+// rawBody undoes sanitizeBody: a body recorded as a JSON string, such as a
+// server-sent event stream or NDJSON, is returned as the text that was sent.
+func rawBody(body json.RawMessage) []byte {
+	var text string
+	if len(body) > 0 && body[0] == '"' && json.Unmarshal(body, &text) == nil {
+		return []byte(text)
+	}
+	return body
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if v, ok := m[key].(string); ok {
 		return v
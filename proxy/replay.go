@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/matias/regrada/eval"
+	"github.com/matias/regrada/trace"
+)
+
+// messageFields are the request fields that determine a model's answer across the
+// supported providers. Sampling parameters, stream flags, and metadata are left out
+// so incidental client changes still match a recording.
+var messageFields = []string{"messages", "system", "contents", "systemInstruction", "input", "prompt", "tools"}
+
+// messageHash identifies a request by its conversation. Bodies that are not JSON
+// objects are hashed whole.
+func messageHash(body []byte) string {
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(body, &reqData); err != nil {
+		sum := sha256.Sum256(body)
+		return hex.EncodeToString(sum[:])
+	}
+
+	conversation := make(map[string]interface{}, len(messageFields))
+	for _, field := range messageFields {
+		if v, ok := reqData[field]; ok {
+			conversation[field] = v
+		}
+	}
+	// encoding/json sorts map keys, so equal conversations encode identically
+	data, _ := json.Marshal(conversation)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayStore serves recorded responses from stored trace sessions instead of calling a provider.
+type replayStore struct {
+	byHash map[string]trace.LLMTrace
+}
+
+// loadReplayStore indexes every trace in .regrada/traces by message hash. When the same
+// conversation was recorded more than once, the most recent successful recording wins.
+func loadReplayStore() (*replayStore, error) {
+	sessions, err := eval.LoadAllSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	store := &replayStore{byHash: make(map[string]trace.LLMTrace)}
+	for _, session := range sessions {
+		for _, tr := range session.Traces {
+			if tr.Response.StatusCode >= 400 {
+				continue
+			}
+			store.byHash[messageHash(tr.Request.Body)] = tr
+		}
+	}
+	if len(store.byHash) == 0 {
+		return nil, fmt.Errorf("no recorded traces to replay in .regrada/traces")
+	}
+	return store, nil
+}
+
+// handleReplayRequest answers a request with the recorded response for the same conversation.
+// Requests without a recording fail with 404 so missing coverage is obvious.
+func (p *LLMProxy) handleReplayRequest(w http.ResponseWriter, r *http.Request) {
+	requestBody, err := p.readRequestBody(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	hash := messageHash(requestBody)
+	recorded, ok := p.replay.byHash[hash]
+	if !ok {
+		http.Error(w, fmt.Sprintf("regrada: no recorded response for this request (message hash %s)", hash[:12]), http.StatusNotFound)
+		return
+	}
+
+	header := make(http.Header)
+	for key, value := range recorded.Response.Headers {
+		header.Set(key, value)
+	}
+	responseBody := rawBody(recorded.Response.Body)
+	resp := &http.Response{
+		StatusCode: recorded.Response.StatusCode,
+		Header:     header,
+		Body:       io.NopCloser(bytes.NewReader(responseBody)),
+	}
+
+	// Recorded latencies are kept so latency checks and SLOs still mean something offline
+	tr := p.createTrace(recorded.Provider, r, requestBody, resp, responseBody, recorded.Latency*time.Millisecond)
+	setMetadata(&tr, "replayed_from", recorded.ID)
+	p.mu.Lock()
+	p.traces = append(p.traces, tr)
+	p.mu.Unlock()
+
+	if p.OnTrace != nil {
+		p.OnTrace(tr)
+	}
+
+	p.writeResponse(w, resp, responseBody)
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+// Retry defaults used when provider.retry sets max_attempts but leaves the rest out.
+const (
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+var defaultRetryOn = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// retryPolicy decides whether and when a failed upstream attempt is retried.
+type retryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	retryOn     map[int]bool
+}
+
+// newRetryPolicy builds the policy for provider.retry, or returns nil when retries are off.
+func newRetryPolicy(cfg *config.RetryConfig) *retryPolicy {
+	if cfg == nil || cfg.MaxAttempts <= 1 {
+		return nil
+	}
+	policy := &retryPolicy{
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     defaultRetryBackoff,
+		maxBackoff:  defaultRetryMaxBackoff,
+		retryOn:     make(map[int]bool),
+	}
+	if d, err := time.ParseDuration(cfg.Backoff); err == nil && d > 0 {
+		policy.backoff = d
+	}
+	if d, err := time.ParseDuration(cfg.MaxBackoff); err == nil && d > 0 {
+		policy.maxBackoff = d
+	}
+	statuses := cfg.RetryOn
+	if len(statuses) == 0 {
+		statuses = defaultRetryOn
+	}
+	for _, status := range statuses {
+		policy.retryOn[status] = true
+	}
+	return policy
+}
+
+// delay returns how long to wait before retry number n (1-based): the Retry-After header
+// when the provider sent one, otherwise exponential backoff with jitter, capped at maxBackoff.
+func (rp *retryPolicy) delay(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			return min(time.Duration(secs)*time.Second, rp.maxBackoff)
+		}
+	}
+	d := rp.backoff << (n - 1)
+	if d <= 0 || d > rp.maxBackoff {
+		d = rp.maxBackoff
+	}
+	// Up to 20% jitter so concurrent clients don't retry in lockstep
+	return d - time.Duration(rand.Int63n(int64(d)/5+1))
+}
+
+// forwardWithRetry sends proxyReq upstream, retrying network errors and retryable
+// statuses per provider.retry with a fresh request built from the original. It returns the
+// final attempt's response, when that attempt started (so latency excludes earlier
+// attempts and backoff), and the number of retries.
+func (p *LLMProxy) forwardWithRetry(proxyReq *http.Request, r *http.Request, targetURL *url.URL, requestBody []byte) (*http.Response, []byte, time.Time, time.Time, int, error) {
+	for retries := 0; ; retries++ {
+		start := time.Now()
+		if retries > 0 {
+			var err error
+			if proxyReq, err = p.createProxyRequest(r, targetURL, requestBody); err != nil {
+				return nil, nil, time.Time{}, start, retries, err
+			}
+		}
+
+		resp, body, firstByte, err := p.executeProxyRequest(proxyReq)
+		retryable := err != nil || (p.retry != nil && p.retry.retryOn[resp.StatusCode])
+		if p.retry == nil || !retryable || retries+1 >= p.retry.maxAttempts || r.Context().Err() != nil {
+			return resp, body, firstByte, start, retries, err
+		}
+
+		wait := p.retry.delay(retries+1, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return nil, nil, time.Time{}, start, retries, r.Context().Err()
+		}
+	}
+}
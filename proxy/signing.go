@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/config"
+)
+
+const defaultSigningTemplate = "{method}\n{path}\n{timestamp}\n{body}"
+
+// signRequest adds an HMAC signature header to a forwarded request.
+func signRequest(req *http.Request, body []byte, signing *config.SigningConfig) error {
+	secret := os.Getenv(signing.SecretEnv)
+	if secret == "" {
+		return fmt.Errorf("signing secret %s is not set", signing.SecretEnv)
+	}
+
+	var newHash func() hash.Hash
+	switch signing.Algorithm {
+	case "", "sha256":
+		newHash = sha256.New
+	case "sha512":
+		newHash = sha512.New
+	default:
+		return fmt.Errorf("unsupported signing algorithm: %s", signing.Algorithm)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	if signing.TimestampHeader != "" {
+		req.Header.Set(signing.TimestampHeader, timestamp)
+	}
+
+	template := signing.Template
+	if template == "" {
+		template = defaultSigningTemplate
+	}
+	payload := strings.NewReplacer(
+		"{method}", req.Method,
+		"{path}", req.URL.Path,
+		"{query}", req.URL.RawQuery,
+		"{timestamp}", timestamp,
+		"{body}", string(body),
+	).Replace(template)
+
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	sum := mac.Sum(nil)
+
+	var signature string
+	switch signing.Encoding {
+	case "", "hex":
+		signature = hex.EncodeToString(sum)
+	case "base64":
+		signature = base64.StdEncoding.EncodeToString(sum)
+	default:
+		return fmt.Errorf("unsupported signature encoding: %s", signing.Encoding)
+	}
+
+	req.Header.Set(signing.Header, signing.Prefix+signature)
+	return nil
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/matias/regrada/trace"
+)
+
+// firstByteReader records when the first bytes of a response body arrive.
+type firstByteReader struct {
+	r         io.Reader
+	firstByte time.Time
+}
+
+func (f *firstByteReader) Read(b []byte) (int, error) {
+	n, err := f.r.Read(b)
+	if n > 0 && f.firstByte.IsZero() {
+		f.firstByte = time.Now()
+	}
+	return n, err
+}
+
+// isStreamingResponse reports whether a call was streamed: a server-sent event or
+// newline-delimited JSON response (Ollama), or a request that asked for a stream.
+func isStreamingResponse(reqBody []byte, resp *http.Response) bool {
+	contentType := resp.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "text/event-stream") || strings.HasPrefix(contentType, "application/x-ndjson") {
+		return true
+	}
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(reqBody, &reqData); err != nil {
+		return false
+	}
+	stream, _ := reqData["stream"].(bool)
+	return stream
+}
+
+// streamMetrics computes the streaming metrics of a call from when it started, when the
+// first bytes of the response arrived, and when the response was complete. Throughput is
+// measured over the generation phase (after the first token), as clients experience it.
+func streamMetrics(start, firstByte, end time.Time, tokensOut int) *trace.StreamMetrics {
+	if firstByte.IsZero() {
+		firstByte = end
+	}
+	m := &trace.StreamMetrics{
+		TTFT:     firstByte.Sub(start) / time.Millisecond,
+		Duration: end.Sub(start) / time.Millisecond,
+	}
+	if generation := end.Sub(firstByte); tokensOut > 0 && generation > 0 {
+		m.TokensPerSec = float64(tokensOut) / generation.Seconds()
+	}
+	return m
+}
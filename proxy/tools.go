@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"encoding/json"
+
+	"github.com/matias/regrada/trace"
+)
+
+// extractToolDefinitions returns the names of the tools offered to the model in a request.
+// It understands OpenAI chat ({"type": "function", "function": {"name": ...}}), OpenAI
+// Responses and Anthropic ({"name": ...}), and Gemini ({"functionDeclarations": [...]}) formats.
+func extractToolDefinitions(reqBody []byte) []string {
+	var reqData map[string]interface{}
+	if err := json.Unmarshal(reqBody, &reqData); err != nil {
+		return nil
+	}
+
+	tools, ok := reqData["tools"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var names []string
+	for _, t := range tools {
+		tool, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if fn, ok := tool["function"].(map[string]interface{}); ok {
+			names = append(names, getString(fn, "name"))
+			continue
+		}
+		if decls, ok := tool["functionDeclarations"].([]interface{}); ok {
+			for _, d := range decls {
+				if decl, ok := d.(map[string]interface{}); ok {
+					names = append(names, getString(decl, "name"))
+				}
+			}
+			continue
+		}
+		if name := getString(tool, "name"); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// parseResponsesToolCalls extracts function calls from an OpenAI Responses API body,
+// where they appear as "function_call" items in the output array.
+func parseResponsesToolCalls(respData map[string]interface{}) []trace.ToolCall {
+	output, ok := respData["output"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var toolCalls []trace.ToolCall
+	for _, o := range output {
+		item, ok := o.(map[string]interface{})
+		if !ok || item["type"] != "function_call" {
+			continue
+		}
+		toolCall := trace.ToolCall{
+			ID:   getString(item, "call_id"),
+			Name: getString(item, "name"),
+		}
+		toolCall.Args = toolArgs(item["arguments"])
+		toolCalls = append(toolCalls, toolCall)
+	}
+	return toolCalls
+}
+
+// toolArgs converts tool call arguments to JSON. OpenAI-style providers send arguments as
+// a JSON-encoded string and Anthropic/Ollama as an object. A string that isn't valid JSON
+// (e.g. cut off by max_tokens) is kept as a JSON string, so it can't corrupt the trace file.
+func toolArgs(v interface{}) json.RawMessage {
+	switch args := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if json.Valid([]byte(args)) {
+			return json.RawMessage(args)
+		}
+		data, _ := json.Marshal(args)
+		return data
+	default:
+		data, err := json.Marshal(args)
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+}
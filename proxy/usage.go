@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+)
+
+// parseUsage extracts token counts from a decoded response body. It understands
+// OpenAI Chat Completions (prompt_tokens/completion_tokens), OpenAI Responses and
+// Anthropic (input_tokens/output_tokens), Ollama (prompt_eval_count/eval_count), and
+// Gemini (usageMetadata).
+func parseUsage(respData map[string]interface{}) (tokensIn, tokensOut int) {
+	if usage, ok := respData["usage"].(map[string]interface{}); ok {
+		tokensIn = firstCount(usage, "prompt_tokens", "input_tokens")
+		tokensOut = firstCount(usage, "completion_tokens", "output_tokens")
+		return
+	}
+	if usage, ok := respData["usageMetadata"].(map[string]interface{}); ok {
+		return firstCount(usage, "promptTokenCount"), firstCount(usage, "candidatesTokenCount")
+	}
+	return firstCount(respData, "prompt_eval_count"), firstCount(respData, "eval_count")
+}
+
+// firstCount returns the first of keys present in m as an int.
+func firstCount(m map[string]interface{}, keys ...string) int {
+	for _, key := range keys {
+		if v, ok := m[key].(float64); ok {
+			return int(v)
+		}
+	}
+	return 0
+}
+
+// parseStreamUsage extracts token counts from a server-sent event stream. Usage is
+// spread across events: OpenAI sends it in the final chunk (with stream_options.include_usage),
+// the Responses API in response.completed, Anthropic input tokens in message_start and
+// cumulative output tokens in message_delta, and Gemini in every chunk. The largest count
+// seen on each side wins. Ollama's newline-delimited JSON streams are read the same way.
+func parseStreamUsage(body []byte) (tokensIn, tokensOut int) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		line = bytes.TrimPrefix(line, []byte("data:"))
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		var event map[string]interface{}
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		// Responses API and Anthropic nest the usage-bearing object
+		for _, key := range []string{"response", "message"} {
+			if nested, ok := event[key].(map[string]interface{}); ok {
+				if _, ok := nested["usage"]; ok {
+					event = nested
+					break
+				}
+			}
+		}
+
+		in, out := parseUsage(event)
+		tokensIn = max(tokensIn, in)
+		tokensOut = max(tokensOut, out)
+	}
+	return
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Pattern is a named detector for a kind of sensitive data.
+type Pattern struct {
+	Kind   string
+	Secret bool // credentials, as opposed to personal data
+	Regexp *regexp.Regexp
+}
+
+// Patterns are the built-in detectors, checked in order.
+var Patterns = []Pattern{
+	{Kind: "private_key", Secret: true, Regexp: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{Kind: "anthropic_key", Secret: true, Regexp: regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{Kind: "openai_key", Secret: true, Regexp: regexp.MustCompile(`sk-(?:proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{Kind: "aws_access_key", Secret: true, Regexp: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Kind: "github_token", Secret: true, Regexp: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b`)},
+	{Kind: "google_api_key", Secret: true, Regexp: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}\b`)},
+	{Kind: "email", Regexp: regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`)},
+	{Kind: "credit_card", Regexp: regexp.MustCompile(`\b(?:\d[ \-]?){13,16}\b`)},
+	{Kind: "ssn", Regexp: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`)},
+	{Kind: "phone", Regexp: regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?\(?\b\d{3}\)?[ .\-]\d{3}[ .\-]\d{4}\b`)},
+}
+
+// Match is a single detection in a text.
+type Match struct {
+	Kind   string
+	Secret bool
+	Start  int
+	End    int
+	Value  string
+}
+
+// Find returns all non-overlapping detections in text, ordered by position.
+// When patterns overlap, the earlier pattern in Patterns wins.
+func Find(text string) []Match {
+	var matches []Match
+	taken := func(start, end int) bool {
+		for _, m := range matches {
+			if start < m.End && end > m.Start {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range Patterns {
+		for _, loc := range p.Regexp.FindAllStringIndex(text, -1) {
+			if taken(loc[0], loc[1]) {
+				continue
+			}
+			if p.Kind == "credit_card" && !luhnValid(text[loc[0]:loc[1]]) {
+				continue
+			}
+			matches = append(matches, Match{
+				Kind:   p.Kind,
+				Secret: p.Secret,
+				Start:  loc[0],
+				End:    loc[1],
+				Value:  text[loc[0]:loc[1]],
+			})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+	return matches
+}
+
+// Mask shortens a detected value for display, keeping only its first and last characters.
+func Mask(value string) string {
+	if len(value) <= 6 {
+		return "***"
+	}
+	return value[:2] + "***" + value[len(value)-2:]
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by card numbers.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// Placeholder returns the token that replaces a redacted value of the given kind, e.g. [REDACTED_EMAIL].
+func Placeholder(kind string) string {
+	return "[REDACTED_" + strings.ToUpper(kind) + "]"
+}
+
+// placeholderPattern matches redaction placeholders, with or without a kind.
+var placeholderPattern = regexp.MustCompile(`\[REDACTED(?:_[A-Z_]+)?\]`)
+
+// HasPlaceholder reports whether text contains a redaction placeholder.
+func HasPlaceholder(text string) bool {
+	return placeholderPattern.MatchString(text)
+}
+
+// Normalize replaces every detected value in text with its placeholder, so redacted and
+// unredacted copies of the same text compare equal.
+func Normalize(text string) string {
+	matches := Find(text)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		b.WriteString(Placeholder(m.Kind))
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
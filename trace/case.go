@@ -0,0 +1,18 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package trace
+
+// Trace-to-test correlation. Apps tag each LLM call with the test case it belongs to by
+// sending CaseHeader, typically set from the CaseEnv environment variable. The proxy
+// strips the header before forwarding and records its value in the trace metadata under
+// CaseMetadataKey, where test selectors look for it.
+const (
+	CaseHeader      = "X-Regrada-Case"
+	CaseEnv         = "REGRADA_CASE"
+	CaseMetadataKey = "case"
+)
+
+// Case returns the test case the call was tagged with, or "" if none.
+func (t *LLMTrace) Case() string {
+	return t.Metadata[CaseMetadataKey]
+}
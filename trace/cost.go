@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package trace
+
+import "strings"
+
+// ModelPrice is the price of a model in USD per 1,000 tokens.
+type ModelPrice struct {
+	InputPer1K  float64
+	OutputPer1K float64
+}
+
+// PriceTable maps model name prefixes to prices. The longest matching prefix wins,
+// so "gpt-4o-mini" can be priced apart from "gpt-4o".
+type PriceTable map[string]ModelPrice
+
+// Lookup returns the price of a model, or false if no prefix matches.
+func (p PriceTable) Lookup(model string) (ModelPrice, bool) {
+	best := -1
+	var price ModelPrice
+	for prefix, mp := range p {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, price = len(prefix), mp
+		}
+	}
+	return price, best >= 0
+}
+
+// ApplyPricing sets the estimated cost of each trace whose model is in the table and
+// returns the total. Traces of unpriced models keep a zero cost.
+func ApplyPricing(traces []LLMTrace, table PriceTable) float64 {
+	var total float64
+	for i := range traces {
+		price, ok := table.Lookup(traces[i].Model)
+		if !ok {
+			continue
+		}
+		traces[i].CostUSD = float64(traces[i].TokensIn)/1000*price.InputPer1K +
+			float64(traces[i].TokensOut)/1000*price.OutputPer1K
+		total += traces[i].CostUSD
+	}
+	return total
+}
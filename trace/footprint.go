@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package trace
+
+import "strings"
+
+// Footprint is the estimated energy use and carbon emissions of a set of calls.
+type Footprint struct {
+	EnergyWh    float64 `json:"energy_wh"`
+	CarbonGrams float64 `json:"carbon_g"`
+}
+
+// FootprintFactors are the coefficients used to estimate a footprint from token counts.
+type FootprintFactors struct {
+	WhPer1KTokens  float64            // Default energy per 1,000 tokens (input + output)
+	ModelWhPer1K   map[string]float64 // Per-model overrides, matched by longest model name prefix
+	GramsCO2PerKWh float64            // Grid carbon intensity
+}
+
+// EstimateFootprint estimates the energy and carbon cost of the given traces.
+func EstimateFootprint(traces []LLMTrace, factors FootprintFactors) Footprint {
+	var fp Footprint
+	for _, t := range traces {
+		tokens := float64(t.TokensIn + t.TokensOut)
+		fp.EnergyWh += tokens / 1000 * factors.whPer1K(t.Model)
+	}
+	fp.CarbonGrams = fp.EnergyWh / 1000 * factors.GramsCO2PerKWh
+	return fp
+}
+
+func (f FootprintFactors) whPer1K(model string) float64 {
+	best, rate := -1, f.WhPer1KTokens
+	for prefix, wh := range f.ModelWhPer1K {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, rate = len(prefix), wh
+		}
+	}
+	return rate
+}
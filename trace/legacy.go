@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// LegacyPath is the single session file earlier releases wrote by default, before
+// sessions were kept one file each in .regrada/traces.
+var LegacyPath = filepath.Join(".regrada", "traces.json")
+
+// ConvertLegacy moves the session at LegacyPath into dir as a session file of the
+// current format, recomputing its summary, and returns the new path. It returns ""
+// when there is no legacy session.
+func ConvertLegacy(dir string) (string, error) {
+	session, err := Load(LegacyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", LegacyPath, err)
+	}
+
+	if session.ID == "" {
+		session.ID = "legacy-" + session.StartTime.UTC().Format("20060102-150405")
+	}
+	session.Summary = CalculateSummary(session.Traces)
+
+	path := filepath.Join(dir, FileName(session.ID, false))
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("cannot convert %s: %s already exists", LegacyPath, path)
+	}
+	if err := Save(session, path); err != nil {
+		return "", err
+	}
+	return path, os.Remove(LegacyPath)
+}
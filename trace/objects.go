@@ -0,0 +1,207 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package trace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ObjectsDir is the directory, next to the session files, that holds request and
+// response bodies shared by several sessions. Each is stored once under its SHA-256.
+const ObjectsDir = "objects"
+
+// minSharedBody is the smallest body worth moving to the objects directory.
+const minSharedBody = 256
+
+// DedupeStats describes the work of DedupeBodies.
+type DedupeStats struct {
+	Sessions       int   // Session files rewritten
+	Bodies         int   // Inline bodies replaced by a reference
+	Objects        int   // Shared bodies, each stored once
+	BytesSaved     int64 // Bytes of inline bodies replaced, minus the objects written
+	RemovedObjects int   // Objects no session references any more
+}
+
+// objectPath returns where the body with the given hash is stored for a session file.
+func objectPath(sessionPath, ref string) string {
+	return filepath.Join(filepath.Dir(sessionPath), ObjectsDir, ref)
+}
+
+// resolveBodies reads the bodies a session stores by reference, so loaded sessions
+// always carry their bodies inline. The references are kept.
+func resolveBodies(session *TraceSession, path string) error {
+	resolve := func(body *json.RawMessage, ref *string) error {
+		if *ref == "" || len(*body) > 0 {
+			return nil
+		}
+		data, err := os.ReadFile(objectPath(path, *ref))
+		if err != nil {
+			return fmt.Errorf("missing shared body %s: %w", *ref, err)
+		}
+		*body = data
+		return nil
+	}
+	for i := range session.Traces {
+		tr := &session.Traces[i]
+		if err := resolve(&tr.Request.Body, &tr.Request.BodyRef); err != nil {
+			return err
+		}
+		if err := resolve(&tr.Response.Body, &tr.Response.BodyRef); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DedupeBodies stores request and response bodies that occur more than once across
+// the session files once in the objects directory, and rewrites the sessions to
+// reference them. Sessions keep their IDs, timings, and modification times, so run
+// history is unchanged. Bodies that are no longer shared are written back inline, and
+// objects no session references are removed. With dryRun, nothing is written.
+func DedupeBodies(files []string, dryRun bool) (DedupeStats, error) {
+	var stats DedupeStats
+
+	type loaded struct {
+		path    string
+		session *TraceSession
+		modTime time.Time
+	}
+	var sessions []loaded
+	counts := make(map[string]int)
+	complete := true
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			complete = false
+			continue
+		}
+		session, err := Load(file)
+		if err != nil {
+			complete = false
+			continue
+		}
+		sessions = append(sessions, loaded{file, session, info.ModTime()})
+		for _, tr := range session.Traces {
+			for _, body := range [][]byte{tr.Request.Body, tr.Response.Body} {
+				if len(body) >= minSharedBody {
+					counts[bodyHash(body)]++
+				}
+			}
+		}
+	}
+
+	referenced := make(map[string]bool)
+	for _, s := range sessions {
+		changed := false
+		share := func(body *json.RawMessage, ref *string) error {
+			var hash string
+			if len(*body) >= minSharedBody {
+				hash = bodyHash(*body)
+			}
+			if counts[hash] < 2 {
+				if *ref != "" {
+					// No longer shared, so it goes back inline
+					*ref = ""
+					changed = true
+				}
+				return nil
+			}
+			if !referenced[hash] {
+				referenced[hash] = true
+				stats.Objects++
+				path := objectPath(s.path, hash)
+				if _, err := os.Stat(path); err != nil {
+					stats.BytesSaved -= int64(len(*body))
+					if !dryRun {
+						if err := writeObject(path, *body); err != nil {
+							return err
+						}
+					}
+				}
+			}
+			if *ref != hash {
+				stats.Bodies++
+				stats.BytesSaved += int64(len(*body))
+				changed = true
+			}
+			*body, *ref = nil, hash
+			return nil
+		}
+		for i := range s.session.Traces {
+			tr := &s.session.Traces[i]
+			if err := share(&tr.Request.Body, &tr.Request.BodyRef); err != nil {
+				return stats, err
+			}
+			if err := share(&tr.Response.Body, &tr.Response.BodyRef); err != nil {
+				return stats, err
+			}
+		}
+		if !changed {
+			continue
+		}
+		stats.Sessions++
+		if dryRun {
+			continue
+		}
+		if err := Save(s.session, s.path); err != nil {
+			return stats, err
+		}
+		// Latest-session lookups go by modification time
+		if err := os.Chtimes(s.path, s.modTime, s.modTime); err != nil {
+			return stats, err
+		}
+	}
+
+	// Objects are only removed once every session has been rewritten, so an
+	// interrupted run never leaves a dangling reference. A session that couldn't be
+	// read may still reference any of them.
+	if !complete {
+		return stats, nil
+	}
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		dirs[filepath.Join(filepath.Dir(file), ObjectsDir)] = true
+	}
+	for dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || referenced[entry.Name()] {
+				continue
+			}
+			stats.RemovedObjects++
+			if !dryRun {
+				if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+					return stats, err
+				}
+			}
+		}
+	}
+	return stats, nil
+}
+
+// writeObject stores a shared body, writing it to a temporary file first so a
+// partial write is never referenced.
+func writeObject(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func bodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
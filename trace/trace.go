@@ -3,10 +3,16 @@
 package trace
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -25,6 +31,54 @@ type LLMTrace struct {
 	TokensIn  int               `json:"tokens_in,omitempty"`
 	TokensOut int               `json:"tokens_out,omitempty"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	// CostUSD is the estimated cost of the call from the configured pricing table.
+	CostUSD float64 `json:"cost_usd,omitempty"`
+
+	// FinishReason is the normalized reason generation stopped: stop, length,
+	// tool_calls, or content_filter. Unrecognized provider values are kept as-is.
+	FinishReason string `json:"finish_reason,omitempty"`
+
+	// ContentFiltered is set when the provider blocked or refused the response
+	// (OpenAI/Azure content filter, Anthropic refusal).
+	ContentFiltered bool `json:"content_filtered,omitempty"`
+
+	// Thinking holds the text of Anthropic extended thinking blocks, kept apart
+	// from the assistant's answer. RedactedThinking counts redacted_thinking blocks.
+	Thinking         string `json:"thinking,omitempty"`
+	RedactedThinking int    `json:"redacted_thinking,omitempty"`
+
+	// ToolsAvailable lists the names of the tools offered to the model in the request.
+	ToolsAvailable []string `json:"tools_available,omitempty"`
+
+	// Attachments lists the file, document, and image inputs sent with the request.
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Stream is set for streamed responses.
+	Stream *StreamMetrics `json:"stream,omitempty"`
+
+	// Retries counts failed attempts the proxy retried before this response
+	// (provider.retry). Latency covers the final attempt only.
+	Retries int `json:"retries,omitempty"`
+}
+
+// StreamMetrics describes a streamed response. Total latency hides streaming regressions,
+// since a slower first token can be offset by faster generation.
+type StreamMetrics struct {
+	TTFT         time.Duration `json:"ttft_ms"`     // Time to first token, in milliseconds
+	Duration     time.Duration `json:"duration_ms"` // Total stream duration, in milliseconds
+	TokensPerSec float64       `json:"tokens_per_sec,omitempty"`
+}
+
+// Attachment describes a file input found in a request. Inline data is identified
+// by its SHA-256 digest; URL references keep the URL instead.
+type Attachment struct {
+	Kind      string `json:"kind"` // document, image, or file
+	MediaType string `json:"media_type,omitempty"`
+	Filename  string `json:"filename,omitempty"`
+	Size      int    `json:"size,omitempty"` // Decoded bytes
+	SHA256    string `json:"sha256,omitempty"`
+	URL       string `json:"url,omitempty"`
 }
 
 // TraceRequest contains the HTTP request details of an LLM API call.
@@ -33,6 +87,10 @@ type TraceRequest struct {
 	Path    string            `json:"path"`
 	Headers map[string]string `json:"headers,omitempty"`
 	Body    json.RawMessage   `json:"body,omitempty"`
+
+	// BodyRef is the SHA-256 of a body stored once in the objects directory (see
+	// DedupeBodies) instead of inline. Load reads the body back into Body.
+	BodyRef string `json:"body_ref,omitempty"`
 }
 
 // TraceResponse contains the HTTP response details of an LLM API call.
@@ -40,6 +98,7 @@ type TraceResponse struct {
 	StatusCode int               `json:"status_code"`
 	Headers    map[string]string `json:"headers,omitempty"`
 	Body       json.RawMessage   `json:"body,omitempty"`
+	BodyRef    string            `json:"body_ref,omitempty"` // See TraceRequest.BodyRef
 }
 
 // ToolCall represents a function/tool invocation by the LLM.
@@ -52,12 +111,13 @@ type ToolCall struct {
 
 // TraceSession holds all traces from a single run.
 type TraceSession struct {
-	ID        string       `json:"id"`
-	StartTime time.Time    `json:"start_time"`
-	EndTime   time.Time    `json:"end_time"`
-	Command   string       `json:"command"`
-	Traces    []LLMTrace   `json:"traces"`
-	Summary   TraceSummary `json:"summary"`
+	ID        string            `json:"id"`
+	StartTime time.Time         `json:"start_time"`
+	EndTime   time.Time         `json:"end_time"`
+	Command   string            `json:"command"`
+	Traces    []LLMTrace        `json:"traces"`
+	Summary   TraceSummary      `json:"summary"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
 }
 
 // TraceSummary aggregates statistics from all traces in a session.
@@ -69,17 +129,42 @@ type TraceSummary struct {
 	ByProvider     map[string]int `json:"by_provider"`
 	ByModel        map[string]int `json:"by_model"`
 	ToolsCalled    []string       `json:"tools_called"`
+
+	ContentFiltered int `json:"content_filtered,omitempty"`
+	Truncated       int `json:"truncated,omitempty"` // finish_reason "length"
+
+	// Streamed counts streamed calls; TTFTP50 and TTFTP95 are their time-to-first-token
+	// percentiles in milliseconds.
+	Streamed int           `json:"streamed,omitempty"`
+	TTFTP50  time.Duration `json:"ttft_p50_ms,omitempty"`
+	TTFTP95  time.Duration `json:"ttft_p95_ms,omitempty"`
+
+	// Footprint is set when sustainability reporting is enabled.
+	Footprint *Footprint `json:"footprint,omitempty"`
+
+	// TotalCostUSD is the estimated cost of all calls, set when a pricing table is configured.
+	TotalCostUSD float64 `json:"total_cost_usd,omitempty"`
+
+	// Retried counts calls that needed retries; Retries is the total number of retries.
+	Retried int `json:"retried,omitempty"`
+	Retries int `json:"retries,omitempty"`
 }
 
 // Comparison represents the difference between a current session and a baseline.
 type Comparison struct {
-	CallCountChanged bool                       `json:"CallCountChanged"`
-	BaselineCount    int                        `json:"BaselineCount"`
-	CurrentCount     int                        `json:"CurrentCount"`
-	NewTools         []string                   `json:"NewTools"`
-	RemovedTools     []string                   `json:"RemovedTools"`
-	ModelChanges     map[string]ModelChange     `json:"ModelChanges"`
-	TokenDiff        int                        `json:"TokenDiff"`
+	CallCountChanged bool                   `json:"CallCountChanged"`
+	BaselineCount    int                    `json:"BaselineCount"`
+	CurrentCount     int                    `json:"CurrentCount"`
+	NewTools         []string               `json:"NewTools"`
+	RemovedTools     []string               `json:"RemovedTools"`
+	ModelChanges     map[string]ModelChange `json:"ModelChanges"`
+	TokenDiff        int                    `json:"TokenDiff"`
+
+	BaselineContentFiltered int `json:"BaselineContentFiltered"`
+	CurrentContentFiltered  int `json:"CurrentContentFiltered"`
+
+	BaselineTruncatedRate float64 `json:"BaselineTruncatedRate"`
+	CurrentTruncatedRate  float64 `json:"CurrentTruncatedRate"`
 }
 
 // ModelChange represents a change in model usage.
@@ -90,7 +175,19 @@ type ModelChange struct {
 	IsNew         bool   `json:"IsNew"`
 }
 
+// CompressedExt is the file extension of gzip-compressed trace sessions.
+const CompressedExt = ".gz"
+
+// FileName returns the file name for a session, with the compressed extension when compress is set.
+func FileName(id string, compress bool) string {
+	if compress {
+		return id + ".json" + CompressedExt
+	}
+	return id + ".json"
+}
+
 // Save writes a trace session to a file in JSON format.
+// Paths ending in .gz are written gzip-compressed.
 func Save(session *TraceSession, path string) error {
 	// Ensure directory exists
 	dir := filepath.Dir(path)
@@ -103,20 +200,48 @@ func Save(session *TraceSession, path string) error {
 		return err
 	}
 
+	if strings.HasSuffix(path, CompressedExt) {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(data); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
 	return os.WriteFile(path, data, 0644)
 }
 
 // Load reads a trace session from a file.
+// Gzip-compressed files are detected and decompressed transparently.
 func Load(path string) (*TraceSession, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(gz)
+		gz.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var session TraceSession
 	if err := json.Unmarshal(data, &session); err != nil {
 		return nil, err
 	}
+	if err := resolveBodies(&session, path); err != nil {
+		return nil, err
+	}
 
 	return &session, nil
 }
@@ -136,6 +261,12 @@ func Compare(current *TraceSession, baselinePath string) (*Comparison, error) {
 		NewTools:         []string{},
 		RemovedTools:     []string{},
 		ModelChanges:     make(map[string]ModelChange),
+
+		BaselineContentFiltered: baseline.Summary.ContentFiltered,
+		CurrentContentFiltered:  current.Summary.ContentFiltered,
+
+		BaselineTruncatedRate: truncatedRate(baseline.Summary),
+		CurrentTruncatedRate:  truncatedRate(current.Summary),
 	}
 
 	// Compare tools called
@@ -161,6 +292,8 @@ func Compare(current *TraceSession, baselinePath string) (*Comparison, error) {
 			comp.RemovedTools = append(comp.RemovedTools, tool)
 		}
 	}
+	sort.Strings(comp.NewTools)
+	sort.Strings(comp.RemovedTools)
 
 	// Compare models
 	for model, count := range current.Summary.ByModel {
@@ -190,6 +323,14 @@ func Compare(current *TraceSession, baselinePath string) (*Comparison, error) {
 	return comp, nil
 }
 
+// truncatedRate returns the fraction of calls that stopped because they hit the token limit.
+func truncatedRate(summary TraceSummary) float64 {
+	if summary.TotalCalls == 0 {
+		return 0
+	}
+	return float64(summary.Truncated) / float64(summary.TotalCalls)
+}
+
 // CalculateSummary aggregates statistics from a list of traces.
 func CalculateSummary(traces []LLMTrace) TraceSummary {
 	summary := TraceSummary{
@@ -200,6 +341,7 @@ func CalculateSummary(traces []LLMTrace) TraceSummary {
 	}
 
 	toolSet := make(map[string]bool)
+	var ttfts []time.Duration
 
 	for _, t := range traces {
 		summary.TotalTokensIn += t.TokensIn
@@ -212,15 +354,42 @@ func CalculateSummary(traces []LLMTrace) TraceSummary {
 		for _, tc := range t.ToolCalls {
 			toolSet[tc.Name] = true
 		}
+		if t.ContentFiltered {
+			summary.ContentFiltered++
+		}
+		if t.FinishReason == "length" {
+			summary.Truncated++
+		}
+		if t.Stream != nil {
+			ttfts = append(ttfts, t.Stream.TTFT)
+		}
+		if t.Retries > 0 {
+			summary.Retried++
+			summary.Retries += t.Retries
+		}
+	}
+
+	if len(ttfts) > 0 {
+		sort.Slice(ttfts, func(i, j int) bool { return ttfts[i] < ttfts[j] })
+		summary.Streamed = len(ttfts)
+		summary.TTFTP50 = percentile(ttfts, 0.50)
+		summary.TTFTP95 = percentile(ttfts, 0.95)
 	}
 
 	for tool := range toolSet {
 		summary.ToolsCalled = append(summary.ToolsCalled, tool)
 	}
+	sort.Strings(summary.ToolsCalled)
 
 	return summary
 }
 
+// percentile returns the nearest-rank percentile q (0-1) of sorted durations.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	return sorted[max(rank, 0)]
+}
+
 // PrintSummary displays a trace session summary to stdout.
 // This is a helper function for command-line output.
 func PrintSummary(session *TraceSession) {
@@ -240,11 +409,11 @@ func PrintSummary(session *TraceSession) {
 	if len(summary.ByProvider) > 0 {
 		fmt.Print("    Providers: ")
 		first := true
-		for provider, count := range summary.ByProvider {
+		for _, provider := range SortedKeys(summary.ByProvider) {
 			if !first {
 				fmt.Print(", ")
 			}
-			fmt.Printf("%s (%d)", provider, count)
+			fmt.Printf("%s (%d)", provider, summary.ByProvider[provider])
 			first = false
 		}
 		fmt.Println()
@@ -253,11 +422,11 @@ func PrintSummary(session *TraceSession) {
 	if len(summary.ByModel) > 0 {
 		fmt.Print("    Models: ")
 		first := true
-		for model, count := range summary.ByModel {
+		for _, model := range SortedKeys(summary.ByModel) {
 			if !first {
 				fmt.Print(", ")
 			}
-			fmt.Printf("%s (%d)", model, count)
+			fmt.Printf("%s (%d)", model, summary.ByModel[model])
 			first = false
 		}
 		fmt.Println()
@@ -269,6 +438,31 @@ func PrintSummary(session *TraceSession) {
 
 	fmt.Printf("    Total latency: %dms\n", summary.TotalLatency.Milliseconds())
 
+	if summary.Streamed > 0 {
+		fmt.Printf("    Streamed: %d (TTFT p50 %dms, p95 %dms)\n", summary.Streamed, summary.TTFTP50, summary.TTFTP95)
+	}
+
+	if summary.TotalCostUSD > 0 {
+		fmt.Printf("    Estimated cost: $%.4f\n", summary.TotalCostUSD)
+	}
+
+	if summary.Footprint != nil {
+		fmt.Printf("    Estimated footprint: %.2f Wh, %.2f g CO2e\n", summary.Footprint.EnergyWh, summary.Footprint.CarbonGrams)
+	}
+
+	if summary.Retried > 0 {
+		fmt.Printf("    Retried: %d calls (%d retries)\n", summary.Retried, summary.Retries)
+	}
+
+	if summary.Truncated > 0 {
+		fmt.Printf("    Truncated (length): %d/%d\n", summary.Truncated, summary.TotalCalls)
+	}
+
+	if summary.ContentFiltered > 0 {
+		fmt.Printf("    Content filtered: %d/%d (%.0f%%)\n", summary.ContentFiltered, summary.TotalCalls,
+			100*float64(summary.ContentFiltered)/float64(summary.TotalCalls))
+	}
+
 	if len(summary.ToolsCalled) > 0 {
 		fmt.Print("    Tools called: ")
 		first := true
@@ -308,7 +502,13 @@ func PrintComparison(comp *Comparison) {
 	}
 
 	// Model changes
-	for _, change := range comp.ModelChanges {
+	models := make([]string, 0, len(comp.ModelChanges))
+	for model := range comp.ModelChanges {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		change := comp.ModelChanges[model]
 		if change.IsNew {
 			fmt.Printf("    ⚠ New model used: %s\n", change.Model)
 		} else {
@@ -327,4 +527,26 @@ func PrintComparison(comp *Comparison) {
 		}
 		fmt.Printf("    ⚠ Token usage %s by %d\n", direction, diff)
 	}
+
+	// Truncation rate
+	if comp.CurrentTruncatedRate > comp.BaselineTruncatedRate {
+		fmt.Printf("    ⚠ Length-truncated responses increased: %.0f%% → %.0f%%\n",
+			100*comp.BaselineTruncatedRate, 100*comp.CurrentTruncatedRate)
+	}
+
+	// Content filter rate
+	if comp.CurrentContentFiltered > comp.BaselineContentFiltered {
+		fmt.Printf("    ⚠ Content-filtered responses increased: %d → %d\n",
+			comp.BaselineContentFiltered, comp.CurrentContentFiltered)
+	}
+}
+
+// SortedKeys returns the keys of a count map in lexical order, so output built from it is stable.
+func SortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package trace
+
+import "strings"
+
+// Distributed trace correlation. Apps instrumented with OpenTelemetry (or anything else
+// following W3C Trace Context) send a traceparent header with each outgoing request.
+// The proxy forwards it unchanged and records its trace and span IDs in the trace
+// metadata, so a capture can be looked up in the backend's tracing system.
+const (
+	TraceparentHeader  = "Traceparent"
+	TraceIDMetadataKey = "trace_id"
+	SpanIDMetadataKey  = "span_id"
+)
+
+// ParseTraceparent returns the trace ID and parent span ID of a W3C traceparent header
+// (version-traceid-spanid-flags). It reports false for malformed values and the
+// all-zero IDs the spec marks invalid.
+func ParseTraceparent(value string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return "", "", false
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	// Version 00 has exactly four fields; later versions may append more
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	if !isHex(traceID, 32) || !isHex(spanID, 16) || !isHex(flags, 2) {
+		return "", "", false
+	}
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, spanID, true
+}
+
+// isHex reports whether s is n lowercase hex digits.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// TraceID returns the distributed trace ID the call was made in, or "" if none.
+func (t *LLMTrace) TraceID() string {
+	return t.Metadata[TraceIDMetadataKey]
+}
+
+// SpanID returns the ID of the app's span that made the call, or "" if none.
+func (t *LLMTrace) SpanID() string {
+	return t.Metadata[SpanIDMetadataKey]
+}
@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package vcs
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Commit describes a single git commit.
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+}
+
+// CommitsSince returns commits after the given time that touched any of the paths,
+// newest first. At most limit commits are returned when limit is positive.
+func CommitsSince(since time.Time, paths []string, limit int) ([]Commit, error) {
+	args := []string{"log", "--format=%H%x1f%an%x1f%aI%x1f%s", "--since=" + since.Format(time.RFC3339)}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	args = append(args, "--")
+	args = append(args, paths...)
+
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		commits = append(commits, Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Subject: fields[3],
+		})
+	}
+
+	return commits, nil
+}
+
+// Revision identifies the commit checked out in the working directory.
+type Revision struct {
+	SHA    string `json:"sha"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// CurrentRevision returns the checked-out commit and branch. CI systems usually check
+// out a detached HEAD, so the branch then comes from their environment when known.
+func CurrentRevision() (Revision, error) {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return Revision{}, fmt.Errorf("git rev-parse failed: %w", err)
+	}
+	rev := Revision{SHA: strings.TrimSpace(string(out))}
+
+	if out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output(); err == nil {
+		rev.Branch = strings.TrimSpace(string(out))
+	}
+	if rev.Branch == "" || rev.Branch == "HEAD" {
+		rev.Branch = ""
+		for _, key := range []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME"} {
+			if branch := os.Getenv(key); branch != "" {
+				rev.Branch = branch
+				break
+			}
+		}
+	}
+	return rev, nil
+}
+
+// CIRunURL returns the web page of the current CI run, where its logs and artifacts
+// are, on GitHub Actions (the workflow run) and GitLab CI (the job). It returns ""
+// elsewhere.
+func CIRunURL() string {
+	if server, repo, id := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"); server != "" && repo != "" && id != "" {
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, id)
+	}
+	return os.Getenv("CI_JOB_URL")
+}
+
+// Stage adds the files to the git index.
+func Stage(paths ...string) error {
+	args := append([]string{"add", "--"}, paths...)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git add failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package vcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommentMarker identifies the sticky comment Regrada keeps on a pull request or merge
+// request, so each run updates it instead of adding another.
+const CommentMarker = "<!-- regrada-report -->"
+
+var pullRef = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// PullRequest is the GitHub pull request a workflow run belongs to.
+type PullRequest struct {
+	Repo   string // owner/name
+	Number int
+}
+
+// CurrentPullRequest finds the pull request of the GitHub Actions run from its event
+// payload (GITHUB_EVENT_PATH), falling back to a refs/pull/<n>/ GITHUB_REF. It reports
+// false outside GitHub Actions and for runs that aren't for a pull request.
+func CurrentPullRequest() (PullRequest, bool) {
+	repo := os.Getenv("GITHUB_REPOSITORY")
+	if os.Getenv("GITHUB_ACTIONS") != "true" || repo == "" {
+		return PullRequest{}, false
+	}
+
+	if data, err := os.ReadFile(os.Getenv("GITHUB_EVENT_PATH")); err == nil {
+		var event struct {
+			Number      int `json:"number"`
+			PullRequest *struct {
+				Number int `json:"number"`
+			} `json:"pull_request"`
+			Issue *struct {
+				Number      int              `json:"number"`
+				PullRequest *json.RawMessage `json:"pull_request"`
+			} `json:"issue"`
+		}
+		if json.Unmarshal(data, &event) == nil {
+			switch {
+			case event.PullRequest != nil && event.PullRequest.Number > 0:
+				return PullRequest{Repo: repo, Number: event.PullRequest.Number}, true
+			case event.Issue != nil && event.Issue.PullRequest != nil && event.Issue.Number > 0:
+				// issue_comment events on a pull request
+				return PullRequest{Repo: repo, Number: event.Issue.Number}, true
+			}
+		}
+	}
+
+	if m := pullRef.FindStringSubmatch(os.Getenv("GITHUB_REF")); m != nil {
+		number, _ := strconv.Atoi(m[1])
+		return PullRequest{Repo: repo, Number: number}, true
+	}
+	return PullRequest{}, false
+}
+
+// GitHubClient posts to the GitHub REST API with the workflow's GITHUB_TOKEN.
+type GitHubClient struct {
+	apiURL     string
+	token      string
+	httpClient *http.Client
+}
+
+// NewGitHubClient creates a client for the API in GITHUB_API_URL (api.github.com by
+// default, set by GitHub Enterprise runners) authenticated with GITHUB_TOKEN.
+func NewGitHubClient() (*GitHubClient, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GITHUB_TOKEN is not set")
+	}
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	return &GitHubClient{
+		apiURL:     strings.TrimSuffix(apiURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type issueComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// UpsertComment keeps a single comment holding body on the pull request: the comment
+// carrying CommentMarker is edited when there is one, and created otherwise. It
+// returns the comment's URL.
+func (c *GitHubClient) UpsertComment(pr PullRequest, body string) (string, error) {
+	body = CommentMarker + "\n" + body
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := c.findComment(pr)
+	if err != nil {
+		return "", err
+	}
+
+	method, path := http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", pr.Repo, pr.Number)
+	if existing != nil {
+		method, path = http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", pr.Repo, existing.ID)
+	}
+	var posted struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := c.do(method, path, payload, &posted); err != nil {
+		return "", err
+	}
+	return posted.HTMLURL, nil
+}
+
+// actionsBot is the user GITHUB_TOKEN posts as.
+const actionsBot = "github-actions[bot]"
+
+// findComment returns the pull request's comment carrying CommentMarker, if any. Only
+// comments posted by the token's own user count, so a person or another bot quoting the
+// report never has their comment overwritten.
+func (c *GitHubClient) findComment(pr PullRequest) (*issueComment, error) {
+	// Installation tokens such as GITHUB_TOKEN can't read /user; they post as actionsBot
+	var self struct {
+		Login string `json:"login"`
+	}
+	if err := c.do(http.MethodGet, "/user", nil, &self); err != nil || self.Login == "" {
+		self.Login = actionsBot
+	}
+
+	const perPage = 100
+	for page := 1; ; page++ {
+		var comments []issueComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", pr.Repo, pr.Number, perPage, page)
+		if err := c.do(http.MethodGet, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			comment := &comments[i]
+			if !strings.HasPrefix(comment.Body, CommentMarker) {
+				continue
+			}
+			if comment.User.Login == self.Login {
+				return comment, nil
+			}
+		}
+		if len(comments) < perPage {
+			return nil, nil
+		}
+	}
+}
+
+func (c *GitHubClient) do(method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, c.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("github returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
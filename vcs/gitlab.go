@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: LicenseRef-Regrada-Proprietary
+
+package vcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// MergeRequest is the GitLab merge request a merge request pipeline runs for.
+type MergeRequest struct {
+	APIURL  string // e.g. https://gitlab.com/api/v4
+	Project string // Project ID
+	IID     string
+	WebURL  string // Project URL, for linking the note
+}
+
+// CurrentMergeRequest finds the merge request of the GitLab CI job from the predefined
+// CI_MERGE_REQUEST_* variables. It reports false outside GitLab CI and for pipelines
+// that aren't merge request pipelines.
+func CurrentMergeRequest() (MergeRequest, bool) {
+	if os.Getenv("GITLAB_CI") != "true" {
+		return MergeRequest{}, false
+	}
+	mr := MergeRequest{
+		APIURL:  os.Getenv("CI_API_V4_URL"),
+		Project: os.Getenv("CI_MERGE_REQUEST_PROJECT_ID"),
+		IID:     os.Getenv("CI_MERGE_REQUEST_IID"),
+		WebURL:  os.Getenv("CI_MERGE_REQUEST_PROJECT_URL"),
+	}
+	if mr.Project == "" {
+		mr.Project = os.Getenv("CI_PROJECT_ID")
+	}
+	if mr.APIURL == "" && os.Getenv("CI_SERVER_URL") != "" {
+		mr.APIURL = strings.TrimSuffix(os.Getenv("CI_SERVER_URL"), "/") + "/api/v4"
+	}
+	if mr.IID == "" || mr.Project == "" || mr.APIURL == "" {
+		return MergeRequest{}, false
+	}
+	return mr, true
+}
+
+// GitLabClient posts merge request notes with a token from GITLAB_TOKEN. The job
+// token (CI_JOB_TOKEN) can't write notes, so this is a project or personal access
+// token with the api scope.
+type GitLabClient struct {
+	token      string
+	httpClient *http.Client
+}
+
+// NewGitLabClient creates a client authenticated with GITLAB_TOKEN.
+func NewGitLabClient() (*GitLabClient, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("GITLAB_TOKEN is not set")
+	}
+	return &GitLabClient{
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+type mergeRequestNote struct {
+	ID     int64  `json:"id"`
+	Body   string `json:"body"`
+	Author struct {
+		ID int64 `json:"id"`
+	} `json:"author"`
+}
+
+// UpsertNote keeps a single note holding body on the merge request: the note carrying
+// CommentMarker is edited when there is one, and created otherwise. It returns the
+// note's URL.
+func (c *GitLabClient) UpsertNote(mr MergeRequest, body string) (string, error) {
+	body = CommentMarker + "\n" + body
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := c.findNote(mr)
+	if err != nil {
+		return "", err
+	}
+
+	notes := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes", strings.TrimSuffix(mr.APIURL, "/"), url.PathEscape(mr.Project), mr.IID)
+	method, endpoint := http.MethodPost, notes
+	if existing != nil {
+		method, endpoint = http.MethodPut, fmt.Sprintf("%s/%d", notes, existing.ID)
+	}
+	var posted mergeRequestNote
+	if err := c.do(method, endpoint, payload, &posted); err != nil {
+		return "", err
+	}
+	if mr.WebURL == "" {
+		return fmt.Sprintf("merge request !%s", mr.IID), nil
+	}
+	return fmt.Sprintf("%s/-/merge_requests/%s#note_%d", mr.WebURL, mr.IID, posted.ID), nil
+}
+
+// findNote returns the merge request's note carrying CommentMarker, if any. Only notes
+// written by the token's own user count, so a person quoting the report never has their
+// note overwritten.
+func (c *GitLabClient) findNote(mr MergeRequest) (*mergeRequestNote, error) {
+	var self struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.do(http.MethodGet, strings.TrimSuffix(mr.APIURL, "/")+"/user", nil, &self); err != nil {
+		return nil, fmt.Errorf("failed to look up the GITLAB_TOKEN user: %w", err)
+	}
+
+	const perPage = 100
+	for page := 1; ; page++ {
+		var notes []mergeRequestNote
+		endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%s/notes?per_page=%d&page=%d",
+			strings.TrimSuffix(mr.APIURL, "/"), url.PathEscape(mr.Project), mr.IID, perPage, page)
+		if err := c.do(http.MethodGet, endpoint, nil, &notes); err != nil {
+			return nil, err
+		}
+		for i := range notes {
+			if strings.HasPrefix(notes[i].Body, CommentMarker) && notes[i].Author.ID == self.ID {
+				return &notes[i], nil
+			}
+		}
+		if len(notes) < perPage {
+			return nil, nil
+		}
+	}
+}
+
+func (c *GitLabClient) do(method, endpoint string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("gitlab returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}